@@ -3,6 +3,8 @@ module github.com/cperez08/dm-resolver
 go 1.15
 
 require (
+	github.com/golang/protobuf v1.3.3
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/net v0.0.0-20190311183353-d8887717615a
 	google.golang.org/grpc v1.32.0
 )
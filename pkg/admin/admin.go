@@ -0,0 +1,192 @@
+// Package admin implements a small gRPC service that applications can
+// mount on their existing gRPC server to introspect and control the
+// embedded resolvers remotely, see admin.proto for the definition
+package admin
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+	"github.com/golang/protobuf/ptypes/empty"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the dmresolver.admin.v1.Admin service
+type Server struct {
+	resolvers map[string]*resolver.DomainResolver
+}
+
+// NewServer creates a new admin server for the given
+// resolvers, they are identified by their address
+func NewServer(resolvers ...*resolver.DomainResolver) *Server {
+	s := &Server{resolvers: map[string]*resolver.DomainResolver{}}
+	for _, r := range resolvers {
+		s.resolvers[r.Status().Address] = r
+	}
+
+	return s
+}
+
+// Register registers the admin service in the gRPC server
+func (s *Server) Register(gs *grpc.Server) {
+	gs.RegisterService(&serviceDesc, s)
+}
+
+// ListTargets returns the watched targets
+func (s *Server) ListTargets(ctx context.Context, _ *empty.Empty) (*structpb.Struct, error) {
+	targets := []string{}
+	for t := range s.resolvers {
+		targets = append(targets, t)
+	}
+	sort.Strings(targets)
+
+	return &structpb.Struct{Fields: map[string]*structpb.Value{"targets": listValue(targets)}}, nil
+}
+
+// GetTarget returns the status of the given target
+func (s *Server) GetTarget(ctx context.Context, target *wrappers.StringValue) (*structpb.Struct, error) {
+	r, ok := s.resolvers[target.GetValue()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown target %q", target.GetValue())
+	}
+
+	return statusStruct(r.Status()), nil
+}
+
+// ForceRefresh resolves the given target right away and returns its status
+func (s *Server) ForceRefresh(ctx context.Context, target *wrappers.StringValue) (*structpb.Struct, error) {
+	r, ok := s.resolvers[target.GetValue()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown target %q", target.GetValue())
+	}
+
+	r.Refresh()
+	return statusStruct(r.Status()), nil
+}
+
+// SetQueryLog enables or disables the DNS query log of the target given
+// as {"target": ..., "enabled": true}, the resolver needs WithQueryLog
+func (s *Server) SetQueryLog(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	target := req.GetFields()["target"].GetStringValue()
+	r, ok := s.resolvers[target]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown target %q", target)
+	}
+
+	r.SetQueryLog(req.GetFields()["enabled"].GetBoolValue())
+	if !r.QueryLogEnabled() && req.GetFields()["enabled"].GetBoolValue() {
+		return nil, status.Errorf(codes.FailedPrecondition, "query log not configured for %q", target)
+	}
+
+	st := statusStruct(r.Status())
+	st.Fields["query_log"] = &structpb.Value{Kind: &structpb.Value_BoolValue{BoolValue: r.QueryLogEnabled()}}
+	return st, nil
+}
+
+// StreamChanges streams the address list of every target when it
+// changes, the current lists are sent when the stream starts
+func (s *Server) StreamChanges(_ *empty.Empty, stream grpc.ServerStream) error {
+	changes := make(chan *structpb.Struct)
+	ctx := stream.Context()
+	for target, r := range s.resolvers {
+		go forward(ctx, target, r, changes)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case c := <-changes:
+			if err := stream.SendMsg(c); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// forward sends the address lists of the resolver to
+// the changes channel until the context is done
+func forward(ctx context.Context, target string, r *resolver.DomainResolver, changes chan<- *structpb.Struct) {
+	ch := r.Watch()
+	defer r.Unwatch(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case addrs, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			c := &structpb.Struct{Fields: map[string]*structpb.Value{
+				"target":    stringValue(target),
+				"addresses": listValue(addrs),
+			}}
+
+			select {
+			case changes <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func statusStruct(st resolver.Status) *structpb.Struct {
+	return &structpb.Struct{Fields: map[string]*structpb.Value{
+		"target":     stringValue(st.Address),
+		"addresses":  listValue(st.Addresses),
+		"changes":    numberValue(float64(st.Churn.Changes)),
+		"invalid":    numberValue(float64(st.Invalid)),
+		"provenance": provenanceValue(st.Provenance),
+		"dns":        listValue(answerStrings(st.DNSAnswers)),
+	}}
+}
+
+// answerStrings describes the DNS answers of the last resolution
+func answerStrings(answers []resolver.DNSAnswer) []string {
+	rs := make([]string, 0, len(answers))
+	for _, a := range answers {
+		rs = append(rs, a.String())
+	}
+
+	return rs
+}
+
+// provenanceValue returns the provenance as a struct keyed by address
+func provenanceValue(provenance map[string]resolver.Provenance) *structpb.Value {
+	fields := make(map[string]*structpb.Value, len(provenance))
+	for addr, p := range provenance {
+		fields[addr] = &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"source":     stringValue(p.Source),
+			"first_seen": stringValue(p.FirstSeen.Format(time.RFC3339)),
+			"last_seen":  stringValue(p.LastSeen.Format(time.RFC3339)),
+		}}}}
+	}
+
+	return &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: &structpb.Struct{Fields: fields}}}
+}
+
+func stringValue(s string) *structpb.Value {
+	return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: s}}
+}
+
+func numberValue(n float64) *structpb.Value {
+	return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: n}}
+}
+
+func listValue(l []string) *structpb.Value {
+	values := make([]*structpb.Value, 0, len(l))
+	for _, s := range l {
+		values = append(values, stringValue(s))
+	}
+
+	return &structpb.Value{Kind: &structpb.Value_ListValue{ListValue: &structpb.ListValue{Values: values}}}
+}
@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+	"github.com/golang/protobuf/ptypes/empty"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func dial(t *testing.T, s *Server) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	gs := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	}))
+	s.Register(gs)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestAdminUnary(t *testing.T) {
+	r := resolver.NewResolver("127.0.0.1", "8080", false, nil, nil)
+	conn := dial(t, NewServer(r))
+	ctx := context.Background()
+
+	out := &structpb.Struct{}
+	assert.Nil(t, conn.Invoke(ctx, "/"+ServiceName+"/ListTargets", &empty.Empty{}, out))
+	assert.Equal(t, "127.0.0.1", out.Fields["targets"].GetListValue().Values[0].GetStringValue())
+
+	assert.Nil(t, conn.Invoke(ctx, "/"+ServiceName+"/GetTarget", &wrappers.StringValue{Value: "127.0.0.1"}, out))
+	assert.Equal(t, "127.0.0.1:8080", out.Fields["addresses"].GetListValue().Values[0].GetStringValue())
+	provenance := out.Fields["provenance"].GetStructValue().Fields["127.0.0.1:8080"].GetStructValue()
+	assert.Equal(t, resolver.SourceStatic, provenance.Fields["source"].GetStringValue())
+
+	assert.Nil(t, conn.Invoke(ctx, "/"+ServiceName+"/ForceRefresh", &wrappers.StringValue{Value: "127.0.0.1"}, out))
+	assert.Equal(t, "127.0.0.1", out.Fields["target"].GetStringValue())
+
+	err := conn.Invoke(ctx, "/"+ServiceName+"/GetTarget", &wrappers.StringValue{Value: "unknown"}, out)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	err = conn.Invoke(ctx, "/"+ServiceName+"/ForceRefresh", &wrappers.StringValue{Value: "unknown"}, out)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAdminSetQueryLog(t *testing.T) {
+	r := resolver.NewResolver("127.0.0.1", "8080", false, nil, nil, resolver.WithQueryLog(nil, false))
+	plain := resolver.NewResolver("127.0.0.2", "8080", false, nil, nil)
+	conn := dial(t, NewServer(r, plain))
+	ctx := context.Background()
+
+	toggle := func(target string, enabled bool) (*structpb.Struct, error) {
+		out := &structpb.Struct{}
+		err := conn.Invoke(ctx, "/"+ServiceName+"/SetQueryLog", &structpb.Struct{Fields: map[string]*structpb.Value{
+			"target":  {Kind: &structpb.Value_StringValue{StringValue: target}},
+			"enabled": {Kind: &structpb.Value_BoolValue{BoolValue: enabled}},
+		}}, out)
+		return out, err
+	}
+
+	out, err := toggle("127.0.0.1", true)
+	assert.Nil(t, err)
+	assert.True(t, out.Fields["query_log"].GetBoolValue())
+	assert.True(t, r.QueryLogEnabled())
+
+	out, err = toggle("127.0.0.1", false)
+	assert.Nil(t, err)
+	assert.False(t, out.Fields["query_log"].GetBoolValue())
+	assert.False(t, r.QueryLogEnabled())
+
+	_, err = toggle("127.0.0.2", true)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = toggle("unknown", true)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAdminStreamChanges(t *testing.T) {
+	r := resolver.NewResolver("127.0.0.1", "8080", false, nil, nil)
+	conn := dial(t, NewServer(r))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	desc := &grpc.StreamDesc{StreamName: "StreamChanges", ServerStreams: true}
+	stream, err := conn.NewStream(ctx, desc, "/"+ServiceName+"/StreamChanges")
+	assert.Nil(t, err)
+	assert.Nil(t, stream.SendMsg(&empty.Empty{}))
+	assert.Nil(t, stream.CloseSend())
+
+	out := &structpb.Struct{}
+	assert.Nil(t, stream.RecvMsg(out))
+	assert.Equal(t, "127.0.0.1", out.Fields["target"].GetStringValue())
+	assert.Equal(t, "127.0.0.1:8080", out.Fields["addresses"].GetListValue().Values[0].GetStringValue())
+}
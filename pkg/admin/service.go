@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+)
+
+// ServiceName is the full name of the admin service
+const ServiceName = "dmresolver.admin.v1.Admin"
+
+// serviceDesc is the equivalent of the protoc generated
+// descriptor for the service defined in admin.proto
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "ListTargets", Handler: listTargetsHandler},
+		{MethodName: "GetTarget", Handler: getTargetHandler},
+		{MethodName: "ForceRefresh", Handler: forceRefreshHandler},
+		{MethodName: "SetQueryLog", Handler: setQueryLogHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "StreamChanges", Handler: streamChangesHandler, ServerStreams: true},
+	},
+	Metadata: "admin.proto",
+}
+
+func listTargetsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).ListTargets(ctx, req.(*empty.Empty))
+	}
+
+	return intercept(ctx, srv, in, "ListTargets", handler, interceptor)
+}
+
+func getTargetHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).GetTarget(ctx, req.(*wrappers.StringValue))
+	}
+
+	return intercept(ctx, srv, in, "GetTarget", handler, interceptor)
+}
+
+func forceRefreshHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).ForceRefresh(ctx, req.(*wrappers.StringValue))
+	}
+
+	return intercept(ctx, srv, in, "ForceRefresh", handler, interceptor)
+}
+
+func setQueryLogHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).SetQueryLog(ctx, req.(*structpb.Struct))
+	}
+
+	return intercept(ctx, srv, in, "SetQueryLog", handler, interceptor)
+}
+
+func streamChangesHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(empty.Empty)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+
+	return srv.(*Server).StreamChanges(in, stream)
+}
+
+func intercept(ctx context.Context, srv, in interface{}, method string, handler grpc.UnaryHandler, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + method}
+	return interceptor(ctx, in, info, handler)
+}
@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	dmresolver "github.com/cperez08/dm-resolver/pkg/resolver"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	minReconnectBackoff = 100 * time.Millisecond
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// Builder implements the gRPC resolver.Builder interface getting the
+// addresses from an agent instead of resolving them, the target endpoint
+// is sent as is to the agent, e.g. scheme:///my-service:8080
+type Builder struct {
+	scheme string
+	socket string
+}
+
+// NewBuilder creates a builder connecting to the agent listening on the unix socket
+func NewBuilder(scheme, socket string) *Builder {
+	return &Builder{scheme: scheme, socket: socket}
+}
+
+// Build subscribes to the target in the agent, the state is updated
+// on every snapshot until the resolver is closed, the agent must be
+// reachable at build time, it's reconnected to afterwards
+func (b *Builder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	r := &agentResolver{
+		socket:    b.socket,
+		endpoint:  target.Endpoint,
+		cc:        cc,
+		reconnect: make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	conn, err := r.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	go r.run(conn)
+	return r, nil
+}
+
+// Scheme returns the scheme of the builder
+func (b *Builder) Scheme() string {
+	return b.scheme
+}
+
+type agentResolver struct {
+	socket    string
+	endpoint  string
+	cc        resolver.ClientConn
+	reconnect chan struct{} // ResolveNow, skips the reconnection backoff
+	done      chan struct{}
+	once      sync.Once
+
+	m    sync.Mutex
+	conn net.Conn // current agent connection, nil while reconnecting
+}
+
+// dial connects to the agent and subscribes to the endpoint
+func (r *agentResolver) dial() (net.Conn, error) {
+	conn, err := net.Dial("unix", r.socket)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.NewEncoder(conn).Encode(Request{Target: r.endpoint}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// run streams the snapshots of the connection and reconnects with
+// exponential backoff once the agent goes away, until the resolver is closed
+func (r *agentResolver) run(conn net.Conn) {
+	backoff := minReconnectBackoff
+	for {
+		if conn != nil {
+			if r.stream(conn) {
+				backoff = minReconnectBackoff
+			}
+		}
+
+		select {
+		case <-r.done:
+			return
+		case <-r.reconnect:
+		case <-time.After(backoff):
+			if backoff *= 2; backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+		}
+
+		var err error
+		if conn, err = r.dial(); err != nil {
+			r.cc.ReportError(fmt.Errorf("agent reconnection failed: %v", err))
+		}
+	}
+}
+
+// stream updates the state with every snapshot of the connection, the
+// error is reported to gRPC if the agent goes away, it returns whether
+// any snapshot was received
+func (r *agentResolver) stream(conn net.Conn) (received bool) {
+	r.m.Lock()
+	select {
+	case <-r.done:
+		r.m.Unlock()
+		conn.Close()
+		return false
+	default:
+	}
+	r.conn = conn
+	r.m.Unlock()
+
+	defer func() {
+		r.m.Lock()
+		r.conn = nil
+		r.m.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		received = true
+		snap, err := dmresolver.UnmarshalSnapshot(scanner.Bytes())
+		if err != nil {
+			r.cc.ReportError(fmt.Errorf("agent invalid snapshot: %v", err))
+			continue
+		}
+
+		addrs := make([]resolver.Address, 0, len(snap.Addresses))
+		for _, a := range snap.Addresses {
+			addrs = append(addrs, resolver.Address{Addr: a})
+		}
+		r.cc.UpdateState(resolver.State{Addresses: addrs})
+	}
+
+	select {
+	case <-r.done:
+	default:
+		r.cc.ReportError(fmt.Errorf("agent connection lost: %v", scanner.Err()))
+	}
+
+	return received
+}
+
+// ResolveNow reconnects to the agent right away if the connection was
+// lost, the agent pushes the changes otherwise
+func (r *agentResolver) ResolveNow(o resolver.ResolveNowOptions) {
+	select {
+	case r.reconnect <- struct{}{}:
+	default:
+	}
+}
+
+// Close disconnects from the agent
+func (r *agentResolver) Close() {
+	r.once.Do(func() {
+		r.m.Lock()
+		defer r.m.Unlock()
+		close(r.done)
+		if r.conn != nil {
+			r.conn.Close()
+		}
+	})
+}
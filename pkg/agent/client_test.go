@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestBuilder(t *testing.T) {
+	lis, socket, cleanup := listen(t)
+	defer cleanup()
+
+	rate := time.Duration(1)
+	s := NewServer(&rate)
+	go s.Serve(lis)
+
+	b := NewBuilder("agent", socket)
+	assert.Equal(t, "agent", b.Scheme())
+
+	cc := resolvertest.NewClientConn()
+	r, err := b.Build(resolver.Target{Scheme: "agent", Endpoint: "localhost:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	r.ResolveNow(resolver.ResolveNowOptions{})
+
+	assert.Eventually(t, func() bool { return len(cc.States()) == 1 }, time.Second, 10*time.Millisecond)
+	st, _ := cc.LastState()
+	assert.NotEmpty(t, st.Addresses)
+
+	// the agent going away is reported
+	s.Close()
+	assert.Eventually(t, func() bool { return len(cc.Errors()) >= 1 }, time.Second, 10*time.Millisecond)
+	r.Close()
+
+	_, err = b.Build(resolver.Target{Scheme: "agent", Endpoint: "localhost:8080"}, cc, resolver.BuildOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestBuilderReconnect(t *testing.T) {
+	lis, socket, cleanup := listen(t)
+	defer cleanup()
+
+	rate := time.Duration(1)
+	s := NewServer(&rate)
+	go s.Serve(lis)
+
+	cc := resolvertest.NewClientConn()
+	r, err := NewBuilder("agent", socket).Build(resolver.Target{Scheme: "agent", Endpoint: "localhost:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	defer r.Close()
+	assert.Eventually(t, func() bool { return len(cc.States()) == 1 }, time.Second, 10*time.Millisecond)
+
+	// the agent restarts, ResolveNow reconnects without waiting for the backoff
+	s.Close()
+	os.Remove(socket)
+	assert.Eventually(t, func() bool { return len(cc.Errors()) >= 1 }, time.Second, 10*time.Millisecond)
+
+	lis, err = net.Listen("unix", socket)
+	assert.Nil(t, err)
+	s = NewServer(&rate)
+	go s.Serve(lis)
+	defer s.Close()
+
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	assert.Eventually(t, func() bool { return len(cc.States()) == 2 }, 5*time.Second, 10*time.Millisecond)
+}
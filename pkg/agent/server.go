@@ -0,0 +1,164 @@
+// Package agent allows a single process on a host to perform the lookups
+// and serve the address snapshots to sibling processes over a unix socket,
+// so the processes of a node don't each poll DNS for the same targets
+//
+// The protocol is line delimited JSON, the client sends a Request and the
+// server replies with a snapshot (see resolver.MarshalSnapshot) every
+// time the address list changes
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+)
+
+// Request subscribes to the snapshots of a target in the host:port format
+type Request struct {
+	Target string `json:"target"`
+}
+
+// Server shares a watched resolver per target among its clients,
+// the resolver is closed once its last client disconnects
+type Server struct {
+	refreshRate *time.Duration
+	opts        []resolver.Option
+
+	m         sync.Mutex
+	resolvers map[string]*shared
+	listeners []net.Listener
+}
+
+type shared struct {
+	r       *resolver.DomainResolver
+	clients int
+}
+
+// NewServer creates a new agent server, the refresh rate
+// and the options apply to the resolvers of all the targets
+func NewServer(refreshRate *time.Duration, opts ...resolver.Option) *Server {
+	return &Server{refreshRate: refreshRate, opts: opts, resolvers: map[string]*shared{}}
+}
+
+// Serve accepts the clients on the listener, e.g. a unix socket,
+// until the listener is closed
+func (s *Server) Serve(lis net.Listener) error {
+	s.m.Lock()
+	s.listeners = append(s.listeners, lis)
+	s.m.Unlock()
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.handle(conn)
+	}
+}
+
+// Close closes the listeners and the resolvers
+func (s *Server) Close() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, lis := range s.listeners {
+		lis.Close()
+	}
+
+	for t, sh := range s.resolvers {
+		sh.r.Close()
+		delete(s.resolvers, t)
+	}
+}
+
+// handle streams the snapshots of the requested target to the client
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	req := Request{}
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		log.Println("[grpc-resolver]: agent invalid request ", err)
+		return
+	}
+
+	t, err := resolver.ParseTarget(req.Target)
+	if err != nil {
+		log.Println("[grpc-resolver]: agent invalid target ", err)
+		return
+	}
+
+	r := s.acquire(t)
+	defer s.release(t)
+
+	ch := r.Watch()
+	defer r.Unwatch(ch)
+
+	// the client never sends anything else, a read only returns on disconnection
+	gone := make(chan struct{})
+	go func() {
+		conn.Read(make([]byte, 1))
+		close(gone)
+	}()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			snap := r.Snapshot()
+			data, err := resolver.MarshalSnapshot(resolver.Snapshot{Target: t.String(), Version: snap.Version, Addresses: snap.Addresses})
+			if err != nil {
+				log.Println("[grpc-resolver]: agent error encoding snapshot ", err)
+				return
+			}
+
+			if _, err := conn.Write(append(data, '\n')); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// acquire returns the shared resolver of the target, started if needed
+func (s *Server) acquire(t resolver.Target) *resolver.DomainResolver {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// keyed by the URI, the resolver depends on the options of the target too
+	sh, ok := s.resolvers[t.URI()]
+	if !ok {
+		opts := append(append([]resolver.Option{}, s.opts...), t.Options()...)
+		sh = &shared{r: resolver.NewResolver(t.Host, t.Port, true, s.refreshRate, nil, opts...)}
+		sh.r.StartResolver()
+		s.resolvers[t.URI()] = sh
+	}
+	sh.clients++
+
+	return sh.r
+}
+
+// release closes the resolver of the target if it has no clients left
+func (s *Server) release(t resolver.Target) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	sh, ok := s.resolvers[t.URI()]
+	if !ok {
+		return
+	}
+
+	sh.clients--
+	if sh.clients == 0 {
+		sh.r.Close()
+		delete(s.resolvers, t.URI())
+	}
+}
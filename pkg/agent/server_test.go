@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+func listen(t *testing.T) (net.Listener, string, func()) {
+	dir, err := ioutil.TempDir("", "agent")
+	assert.Nil(t, err)
+
+	socket := filepath.Join(dir, "agent.sock")
+	lis, err := net.Listen("unix", socket)
+	assert.Nil(t, err)
+
+	return lis, socket, func() { os.RemoveAll(dir) }
+}
+
+func TestServer(t *testing.T) {
+	lis, socket, cleanup := listen(t)
+	defer cleanup()
+
+	rate := time.Duration(1)
+	s := NewServer(&rate)
+	go s.Serve(lis)
+	defer s.Close()
+
+	conns := []net.Conn{}
+	for _, target := range []string{"localhost:8080", "localhost:8080", "localhost:8080?ipv4_port=9090"} {
+		conn, err := net.Dial("unix", socket)
+		assert.Nil(t, err)
+		assert.Nil(t, json.NewEncoder(conn).Encode(Request{Target: target}))
+
+		line, err := bufio.NewReader(conn).ReadBytes('\n')
+		assert.Nil(t, err)
+		snap, err := resolver.UnmarshalSnapshot(line)
+		assert.Nil(t, err)
+		assert.Equal(t, "localhost:8080", snap.Target)
+		assert.NotEmpty(t, snap.Addresses)
+		conns = append(conns, conn)
+	}
+
+	// one resolver shared by the clients of the same target, the
+	// options of the target set it apart
+	s.m.Lock()
+	assert.Equal(t, 2, len(s.resolvers))
+	assert.Equal(t, 2, s.resolvers["localhost:8080"].clients)
+	assert.Equal(t, 1, s.resolvers["localhost:8080?ipv4_port=9090"].clients)
+	s.m.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+
+	assert.Eventually(t, func() bool {
+		s.m.Lock()
+		defer s.m.Unlock()
+		return len(s.resolvers) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServerInvalidRequest(t *testing.T) {
+	lis, socket, cleanup := listen(t)
+	defer cleanup()
+
+	s := NewServer(nil)
+	go s.Serve(lis)
+	defer s.Close()
+
+	for _, req := range []string{"{\n", "{\"target\": \":8080\"}\n"} {
+		conn, err := net.Dial("unix", socket)
+		assert.Nil(t, err)
+		conn.Write([]byte(req))
+		if req == "{\n" {
+			conn.(*net.UnixConn).CloseWrite()
+		}
+
+		// the server hangs up
+		_, err = conn.Read(make([]byte, 1))
+		assert.NotNil(t, err)
+		conn.Close()
+	}
+}
@@ -0,0 +1,66 @@
+package hashring
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// DefaultReplicas is the number of virtual nodes per address
+// used when a non positive value is given to New
+const DefaultReplicas = 100
+
+// Ring is a consistent hash ring built from an address snapshot,
+// each address is placed several times in the ring (virtual nodes)
+// so keys are spread evenly and only the keys of added or removed
+// addresses move when the snapshot changes
+type Ring struct {
+	replicas int
+	hashes   []uint32
+	nodes    map[uint32]string
+}
+
+// New creates a ring for the given addresses, replicas indicates
+// the number of virtual nodes per address
+func New(addrs []string, replicas int) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+
+	r := &Ring{replicas: replicas, nodes: make(map[uint32]string)}
+	for _, a := range addrs {
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + a))
+			if _, ok := r.nodes[h]; ok {
+				continue
+			}
+			r.nodes[h] = a
+			r.hashes = append(r.hashes, h)
+		}
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// Get returns the address the key is mapped to,
+// an empty string is returned if the ring is empty
+func (r *Ring) Get(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.nodes[r.hashes[i]]
+}
+
+// Pick is a helper that builds a ring for the given snapshot
+// and returns the address chosen for the key
+func Pick(addrs []string, key string) string {
+	return New(addrs, DefaultReplicas).Get(key)
+}
@@ -0,0 +1,35 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingGet(t *testing.T) {
+	assert.Equal(t, "", New([]string{}, 0).Get("key"))
+
+	addrs := []string{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:8080"}
+	r := New(addrs, 0)
+	assert.Contains(t, addrs, r.Get("key"))
+	assert.Equal(t, r.Get("key"), r.Get("key"))
+	assert.Equal(t, r.Get("key"), Pick(addrs, "key"))
+}
+
+func TestRingStability(t *testing.T) {
+	addrs := []string{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:8080"}
+	before := New(addrs, 50)
+	after := New(append(addrs, "10.0.0.4:8080"), 50)
+
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := "key-" + strconv.Itoa(i)
+		if b, a := before.Get(key), after.Get(key); b != a {
+			assert.Equal(t, "10.0.0.4:8080", a)
+			moved++
+		}
+	}
+
+	assert.True(t, moved < 500)
+}
@@ -0,0 +1,103 @@
+package list
+
+import (
+	"reflect"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+)
+
+// attributesEqualer is implemented by the attributes of the newer gRPC versions
+type attributesEqualer interface {
+	Equal(o *attributes.Attributes) bool
+}
+
+// EqualAttributes returns true if both attributes have the same values,
+// nil and empty attributes are equal
+func EqualAttributes(a, b *attributes.Attributes) bool {
+	if a == nil {
+		a = attributes.New()
+	}
+
+	if b == nil {
+		b = attributes.New()
+	}
+
+	if e, ok := interface{}(a).(attributesEqualer); ok {
+		return e.Equal(b)
+	}
+
+	// attributes have no Equal in this gRPC version, DeepEqual compares the values
+	return reflect.DeepEqual(a, b)
+}
+
+// EqualAddress returns true if both addresses are the same, server name,
+// type, metadata and attributes included
+func EqualAddress(a, b resolver.Address) bool {
+	return a.Addr == b.Addr &&
+		a.ServerName == b.ServerName &&
+		a.Type == b.Type &&
+		reflect.DeepEqual(a.Metadata, b.Metadata) &&
+		EqualAttributes(a.Attributes, b.Attributes)
+}
+
+// EqualAddresses returns true if both lists have the same addresses in
+// the same order, see EqualAddress
+func EqualAddresses(a, b []resolver.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !EqualAddress(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CompareAddresses compares two address lists regardless of the order
+// and returns true if there is any difference between the lists, an
+// address whose metadata changed is a difference
+func CompareAddresses(base, new []resolver.Address) (hasDiff bool) {
+	if len(base) != len(new) {
+		return true
+	}
+
+	added, _ := DiffAddresses(base, new)
+	return len(added) > 0
+}
+
+// DiffAddresses returns the addresses present in new but not in base
+// (added) and the ones present in base but not in new (removed), an
+// address whose metadata changed is both removed and added
+func DiffAddresses(base, new []resolver.Address) (added, removed []resolver.Address) {
+	byAddr := make(map[string][]int, len(base))
+	for i, b := range base {
+		byAddr[b.Addr] = append(byAddr[b.Addr], i)
+	}
+
+	matched := make([]bool, len(base))
+	for _, n := range new {
+		found := false
+		for _, i := range byAddr[n.Addr] {
+			if !matched[i] && EqualAddress(base[i], n) {
+				matched[i], found = true, true
+				break
+			}
+		}
+
+		if !found {
+			added = append(added, n)
+		}
+	}
+
+	for i, b := range base {
+		if !matched[i] {
+			removed = append(removed, b)
+		}
+	}
+
+	return added, removed
+}
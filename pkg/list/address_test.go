@@ -0,0 +1,64 @@
+package list
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestEqualAttributes(t *testing.T) {
+	assert.True(t, EqualAttributes(nil, nil))
+	assert.True(t, EqualAttributes(nil, attributes.New()))
+	assert.True(t, EqualAttributes(attributes.New("weight", 1), attributes.New("weight", 1)))
+	assert.False(t, EqualAttributes(attributes.New("weight", 1), attributes.New("weight", 2)))
+	assert.False(t, EqualAttributes(nil, attributes.New("weight", 1)))
+}
+
+func TestEqualAddresses(t *testing.T) {
+	a := resolver.Address{Addr: "10.0.0.1:8080", ServerName: "svc", Attributes: attributes.New("weight", 1)}
+	b := a
+	assert.True(t, EqualAddress(a, b))
+	assert.True(t, EqualAddresses([]resolver.Address{a}, []resolver.Address{b}))
+
+	b.ServerName = "other"
+	assert.False(t, EqualAddress(a, b))
+	b = a
+	b.Attributes = attributes.New("weight", 2)
+	assert.False(t, EqualAddress(a, b))
+	b = a
+	b.Metadata = "zone-a"
+	assert.False(t, EqualAddress(a, b))
+
+	c := resolver.Address{Addr: "10.0.0.2:8080"}
+	assert.False(t, EqualAddresses([]resolver.Address{a, c}, []resolver.Address{c, a}))
+	assert.False(t, EqualAddresses([]resolver.Address{a}, nil))
+}
+
+func TestCompareAddresses(t *testing.T) {
+	a := resolver.Address{Addr: "10.0.0.1:8080", Attributes: attributes.New("weight", 1)}
+	c := resolver.Address{Addr: "10.0.0.2:8080"}
+	assert.False(t, CompareAddresses([]resolver.Address{a, c}, []resolver.Address{c, a}))
+	assert.True(t, CompareAddresses([]resolver.Address{a}, []resolver.Address{a, c}))
+
+	// same addresses with different attributes
+	b := a
+	b.Attributes = attributes.New("weight", 2)
+	assert.True(t, CompareAddresses([]resolver.Address{a, c}, []resolver.Address{c, b}))
+}
+
+func TestDiffAddresses(t *testing.T) {
+	a := resolver.Address{Addr: "10.0.0.1:8080", Attributes: attributes.New("weight", 1)}
+	b := a
+	b.Attributes = attributes.New("weight", 2)
+	c := resolver.Address{Addr: "10.0.0.2:8080"}
+
+	added, removed := DiffAddresses([]resolver.Address{a, c}, []resolver.Address{c, b})
+	assert.Equal(t, []resolver.Address{b}, added)
+	assert.Equal(t, []resolver.Address{a}, removed)
+
+	added, removed = DiffAddresses([]resolver.Address{c}, []resolver.Address{c})
+	assert.Nil(t, added)
+	assert.Nil(t, removed)
+}
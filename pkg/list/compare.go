@@ -1,6 +1,9 @@
 package list
 
-import "sort"
+import (
+	"net"
+	"sort"
+)
 
 // CompareListStr compares two string lists and
 // returns true if there is any difference between
@@ -20,3 +23,53 @@ func CompareListStr(base, new []string) (hasDiff bool) {
 	}
 	return false
 }
+
+// DiffListStr returns the elements present in new but not in base
+// (added) and the elements present in base but not in new (removed)
+func DiffListStr(base, new []string) (added, removed []string) {
+	inBase := make(map[string]bool, len(base))
+	for _, b := range base {
+		inBase[b] = true
+	}
+
+	inNew := make(map[string]bool, len(new))
+	for _, n := range new {
+		inNew[n] = true
+		if !inBase[n] {
+			added = append(added, n)
+		}
+	}
+
+	for _, b := range base {
+		if !inNew[b] {
+			removed = append(removed, b)
+		}
+	}
+
+	return added, removed
+}
+
+// CompareHostsStr compares two host:port lists ignoring the ports,
+// returns true if there is any difference between the hosts
+func CompareHostsStr(base, new []string) (hasDiff bool) {
+	return CompareListStr(hosts(base), hosts(new))
+}
+
+// hosts returns the unique hosts of a host:port list
+func hosts(addrs []string) []string {
+	seen := map[string]bool{}
+	rs := []string{}
+	for _, a := range addrs {
+		h := a
+		if host, _, err := net.SplitHostPort(a); err == nil {
+			h = host
+		}
+
+		if !seen[h] {
+			seen[h] = true
+			rs = append(rs, h)
+		}
+	}
+
+	return rs
+}
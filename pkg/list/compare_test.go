@@ -19,3 +19,20 @@ func TestCompareListStr(t *testing.T) {
 	assert.Equal(t, false, CompareListStr([]string{}, []string{}))
 	assert.Equal(t, true, CompareListStr([]string{"1"}, []string{"2"}))
 }
+
+func TestDiffListStr(t *testing.T) {
+	added, removed := DiffListStr([]string{"1", "2"}, []string{"2", "3"})
+	assert.Equal(t, []string{"3"}, added)
+	assert.Equal(t, []string{"1"}, removed)
+
+	added, removed = DiffListStr([]string{"1"}, []string{"1"})
+	assert.Nil(t, added)
+	assert.Nil(t, removed)
+}
+
+func TestCompareHostsStr(t *testing.T) {
+	assert.False(t, CompareHostsStr([]string{"10.0.0.1:80", "10.0.0.1:443"}, []string{"10.0.0.1:8080"}))
+	assert.False(t, CompareHostsStr([]string{"[::1]:80"}, []string{"[::1]:443"}))
+	assert.True(t, CompareHostsStr([]string{"10.0.0.1:80"}, []string{"10.0.0.2:80"}))
+	assert.False(t, CompareHostsStr([]string{"my-host"}, []string{"my-host"}))
+}
@@ -0,0 +1,88 @@
+package list
+
+import "sync"
+
+// fnv-1a 64 bits constants
+const (
+	offset64 = 14695981039346656037
+	prime64  = 1099511628211
+)
+
+// Hash is an order independent hash of a string list, the same elements
+// in any order have the same hash so no sorting is needed
+type Hash struct {
+	n   int
+	sum uint64
+	xor uint64
+}
+
+// HashListStr returns the hash of the list in O(n) without allocations
+func HashListStr(l []string) Hash {
+	h := Hash{n: len(l)}
+	for _, s := range l {
+		e := uint64(offset64)
+		for i := 0; i < len(s); i++ {
+			e ^= uint64(s[i])
+			e *= prime64
+		}
+
+		h.sum += e
+		h.xor ^= e
+	}
+
+	return h
+}
+
+// HashComparator compares lists through their hashes, the hash of the
+// list that stays current after a comparison (base if equal, new
+// otherwise) is cached so it's not hashed again on the next tick, it's
+// safe for concurrent use. The lists must not be modified in place
+// after being compared
+type HashComparator struct {
+	m        sync.Mutex
+	last     []string
+	lastHash Hash
+}
+
+// NewHashComparator creates a new HashComparator
+func NewHashComparator() *HashComparator {
+	return &HashComparator{}
+}
+
+// Compare returns true if there is any difference between the lists,
+// it has the signature of CompareListStr, a hash collision reports no
+// difference but with 64 bits hashes it's negligible
+func (c *HashComparator) Compare(base, new []string) (hasDiff bool) {
+	if len(base) != len(new) {
+		c.remember(new, HashListStr(new))
+		return true
+	}
+
+	c.m.Lock()
+	baseHash, cached := c.lastHash, sameSlice(base, c.last)
+	c.m.Unlock()
+	if !cached {
+		baseHash = HashListStr(base)
+	}
+
+	newHash := HashListStr(new)
+	if baseHash == newHash {
+		c.remember(base, baseHash)
+		return false
+	}
+
+	c.remember(new, newHash)
+	return true
+}
+
+// remember caches the hash of the list
+func (c *HashComparator) remember(l []string, h Hash) {
+	c.m.Lock()
+	c.last, c.lastHash = l, h
+	c.m.Unlock()
+}
+
+// sameSlice returns true if both slices share the backing array and length
+func sameSlice(a, b []string) bool {
+	return len(a) == len(b) && len(a) > 0 && &a[0] == &b[0]
+}
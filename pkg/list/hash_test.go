@@ -0,0 +1,64 @@
+package list
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashListStr(t *testing.T) {
+	assert.Equal(t, HashListStr([]string{"1", "2", "3"}), HashListStr([]string{"3", "1", "2"}))
+	assert.NotEqual(t, HashListStr([]string{"1", "2"}), HashListStr([]string{"1", "3"}))
+	assert.NotEqual(t, HashListStr([]string{"1", "1"}), HashListStr([]string{"2", "2"}))
+	assert.Equal(t, HashListStr(nil), HashListStr([]string{}))
+}
+
+func TestHashComparator(t *testing.T) {
+	c := NewHashComparator()
+	assert.True(t, c.Compare([]string{"1"}, []string{}))
+	assert.False(t, c.Compare([]string{"1", "2"}, []string{"2", "1"}))
+	assert.True(t, c.Compare([]string{"1"}, []string{"2"}))
+	assert.False(t, c.Compare([]string{}, []string{}))
+
+	// the resolved list of a tick is the current one of the next
+	current := []string{"10.0.0.1:80", "10.0.0.2:80"}
+	assert.True(t, c.Compare([]string{"10.0.0.1:80"}, current))
+	assert.True(t, sameSlice(current, c.last))
+	assert.False(t, c.Compare(current, []string{"10.0.0.2:80", "10.0.0.1:80"}))
+	assert.True(t, sameSlice(current, c.last))
+	assert.True(t, c.Compare(current, []string{"10.0.0.2:80", "10.0.0.3:80"}))
+}
+
+// benchmarkList returns n addresses in reverse order, like an
+// unsorted DNS answer
+func benchmarkList(n int) []string {
+	l := make([]string, n)
+	for i := range l {
+		l[n-1-i] = fmt.Sprintf("10.0.%d.%d:443", i/256, i%256)
+	}
+
+	return l
+}
+
+func BenchmarkCompareListStr(b *testing.B) {
+	current, resolved := benchmarkList(500), benchmarkList(500)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		resolved = benchmarkList(500)
+		b.StartTimer()
+		CompareListStr(current, resolved)
+	}
+}
+
+func BenchmarkHashComparator(b *testing.B) {
+	current, resolved := benchmarkList(500), benchmarkList(500)
+	c := NewHashComparator()
+	c.Compare(nil, current)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Compare(current, resolved)
+	}
+}
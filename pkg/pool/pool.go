@@ -0,0 +1,225 @@
+// Package pool hands out the addresses of a watched resolver to the
+// clients without gRPC, e.g. database or Redis connection pools, the
+// unhealthy addresses are skipped until they pass a health check again
+package pool
+
+import (
+	"context"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+)
+
+// DefaultCheckInterval is the health check interval used if none is set
+const DefaultCheckInterval = 10 * time.Second
+
+// HealthCheck returns an error if the address is not healthy
+type HealthCheck func(ctx context.Context, addr string) error
+
+// DialCheck is the default health check, it opens and closes a TCP connection
+func DialCheck(ctx context.Context, addr string) error {
+	var d net.Dialer
+	return DialerCheck(d.DialContext)(ctx, addr)
+}
+
+// DialerCheck returns a health check opening and closing a TCP connection
+// with the dialer, e.g. one bound to the interface or the VRF of the data
+// network on multi-homed hosts
+func DialerCheck(dial resolver.DialFunc) HealthCheck {
+	return func(ctx context.Context, addr string) error {
+		conn, err := dial(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	}
+}
+
+// Pool keeps the address list of the resolver up to date and hands out
+// its healthy addresses in round robin, every address failing a health
+// check or released with an error is skipped until it passes a check,
+// if no address is healthy all of them are handed out
+type Pool struct {
+	r        *resolver.DomainResolver
+	check    HealthCheck
+	interval time.Duration
+	updates  <-chan []string
+
+	m         sync.Mutex
+	addrs     []string
+	unhealthy map[string]bool
+	inFlight  map[string]int
+	next      int
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New creates a pool on top of the resolver, it should be started so the
+// initial addresses are available, the check is DialCheck if nil and run
+// every interval, DefaultCheckInterval if not positive
+func New(r *resolver.DomainResolver, check HealthCheck, interval time.Duration) *Pool {
+	if check == nil {
+		check = DialCheck
+	}
+
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+
+	p := &Pool{
+		r:         r,
+		check:     check,
+		interval:  interval,
+		updates:   r.Watch(),
+		unhealthy: map[string]bool{},
+		inFlight:  map[string]int{},
+		done:      make(chan struct{}),
+	}
+
+	go p.loop()
+	return p
+}
+
+// Next returns the next address and the function releasing it, the
+// error of the operation made with the address, if any, has to be given
+// so the address is skipped until it's healthy again, an empty address
+// is returned if the resolver has none
+func (p *Pool) Next() (addr string, release func(err error)) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	candidates := []string{}
+	for _, a := range p.addrs {
+		if !p.unhealthy[a] {
+			candidates = append(candidates, a)
+		}
+	}
+
+	if len(candidates) == 0 {
+		candidates = p.addrs
+	}
+
+	if len(candidates) == 0 {
+		return "", func(error) {}
+	}
+
+	addr = candidates[p.next%len(candidates)]
+	p.next++
+	p.inFlight[addr]++
+
+	var once sync.Once
+	return addr, func(err error) {
+		once.Do(func() { p.release(addr, err) })
+	}
+}
+
+// InFlight returns the number of addresses handed out and not released yet
+func (p *Pool) InFlight(addr string) int {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.inFlight[addr]
+}
+
+// Close stops the updates and the health checks, the resolver is not closed
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		p.r.Unwatch(p.updates)
+	})
+}
+
+// release decrements the in flight counter of the address and marks it
+// unhealthy on errors, the error is reported to the resolver as well
+func (p *Pool) release(addr string, err error) {
+	p.m.Lock()
+	p.inFlight[addr]--
+	if p.inFlight[addr] <= 0 {
+		delete(p.inFlight, addr)
+	}
+	if err != nil {
+		p.unhealthy[addr] = true
+	}
+	p.m.Unlock()
+
+	p.r.ReportConnectResult(addr, err)
+}
+
+// loop applies the address list updates and runs the health checks
+func (p *Pool) loop() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case addrs, ok := <-p.updates:
+			if !ok {
+				return
+			}
+			p.setAddresses(addrs)
+		case <-ticker.C:
+			p.checkAll()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// setAddresses replaces the address list forgetting the removed addresses
+func (p *Pool) setAddresses(addrs []string) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	current := make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		current[a] = true
+	}
+
+	for a := range p.unhealthy {
+		if !current[a] {
+			delete(p.unhealthy, a)
+		}
+	}
+
+	p.addrs = addrs
+}
+
+// checkAll runs the health check of every address concurrently, each one
+// with the interval as timeout
+func (p *Pool) checkAll() {
+	p.m.Lock()
+	addrs := append([]string{}, p.addrs...)
+	p.m.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+	defer cancel()
+
+	results := make([]error, len(addrs))
+	wg := sync.WaitGroup{}
+	for i, a := range addrs {
+		wg.Add(1)
+		go func(i int, a string) {
+			defer wg.Done()
+			results[i] = p.check(ctx, a)
+		}(i, a)
+	}
+	wg.Wait()
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	for i, a := range addrs {
+		if results[i] == nil {
+			delete(p.unhealthy, a)
+			continue
+		}
+
+		if !p.unhealthy[a] {
+			log.Println("[grpc-resolver]: pool address ", a, " unhealthy ", results[i])
+		}
+		p.unhealthy[a] = true
+	}
+}
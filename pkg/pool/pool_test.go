@@ -0,0 +1,107 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+var refreshRate = time.Duration(50)
+
+// waitAddresses waits until the pool has n addresses
+func waitAddresses(t *testing.T, p *Pool, n int) {
+	assert.Eventually(t, func() bool {
+		p.m.Lock()
+		defer p.m.Unlock()
+		return len(p.addrs) == n
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestNext(t *testing.T) {
+	hosts := map[string][]string{"db.test": {"10.0.0.1", "10.0.0.2"}}
+	r := resolver.NewResolver("db.test", "5432", false, &refreshRate, nil, resolver.WithHosts(hosts, true))
+	r.StartResolver()
+	p := New(r, func(context.Context, string) error { return nil }, time.Hour)
+	defer p.Close()
+	waitAddresses(t, p, 2)
+
+	first, release := p.Next()
+	second, _ := p.Next()
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, 1, p.InFlight(first))
+
+	release(nil)
+	release(nil)
+	assert.Equal(t, 0, p.InFlight(first))
+
+	// a failed address is skipped
+	addr, release := p.Next()
+	release(errors.New("connection refused"))
+	for i := 0; i < 4; i++ {
+		next, release := p.Next()
+		assert.NotEqual(t, addr, next)
+		release(nil)
+	}
+
+	// and handed out again once healthy
+	p.checkAll()
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		next, _ := p.Next()
+		seen[next] = true
+	}
+	assert.True(t, seen[addr])
+}
+
+func TestNextAllUnhealthy(t *testing.T) {
+	r := resolver.NewResolver("127.0.0.1", "1", false, &refreshRate, nil)
+	r.StartResolver()
+	p := New(r, nil, time.Hour)
+	defer p.Close()
+	waitAddresses(t, p, 1)
+
+	p.checkAll()
+	addr, _ := p.Next()
+	assert.Equal(t, "127.0.0.1:1", addr)
+	assert.True(t, p.unhealthy[addr])
+}
+
+func TestNextEmpty(t *testing.T) {
+	r := resolver.NewResolver("db.test", "5432", false, &refreshRate, nil, resolver.WithHosts(map[string][]string{}, true))
+	p := New(r, nil, time.Hour)
+	p.Close()
+	p.Close()
+
+	addr, release := p.Next()
+	assert.Equal(t, "", addr)
+	release(nil)
+}
+
+func TestDialCheck(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	assert.Nil(t, DialCheck(context.Background(), lis.Addr().String()))
+	assert.NotNil(t, DialCheck(context.Background(), "127.0.0.1:1"))
+}
+
+func TestDialerCheck(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	dialed := ""
+	check := DialerCheck(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = network + " " + addr
+		var d net.Dialer
+		return d.DialContext(ctx, network, lis.Addr().String())
+	})
+	assert.Nil(t, check(context.Background(), "10.0.0.1:8080"))
+	assert.Equal(t, "tcp 10.0.0.1:8080", dialed)
+}
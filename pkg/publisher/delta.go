@@ -0,0 +1,152 @@
+package publisher
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+)
+
+// DeltaFile appends the changes of the address list to a file as change
+// events, one JSON per line, see resolver.MarshalEvent, the first line
+// has the full list and the next ones the added and removed addresses
+// since their base version, every fullEvery deltas the file is replaced
+// by a full event so it doesn't grow forever, reduces the writes of very
+// large or churny services, see ReadDeltaFile
+type DeltaFile struct {
+	path      string
+	fullEvery int
+
+	m         sync.Mutex
+	last      []string
+	version   uint64 // version of the last line written, 0 if none
+	sinceFull int
+}
+
+// NewDeltaFile creates a publisher writing to the given path, a full
+// event is written every fullEvery deltas, on every publication if not
+// positive
+func NewDeltaFile(path string, fullEvery int) *DeltaFile {
+	return &DeltaFile{path: path, fullEvery: fullEvery}
+}
+
+// Publish writes the changes since the last publication, nothing is
+// written if the address list didn't change
+func (f *DeltaFile) Publish(s resolver.Snapshot) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	// DiffListStr leaves the addresses as they are, the snapshot is shared
+	// with the other publishers
+	added, removed := list.DiffListStr(f.last, s.Addresses)
+	if f.version != 0 && len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	e := resolver.Event{Type: resolver.EventChange, Target: s.Target, Time: time.Now(), Version: s.Version}
+	full := f.version == 0 || f.sinceFull >= f.fullEvery
+	if full {
+		e.Addresses = s.Addresses
+	} else {
+		e.Base = f.version
+		e.Added, e.Removed = added, removed
+	}
+
+	data, err := resolver.MarshalEvent(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if full {
+		err = writeAtomic(f.path, data)
+	} else {
+		err = appendFile(f.path, data)
+	}
+
+	if err != nil {
+		f.version = 0 // start over with a full event
+		return err
+	}
+
+	if full {
+		f.sinceFull = 0
+	} else {
+		f.sinceFull++
+	}
+	f.last = append([]string{}, s.Addresses...)
+	f.version = s.Version
+	return nil
+}
+
+// appendFile appends the data to the file, the line is written with a
+// single call so the readers see either all of it or none
+func appendFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// ReadDeltaFile rebuilds the last snapshot of a file written by DeltaFile,
+// a trailing partial line is ignored, an error is returned if a delta
+// doesn't apply to the previous version
+func ReadDeltaFile(path string) (resolver.Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return resolver.Snapshot{}, err
+	}
+	defer file.Close()
+
+	s := resolver.Snapshot{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		e, err := resolver.UnmarshalEvent(scanner.Bytes())
+		if err != nil {
+			break // partial line being written
+		}
+
+		if e.Base == 0 {
+			s = resolver.Snapshot{Target: e.Target, Version: e.Version, Addresses: e.Addresses}
+			continue
+		}
+
+		if line == 1 || e.Base != s.Version {
+			return resolver.Snapshot{}, fmt.Errorf("delta of version %d applies to %d, found %d", e.Version, e.Base, s.Version)
+		}
+
+		s.Version = e.Version
+		s.Addresses = applyDelta(s.Addresses, e.Added, e.Removed)
+	}
+
+	return s, scanner.Err()
+}
+
+// applyDelta returns the addresses without the removed ones and with the added ones
+func applyDelta(addrs, added, removed []string) []string {
+	gone := make(map[string]bool, len(removed))
+	for _, a := range removed {
+		gone[a] = true
+	}
+
+	rs := make([]string, 0, len(addrs)+len(added))
+	for _, a := range addrs {
+		if !gone[a] {
+			rs = append(rs, a)
+		}
+	}
+
+	return append(rs, added...)
+}
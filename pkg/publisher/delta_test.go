@@ -0,0 +1,76 @@
+package publisher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeltaFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publisher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "addresses.jsonl")
+	f := NewDeltaFile(path, 2)
+	lines := func() []string {
+		data, err := ioutil.ReadFile(path)
+		assert.Nil(t, err)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	assert.Nil(t, f.Publish(resolver.Snapshot{Target: "my-service", Version: 1, Addresses: []string{"10.0.0.1:8080"}}))
+	assert.Nil(t, f.Publish(resolver.Snapshot{Target: "my-service", Version: 1, Addresses: []string{"10.0.0.1:8080"}}))
+	assert.Nil(t, f.Publish(resolver.Snapshot{Target: "my-service", Version: 2, Addresses: []string{"10.0.0.1:8080", "10.0.0.2:8080"}}))
+	assert.Nil(t, f.Publish(resolver.Snapshot{Target: "my-service", Version: 3, Addresses: []string{"10.0.0.2:8080"}}))
+	assert.Equal(t, 3, len(lines()))
+	assert.Contains(t, lines()[1], `"base":1`)
+
+	s, err := ReadDeltaFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "my-service", s.Target)
+	assert.Equal(t, uint64(3), s.Version)
+	assert.Equal(t, []string{"10.0.0.2:8080"}, s.Addresses)
+
+	// the file is compacted after two deltas
+	assert.Nil(t, f.Publish(resolver.Snapshot{Target: "my-service", Version: 4, Addresses: []string{"10.0.0.3:8080"}}))
+	assert.Equal(t, 1, len(lines()))
+	s, err = ReadDeltaFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), s.Version)
+	assert.Equal(t, []string{"10.0.0.3:8080"}, s.Addresses)
+}
+
+func TestReadDeltaFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publisher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = ReadDeltaFile(filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+
+	// a delta without its base
+	path := filepath.Join(dir, "addresses.jsonl")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"schema":"v1","type":"change","target":"t","version":2,"base":1,"addresses":null}`+"\n"), 0644))
+	_, err = ReadDeltaFile(path)
+	assert.NotNil(t, err)
+}
+
+func TestDeltaFileKeepsOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publisher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// the addresses of the snapshot are shared with the next publishers
+	f := NewDeltaFile(filepath.Join(dir, "addresses.jsonl"), 2)
+	s := resolver.Snapshot{Target: "my-service", Version: 1, Addresses: []string{"10.0.0.2:8080", "10.0.0.1:8080"}}
+	assert.Nil(t, f.Publish(s))
+	s.Version = 2
+	assert.Nil(t, f.Publish(s))
+	assert.Equal(t, []string{"10.0.0.2:8080", "10.0.0.1:8080"}, s.Addresses)
+}
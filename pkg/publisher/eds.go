@@ -0,0 +1,101 @@
+package publisher
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+)
+
+// EDSPath is the path Envoy POSTs the REST EDS requests to
+const EDSPath = "/v3/discovery:endpoints"
+
+// EDS serves the snapshots as an Envoy REST EDS endpoint, every target
+// fed to it is a cluster named after the target, so a local Envoy sidecar
+// can be driven by the same discovery as the gRPC clients with
+// api_config_source: {api_type: REST, transport_api_version: V3, ...}
+type EDS struct {
+	m        sync.Mutex
+	version  uint64 // increased on every publication, shared by all the clusters
+	clusters map[string]ClusterLoadAssignment
+}
+
+// NewEDS creates an EDS publisher, it has to be served with an http.Server
+func NewEDS() *EDS {
+	return &EDS{clusters: map[string]ClusterLoadAssignment{}}
+}
+
+// discoveryRequest holds the fields of the Envoy requests used by EDS
+type discoveryRequest struct {
+	VersionInfo   string   `json:"version_info"`
+	ResourceNames []string `json:"resource_names"`
+}
+
+// Publish replaces the endpoints of the cluster of the snapshot target
+func (e *EDS) Publish(s resolver.Snapshot) error {
+	res, err := DiscoveryResponse(s)
+	if err != nil {
+		return err
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.version++
+	e.clusters[s.Target] = res.Resources[0]
+	return nil
+}
+
+// ServeHTTP answers the EDS requests with the requested clusters, or all
+// of them if none is requested, 304 is returned if Envoy is up to date
+func (e *EDS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != EDSPath {
+		http.NotFound(w, req)
+		return
+	}
+
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dr := discoveryRequest{}
+	if err := json.NewDecoder(req.Body).Decode(&dr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res := e.response(dr.ResourceNames)
+	if res.VersionInfo == dr.VersionInfo {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// response returns the discovery response for the given clusters,
+// sorted by name to keep the responses stable
+func (e *EDS) response(names []string) Response {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if len(names) == 0 {
+		for name := range e.clusters {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	res := Response{VersionInfo: strconv.FormatUint(e.version, 10), TypeURL: ClusterLoadAssignmentType, Resources: []ClusterLoadAssignment{}}
+	for _, name := range names {
+		if cla, ok := e.clusters[name]; ok {
+			res.Resources = append(res.Resources, cla)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,57 @@
+package publisher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+func edsRequest(t *testing.T, e *EDS, method, path, body string) (*httptest.ResponseRecorder, Response) {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	res := Response{}
+	if w.Code == http.StatusOK {
+		assert.Nil(t, json.NewDecoder(w.Body).Decode(&res))
+	}
+	return w, res
+}
+
+func TestEDS(t *testing.T) {
+	e := NewEDS()
+	assert.Nil(t, e.Publish(snapshot))
+	assert.Nil(t, e.Publish(resolver.Snapshot{Target: "other", Addresses: []string{"10.0.0.2:9090"}}))
+	assert.NotNil(t, e.Publish(resolver.Snapshot{Target: "bad", Addresses: []string{"10.0.0.2"}}))
+
+	w, res := edsRequest(t, e, http.MethodPost, EDSPath, `{}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", res.VersionInfo)
+	assert.Equal(t, 2, len(res.Resources))
+	assert.Equal(t, "my-service", res.Resources[0].ClusterName)
+	assert.Equal(t, "other", res.Resources[1].ClusterName)
+
+	w, res = edsRequest(t, e, http.MethodPost, EDSPath, `{"resource_names": ["other", "unknown"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, len(res.Resources))
+	assert.Equal(t, 9090, res.Resources[0].Endpoints[0].LbEndpoints[0].Endpoint.Address.SocketAddress.PortValue)
+
+	w, _ = edsRequest(t, e, http.MethodPost, EDSPath, `{"version_info": "2"}`)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func TestEDSErrors(t *testing.T) {
+	e := NewEDS()
+	w, _ := edsRequest(t, e, http.MethodPost, "/other", `{}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w, _ = edsRequest(t, e, http.MethodGet, EDSPath, ``)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w, _ = edsRequest(t, e, http.MethodPost, EDSPath, `{`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
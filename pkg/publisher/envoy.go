@@ -0,0 +1,112 @@
+package publisher
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+)
+
+// ClusterLoadAssignmentType is the type URL of the EDS resources
+const ClusterLoadAssignmentType = "type.googleapis.com/envoy.config.endpoint.v3.ClusterLoadAssignment"
+
+// the types below are the JSON representation of the Envoy v3 EDS API,
+// only the fields required to describe the endpoints are included
+
+// Response is an Envoy discovery response
+type Response struct {
+	VersionInfo string                  `json:"version_info"`
+	Resources   []ClusterLoadAssignment `json:"resources"`
+	TypeURL     string                  `json:"type_url"`
+}
+
+// ClusterLoadAssignment lists the endpoints of a cluster
+type ClusterLoadAssignment struct {
+	Type        string             `json:"@type"`
+	ClusterName string             `json:"cluster_name"`
+	Endpoints   []LocalityEndpoint `json:"endpoints"`
+}
+
+// LocalityEndpoint groups the endpoints of a locality
+type LocalityEndpoint struct {
+	Locality            *resolver.Locality `json:"locality,omitempty"`
+	LbEndpoints         []LbEndpoint       `json:"lb_endpoints"`
+	LoadBalancingWeight uint32             `json:"load_balancing_weight,omitempty"`
+}
+
+// LbEndpoint is an endpoint of a cluster
+type LbEndpoint struct {
+	Endpoint Endpoint `json:"endpoint"`
+}
+
+// Endpoint is the address of an endpoint
+type Endpoint struct {
+	Address Address `json:"address"`
+}
+
+// Address is a socket address
+type Address struct {
+	SocketAddress SocketAddress `json:"socket_address"`
+}
+
+// SocketAddress is an IP and a port
+type SocketAddress struct {
+	Address   string `json:"address"`
+	PortValue int    `json:"port_value"`
+}
+
+// DiscoveryResponse returns the snapshot as an EDS discovery response
+// with a cluster named after the target and the version of the snapshot,
+// the endpoints are grouped by the locality attached with WithLocality
+// and the localities carry their weight
+func DiscoveryResponse(s resolver.Snapshot) (Response, error) {
+	localities := map[string]resolver.Locality{}
+	weights := map[string]uint32{}
+	for _, a := range s.State.Addresses {
+		if l, w, ok := resolver.LocalityFrom(a); ok {
+			localities[a.Addr], weights[a.Addr] = l, w
+		}
+	}
+
+	groups := []LocalityEndpoint{}
+	index := map[resolver.Locality]int{}
+	for _, a := range s.Addresses {
+		host, port, err := net.SplitHostPort(a)
+		if err != nil {
+			return Response{}, err
+		}
+
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return Response{}, err
+		}
+
+		l, ok := localities[a]
+		i, found := index[l]
+		if !found {
+			i = len(groups)
+			index[l] = i
+			groups = append(groups, LocalityEndpoint{LbEndpoints: []LbEndpoint{}})
+			if ok {
+				locality := l
+				groups[i].Locality, groups[i].LoadBalancingWeight = &locality, weights[a]
+			}
+		}
+
+		groups[i].LbEndpoints = append(groups[i].LbEndpoints, LbEndpoint{Endpoint{Address{SocketAddress{host, p}}}})
+	}
+
+	if len(groups) == 0 {
+		groups = append(groups, LocalityEndpoint{LbEndpoints: []LbEndpoint{}})
+	}
+
+	return Response{
+		VersionInfo: strconv.FormatUint(s.Version, 10),
+		Resources: []ClusterLoadAssignment{{
+			Type:        ClusterLoadAssignmentType,
+			ClusterName: s.Target,
+			Endpoints:   groups,
+		}},
+		TypeURL: ClusterLoadAssignmentType,
+	}, nil
+}
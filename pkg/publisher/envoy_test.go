@@ -0,0 +1,58 @@
+package publisher
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoveryResponse(t *testing.T) {
+	res, err := DiscoveryResponse(snapshot)
+	assert.Nil(t, err)
+	assert.Equal(t, "3", res.VersionInfo)
+	assert.Equal(t, "my-service", res.Resources[0].ClusterName)
+
+	data, err := json.Marshal(res.Resources[0].Endpoints[0].LbEndpoints[1])
+	assert.Nil(t, err)
+	assert.Equal(t, `{"endpoint":{"address":{"socket_address":{"address":"::1","port_value":8080}}}}`, string(data))
+
+	_, err = DiscoveryResponse(resolver.Snapshot{Addresses: []string{"10.0.0.1"}})
+	assert.NotNil(t, err)
+	_, err = DiscoveryResponse(resolver.Snapshot{Addresses: []string{"10.0.0.1:http"}})
+	assert.NotNil(t, err)
+}
+
+func TestDiscoveryResponseLocalities(t *testing.T) {
+	var s resolver.Snapshot
+	capture := resolver.PublisherFunc(func(snap resolver.Snapshot) error {
+		s = snap
+		return nil
+	})
+	zones := map[string]resolver.Locality{
+		"10.0.0.1:8080": {Region: "eu", Zone: "eu-1"},
+		"10.0.0.2:8080": {Region: "eu", Zone: "eu-2"},
+		"10.0.0.3:8080": {Region: "eu", Zone: "eu-1"},
+	}
+	zone := func(addr string) (resolver.Locality, bool) {
+		l, ok := zones[addr]
+		return l, ok
+	}
+	answers := []resolver.SimulatedAnswer{{Time: time.Now(), IPs: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}}}
+	resolver.Simulate("my-service", "8080", answers, resolver.WithPublishers(capture), resolver.WithLocality(zone))
+
+	res, err := DiscoveryResponse(s)
+	assert.Nil(t, err)
+	data, err := json.Marshal(res.Resources[0].Endpoints)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[
+		{"locality":{"region":"eu","zone":"eu-1"},"load_balancing_weight":2,"lb_endpoints":[
+			{"endpoint":{"address":{"socket_address":{"address":"10.0.0.1","port_value":8080}}}},
+			{"endpoint":{"address":{"socket_address":{"address":"10.0.0.3","port_value":8080}}}}
+		]},
+		{"locality":{"region":"eu","zone":"eu-2"},"load_balancing_weight":1,"lb_endpoints":[{"endpoint":{"address":{"socket_address":{"address":"10.0.0.2","port_value":8080}}}}]},
+		{"lb_endpoints":[{"endpoint":{"address":{"socket_address":{"address":"10.0.0.4","port_value":8080}}}}]}
+	]`, string(data))
+}
@@ -0,0 +1,110 @@
+// Package publisher contains resolver.Publisher implementations writing
+// the address snapshots for other processes, e.g. a proxy sidecar
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+)
+
+// Format is the format the snapshots are written in
+type Format int
+
+const (
+	// JSON writes the snapshot as a JSON object, see resolver.MarshalSnapshot
+	JSON Format = iota
+	// HAProxy writes a server line per address, to be included in a backend section
+	HAProxy
+	// Envoy writes an EDS discovery response, to be used as a path based EDS config
+	Envoy
+)
+
+// File writes the snapshots to a file on every publication, the file is
+// replaced atomically so the readers never see a partial write
+type File struct {
+	path   string
+	format Format
+	m      sync.Mutex
+}
+
+// NewFile creates a publisher writing to the given path in the given format
+func NewFile(path string, format Format) *File {
+	return &File{path: path, format: format}
+}
+
+// Publish writes the snapshot to the file
+func (f *File) Publish(s resolver.Snapshot) error {
+	data, err := render(f.format, s)
+	if err != nil {
+		return err
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+	return writeAtomic(f.path, data)
+}
+
+// render returns the snapshot in the given format
+func render(format Format, s resolver.Snapshot) ([]byte, error) {
+	switch format {
+	case JSON:
+		return resolver.MarshalSnapshot(s)
+	case HAProxy:
+		var b strings.Builder
+		for i, a := range s.Addresses {
+			fmt.Fprintf(&b, "server %s-%d %s check\n", s.Target, i, a)
+		}
+		return []byte(b.String()), nil
+	case Envoy:
+		res, err := DiscoveryResponse(s)
+		if err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(res, "", "  ")
+	default:
+		return nil, fmt.Errorf("unknown format %d", format)
+	}
+}
+
+// fileMode is the mode of the written files, the temporary files are
+// created 0600 and the readers, e.g. a proxy sidecar, often run as
+// another user
+const fileMode = 0644
+
+// writeAtomic writes the data to a temporary file in the same directory
+// and renames it to the path, the rename is atomic on POSIX systems
+func writeAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(fileMode); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,57 @@
+package publisher
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+var snapshot = resolver.Snapshot{Target: "my-service", Version: 3, Addresses: []string{"10.0.0.1:8080", "[::1]:8080"}}
+
+func TestFileJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publisher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "addresses.json")
+	assert.Nil(t, NewFile(path, JSON).Publish(snapshot))
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	got := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(data, &got))
+	assert.Equal(t, "my-service", got["target"])
+	assert.Equal(t, float64(3), got["version"])
+	assert.Equal(t, []interface{}{"10.0.0.1:8080", "[::1]:8080"}, got["addresses"])
+
+	// no temporary files left behind
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+
+	// readable by the other users, not 0600 like the temporary file
+	assert.Equal(t, os.FileMode(0644), files[0].Mode().Perm())
+}
+
+func TestFileHAProxy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publisher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "servers.cfg")
+	assert.Nil(t, NewFile(path, HAProxy).Publish(snapshot))
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "server my-service-0 10.0.0.1:8080 check\nserver my-service-1 [::1]:8080 check\n", string(data))
+}
+
+func TestFileErrors(t *testing.T) {
+	assert.NotNil(t, NewFile("/nonexistent/dir/file", JSON).Publish(snapshot))
+	assert.NotNil(t, NewFile(filepath.Join(os.TempDir(), "file"), Format(10)).Publish(snapshot))
+}
@@ -0,0 +1,118 @@
+package publisher
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os/exec"
+	"sync"
+	"text/template"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+)
+
+// funcs are the functions available to the templates besides the built-in ones
+var funcs = template.FuncMap{
+	"host": func(addr string) string {
+		host, _, _ := net.SplitHostPort(addr)
+		return host
+	},
+	"port": func(addr string) string {
+		_, port, _ := net.SplitHostPort(addr)
+		return port
+	},
+	// host:port with the brackets of the IPv6 hosts, e.g. [::1]:8080
+	"hostport": net.JoinHostPort,
+}
+
+// Template renders a Go template with the snapshot (Target, Version and
+// Addresses fields, host and port functions splitting an address and
+// hostport joining them back, IPv6 brackets included) to a file and runs
+// the reload command when the rendered file changed, e.g. to reload nginx
+// or haproxy. The reload runs in the background so a slow command never
+// delays the publication, the changes made meanwhile are reloaded once
+// and the failures are logged
+type Template struct {
+	tmpl   *template.Template
+	path   string
+	reload []string
+	m      sync.Mutex
+
+	rm        sync.Mutex
+	reloading bool // a reload goroutine is running
+	pending   bool // the file changed since the running reload started
+}
+
+// NewTemplate parses the template, the reload command is optional
+func NewTemplate(text, path string, reload ...string) (*Template, error) {
+	tmpl, err := template.New(path).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Template{tmpl: tmpl, path: path, reload: reload}, nil
+}
+
+// Publish renders the snapshot, the file is only written and the
+// reload command scheduled if the content changed
+func (t *Template) Publish(s resolver.Snapshot) error {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, s); err != nil {
+		return err
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	if current, err := ioutil.ReadFile(t.path); err == nil && bytes.Equal(current, buf.Bytes()) {
+		return nil
+	}
+
+	if err := writeAtomic(t.path, buf.Bytes()); err != nil {
+		return err
+	}
+
+	if len(t.reload) > 0 {
+		t.scheduleReload()
+	}
+
+	return nil
+}
+
+// scheduleReload runs the reload command in the background, coalescing
+// the requests made while it runs into a single run afterwards
+func (t *Template) scheduleReload() {
+	t.rm.Lock()
+	defer t.rm.Unlock()
+	t.pending = true
+	if !t.reloading {
+		t.reloading = true
+		go t.runReloads()
+	}
+}
+
+func (t *Template) runReloads() {
+	for {
+		t.rm.Lock()
+		if !t.pending {
+			t.reloading = false
+			t.rm.Unlock()
+			return
+		}
+		t.pending = false
+		t.rm.Unlock()
+
+		if err := t.runReload(); err != nil {
+			log.Println("[grpc-resolver]: error reloading ", t.path, " ", err)
+		}
+	}
+}
+
+func (t *Template) runReload() error {
+	if out, err := exec.Command(t.reload[0], t.reload[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("reload command failed: %v: %s", err, out)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package publisher
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver"
+	"github.com/stretchr/testify/assert"
+)
+
+const upstream = `upstream {{.Target}} {
+{{- range .Addresses}}
+  server {{hostport (host .) (port .)}};
+{{- end}}
+}
+`
+
+func TestTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publisher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "upstream.conf")
+	reloads := filepath.Join(dir, "reloads")
+	p, err := NewTemplate(upstream, path, "sh", "-c", "echo reload >> "+reloads)
+	assert.Nil(t, err)
+
+	assert.Nil(t, p.Publish(snapshot))
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "upstream my-service {\n  server 10.0.0.1:8080;\n  server [::1]:8080;\n}\n", string(data))
+	waitReloads(t, reloads, "reload\n")
+
+	// same content, no reload
+	assert.Nil(t, p.Publish(resolver.Snapshot{Target: "my-service", Version: 4, Addresses: snapshot.Addresses}))
+	waitReloads(t, reloads, "reload\n")
+
+	assert.Nil(t, p.Publish(resolver.Snapshot{Target: "my-service", Addresses: []string{"10.0.0.2:8080"}}))
+	waitReloads(t, reloads, "reload\nreload\n")
+}
+
+// waitReloads waits for the reloads of the background reload command
+func waitReloads(t *testing.T, path, expected string) {
+	assert.Eventually(t, func() bool {
+		data, _ := ioutil.ReadFile(path)
+		return string(data) == expected
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestTemplateReloadOutsidePublish(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publisher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// a slow reload doesn't delay the publications, the changes made
+	// while it runs are reloaded once
+	reloads := filepath.Join(dir, "reloads")
+	p, err := NewTemplate(upstream, filepath.Join(dir, "upstream.conf"), "sh", "-c", "sleep 0.2; echo reload >> "+reloads)
+	assert.Nil(t, err)
+
+	start := time.Now()
+	assert.Nil(t, p.Publish(resolver.Snapshot{Target: "my-service", Addresses: []string{"10.0.0.1:8080"}}))
+
+	// the first reload is running, the next changes wait for it
+	assert.Eventually(t, func() bool {
+		p.rm.Lock()
+		defer p.rm.Unlock()
+		return p.reloading && !p.pending
+	}, time.Second, time.Millisecond)
+	for i := 2; i <= 3; i++ {
+		assert.Nil(t, p.Publish(resolver.Snapshot{Target: "my-service", Addresses: []string{fmt.Sprintf("10.0.0.%d:8080", i)}}))
+	}
+	assert.True(t, time.Since(start) < 200*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		data, _ := ioutil.ReadFile(reloads)
+		return string(data) == "reload\nreload\n"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestTemplateErrors(t *testing.T) {
+	_, err := NewTemplate("{{.Target", "file")
+	assert.NotNil(t, err)
+
+	dir, err := ioutil.TempDir("", "publisher")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	p, err := NewTemplate("{{.Unknown}}", filepath.Join(dir, "file"))
+	assert.Nil(t, err)
+	assert.NotNil(t, p.Publish(snapshot))
+
+	// the reload failures are only logged
+	p, err = NewTemplate("{{.Target}}", filepath.Join(dir, "file"), "false")
+	assert.Nil(t, err)
+	assert.Nil(t, p.Publish(snapshot))
+	assert.NotNil(t, p.runReload())
+}
+
+func TestTemplateHostport(t *testing.T) {
+	p, err := NewTemplate(`{{range .Addresses}}{{hostport (host .) "9090"}} {{end}}`, "file")
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, p.tmpl.Execute(&buf, snapshot))
+	assert.Equal(t, "10.0.0.1:9090 [::1]:9090 ", buf.String())
+}
@@ -0,0 +1,104 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSAnswer is the metadata of a DNS answer, only available when the
+// queries go through the Go resolver dialer, i.e. with WithDNSServer,
+// WithViews, WithForceTCP, WithAuthoritative or WithClientSubnet
+type DNSAnswer struct {
+	Server        string    `json:"server"`        // DNS server that sent the answer
+	Name          string    `json:"name"`          // queried name
+	Type          string    `json:"type"`          // queried record type, e.g. TypeA
+	RCode         string    `json:"rcode"`         // response code, e.g. RCodeNameError for NXDOMAIN
+	Truncated     bool      `json:"truncated"`     // the answer didn't fit in the UDP message
+	Authoritative bool      `json:"authoritative"` // the answer comes from a name server of the zone
+	Time          time.Time `json:"time"`
+}
+
+// String returns a short description of the answer for the logs
+func (a DNSAnswer) String() string {
+	flags := ""
+	if a.Authoritative {
+		flags += " authoritative"
+	}
+	if a.Truncated {
+		flags += " truncated"
+	}
+
+	return fmt.Sprintf("%s %s %s from %s%s", a.Name, a.Type, a.RCode, a.Server, flags)
+}
+
+// dnsAnswers records the answers received during a resolution, it
+// travels in the context of the lookups
+type dnsAnswers struct {
+	sync.Mutex
+	list []DNSAnswer
+}
+
+type dnsAnswersKey struct{}
+
+func withDNSAnswers(ctx context.Context) (context.Context, *dnsAnswers) {
+	a := &dnsAnswers{}
+	return context.WithValue(ctx, dnsAnswersKey{}, a), a
+}
+
+// dnsAnswersFrom returns the recorder of the context, nil if none
+func dnsAnswersFrom(ctx context.Context) *dnsAnswers {
+	a, _ := ctx.Value(dnsAnswersKey{}).(*dnsAnswers)
+	return a
+}
+
+// record parses the header and the question of the answer
+func (a *dnsAnswers) record(msg []byte, server string) {
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil {
+		return
+	}
+
+	answer := DNSAnswer{Server: server, RCode: h.RCode.String(), Truncated: h.Truncated, Authoritative: h.Authoritative, Time: time.Now()}
+	if q, err := p.Question(); err == nil {
+		answer.Name = q.Name.String()
+		answer.Type = q.Type.String()
+	}
+
+	a.Lock()
+	a.list = append(a.list, answer)
+	a.Unlock()
+}
+
+// all returns the recorded answers
+func (a *dnsAnswers) all() []DNSAnswer {
+	if a == nil {
+		return nil
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	return append([]DNSAnswer{}, a.list...)
+}
+
+// describeAnswers returns the failed answers recorded in the context to
+// be added to the lookup errors, empty if none
+func describeAnswers(ctx context.Context) string {
+	failed := []string{}
+	for _, a := range dnsAnswersFrom(ctx).all() {
+		if a.RCode != dnsmessage.RCodeSuccess.String() || a.Truncated {
+			failed = append(failed, a.String())
+		}
+	}
+
+	if len(failed) == 0 {
+		return ""
+	}
+
+	return "(" + strings.Join(failed, ", ") + ")"
+}
@@ -0,0 +1,79 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveNXDomain answers every query with an authoritative NXDOMAIN
+func serveNXDomain(pc net.PacketConn) {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		query := dnsmessage.Message{}
+		if err := query.Unpack(buf[:n]); err != nil {
+			continue
+		}
+
+		answer := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true, RCode: dnsmessage.RCodeNameError},
+			Questions: query.Questions,
+		}
+		packed, _ := answer.Pack()
+		pc.WriteTo(packed, addr)
+	}
+}
+
+func TestDNSAnswers(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+	go serveNXDomain(pc)
+
+	r := NewResolver("missing.test.", "8080", false, &refreshRate, nil, WithDNSServer(pc.LocalAddr().String()))
+	r.StartResolver()
+
+	answers := r.Status().DNSAnswers
+	assert.True(t, len(answers) > 0)
+	for _, a := range answers {
+		assert.Equal(t, pc.LocalAddr().String(), a.Server)
+		assert.Equal(t, "missing.test.", a.Name)
+		assert.Equal(t, dnsmessage.RCodeNameError.String(), a.RCode)
+		assert.True(t, a.Authoritative)
+		assert.False(t, a.Truncated)
+	}
+	assert.Contains(t, answers[0].String(), "authoritative")
+}
+
+func TestDescribeAnswers(t *testing.T) {
+	ctx, answers := withDNSAnswers(context.Background())
+	assert.Equal(t, "", describeAnswers(ctx))
+	assert.Equal(t, "", describeAnswers(context.Background()))
+
+	ok := dnsmessage.Message{Header: dnsmessage.Header{Response: true}, Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName("ok.test."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}}}
+	packed, err := ok.Pack()
+	assert.Nil(t, err)
+	answers.record(packed, "10.0.0.53:53")
+	assert.Equal(t, "", describeAnswers(ctx))
+
+	failed := ok
+	failed.Header.RCode = dnsmessage.RCodeServerFailure
+	failed.Header.Truncated = true
+	packed, err = failed.Pack()
+	assert.Nil(t, err)
+	answers.record(packed, "10.0.0.53:53")
+	desc := describeAnswers(ctx)
+	assert.True(t, strings.HasPrefix(desc, "(ok.test. TypeA RCodeServerFailure from 10.0.0.53:53 truncated"))
+
+	answers.record([]byte("garbage"), "10.0.0.53:53")
+	assert.Equal(t, 2, len(answers.all()))
+}
@@ -0,0 +1,18 @@
+package resolver
+
+import (
+	"google.golang.org/grpc/resolver"
+)
+
+// withServerName returns a copy of the addresses with the server name
+// set, gRPC uses it instead of the target host to verify the certificate
+// of the server, so the published IPs are dialed as the virtual host
+func withServerName(addrs []resolver.Address, name string) []resolver.Address {
+	named := make([]resolver.Address, 0, len(addrs))
+	for _, a := range addrs {
+		a.ServerName = name
+		named = append(named, a)
+	}
+
+	return named
+}
@@ -0,0 +1,24 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestWithServerName(t *testing.T) {
+	addrs := []resolver.Address{{Addr: "10.0.0.1:443"}, {Addr: "10.0.0.2:443"}}
+	named := withServerName(addrs, "api.example.com")
+	assert.Equal(t, "api.example.com", named[1].ServerName)
+	assert.Equal(t, "", addrs[1].ServerName)
+
+	cc := resolvertest.NewClientConn()
+	rb := NewDomainResolverBuilder("my-schema", "127.0.0.1", "443", false, nil, WithServerName("api.example.com"))
+	_, err := rb.Build(resolver.Target{Scheme: "my-schema", Endpoint: "127.0.0.1:443"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+
+	st, _ := cc.LastState()
+	assert.Equal(t, []resolver.Address{{Addr: "127.0.0.1:443", ServerName: "api.example.com"}}, st.Addresses)
+}
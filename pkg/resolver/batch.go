@@ -0,0 +1,61 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+)
+
+// ResolveAll resolves the targets concurrently once, with the same
+// semantics and options as the watched resolvers, the result maps every
+// target (in the host:port format) to its sorted address list, an error
+// listing the targets without addresses is returned along with the
+// addresses of the other targets
+func ResolveAll(ctx context.Context, targets []Target, opts ...Option) (map[string][]string, error) {
+	var m sync.Mutex
+	var wg sync.WaitGroup
+	result := make(map[string][]string, len(targets))
+	failed := []string{}
+
+	opts = append(append([]Option{}, opts...), WithContext(ctx))
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t Target) {
+			defer wg.Done()
+			topts := append(append([]Option{}, opts...), t.Options()...)
+			addrs := NewResolver(t.Host, t.Port, false, nil, nil, topts...).lookupOnce()
+
+			m.Lock()
+			defer m.Unlock()
+			if len(addrs) == 0 {
+				failed = append(failed, t.String())
+				return
+			}
+			result[t.String()] = addrs
+		}(t)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return result, fmt.Errorf("no addresses found for %s", strings.Join(failed, ", "))
+	}
+
+	return result, nil
+}
+
+// lookupOnce returns the sorted address list without
+// updating the resolver state
+func (r *DomainResolver) lookupOnce() []string {
+	if !r.needLookup {
+		return append([]string{}, r.Addresses...)
+	}
+
+	addrs := list.FromAddrToString(r.resolve(r.ctx))
+	sort.Strings(addrs)
+	return addrs
+}
@@ -0,0 +1,31 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveAll(t *testing.T) {
+	hosts := map[string][]string{"my-service": {"10.0.0.2", "10.0.0.1"}}
+	targets := []Target{{Host: "my-service", Port: "8080"}, {Host: "127.0.0.1", Port: "9090"}}
+	result, err := ResolveAll(context.Background(), targets, WithHosts(hosts, true))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{
+		"my-service:8080": {"10.0.0.1:8080", "10.0.0.2:8080"},
+		"127.0.0.1:9090":  {"127.0.0.1:9090"},
+	}, result)
+
+	targets = append(targets, Target{Host: "unknown", Port: "80"})
+	result, err = ResolveAll(context.Background(), targets, WithHosts(hosts, true))
+	assert.EqualError(t, err, "no addresses found for unknown:80")
+	assert.Equal(t, 2, len(result))
+}
+
+func TestResolveAllCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ResolveAll(ctx, []Target{{Host: "localhost", Port: "8080"}})
+	assert.NotNil(t, err)
+}
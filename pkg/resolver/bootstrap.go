@@ -0,0 +1,41 @@
+package resolver
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"google.golang.org/grpc/resolver"
+)
+
+// startBootstrapped publishes the bootstrap addresses right away and
+// resolves the domain in background, the bootstrap addresses are replaced
+// once the first resolution returns records
+func (r *DomainResolver) startBootstrapped() {
+	addrs := make([]resolver.Address, 0, len(r.bootstrap))
+	for _, a := range r.bootstrap {
+		addrs = append(addrs, resolver.Address{Addr: a})
+	}
+	addrs = r.prepare(addrs)
+
+	r.m.Lock()
+	r.Addresses = list.FromAddrToString(addrs)
+	r.setResolved(addrs)
+	sources := map[string]string{}
+	for _, a := range r.Addresses {
+		sources[a] = SourceBootstrap
+	}
+	r.recordProvenance(sources, time.Now())
+	sort.Strings(r.Addresses)
+	r.touch(r.Addresses)
+	r.sendLatest()
+	r.m.Unlock()
+
+	if r.needWatcher {
+		register(r)
+		go r.watch()
+	}
+
+	r.publish(resolver.State{Addresses: addrs})
+	go r.Refresh()
+}
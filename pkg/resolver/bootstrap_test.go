@@ -0,0 +1,53 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestBootstrapAddresses(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	rb := NewDomainResolverBuilder("my-schema", "localhost", "8080", false, nil, WithBootstrapAddresses([]string{"10.0.0.2:8080", "10.0.0.1:8080"}))
+	rr, err := rb.Build(resolver.Target{Scheme: "my-schema", Endpoint: "localhost:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	defer rr.Close()
+
+	// the bootstrap addresses are published first and replaced by the resolution
+	assert.Eventually(t, func() bool { return len(cc.States()) == 2 }, time.Second, 10*time.Millisecond)
+	states := cc.States()
+	assert.Equal(t, []string{"10.0.0.2:8080", "10.0.0.1:8080"}, list.FromAddrToString(states[0].Addresses))
+	assert.NotContains(t, list.FromAddrToString(states[1].Addresses), "10.0.0.1:8080")
+
+	r := rr.(*DomainResolver)
+	r.m.Lock()
+	defer r.m.Unlock()
+	assert.NotContains(t, r.Addresses, "10.0.0.1:8080")
+}
+
+func TestBootstrapAddressesFailedResolution(t *testing.T) {
+	r := NewResolver("unknown.invalid", "8080", false, &refreshRate, nil, WithBootstrapAddresses([]string{"10.0.0.1:8080"}))
+	r.StartResolver()
+	time.Sleep(50 * time.Millisecond)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	assert.Equal(t, []string{"10.0.0.1:8080"}, r.Addresses)
+}
+
+func TestBootstrapAddressesPipeline(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "unknown.invalid", "8080", false, nil,
+		WithBootstrapAddresses([]string{"10.0.0.2:8080", "10.0.0.1:8080"}), WithPortRules(PortRule{CIDR: "10.0.0.0/8", To: "15001"}), WithMaxAddresses(1, false))
+	r.StartResolver()
+	defer r.Close()
+
+	// the bootstrap addresses go through the ports and the cap like the resolved ones
+	st, ok := cc.LastState()
+	assert.True(t, ok)
+	assert.Equal(t, []string{"10.0.0.1:15001"}, list.FromAddrToString(st.Addresses))
+}
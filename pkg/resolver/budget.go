@@ -0,0 +1,196 @@
+package resolver
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// EventHealth is emitted when the error budget state changes, see WithErrorBudget
+const EventHealth = "health"
+
+// stalenessChecks is the number of times the staleness is checked
+// within MaxStaleness, besides the checks done on every refresh
+const stalenessChecks = 4
+
+// error budget states
+const (
+	// BudgetHealthy means the refreshes are within the error budget
+	BudgetHealthy = "healthy"
+	// BudgetDegraded means too many refreshes failed over the window
+	BudgetDegraded = "degraded"
+	// BudgetStale means no refresh succeeded for longer than the staleness allowed
+	BudgetStale = "stale"
+)
+
+// ErrorBudget is the policy deciding the state reported by the health
+// events, zero values disable the corresponding check
+type ErrorBudget struct {
+	Window       time.Duration // window the success ratio is computed over, e.g. 10 minutes
+	MinSuccess   float64       // ratio of successful refreshes below which the resolver is degraded, e.g. 0.9
+	MaxStaleness time.Duration // time since the last successful refresh after which the resolver is stale
+}
+
+// budget tracks the refreshes of the window and the current state
+type budget struct {
+	sync.Mutex
+	ErrorBudget
+	started     time.Time
+	results     []budgetResult // refreshes in the window, oldest first
+	lastSuccess time.Time
+	state       string
+}
+
+type budgetResult struct {
+	time time.Time
+	ok   bool
+}
+
+// record adds the refresh and returns the new state and its reason
+// if it changed, the zero state is healthy
+func (b *budget) record(now time.Time, ok bool) (state, reason string, changed bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.started.IsZero() {
+		b.started = now
+	}
+	if ok {
+		b.lastSuccess = now
+	}
+	b.results = append(b.results, budgetResult{now, ok})
+	i := 0
+	for i < len(b.results) && now.Sub(b.results[i].time) > b.Window {
+		i++
+	}
+	b.results = b.results[i:]
+
+	state, reason = BudgetHealthy, ""
+	last := b.lastSince()
+
+	succeeded := 0
+	for _, r := range b.results {
+		if r.ok {
+			succeeded++
+		}
+	}
+	ratio := float64(succeeded) / float64(len(b.results))
+
+	switch {
+	case b.MaxStaleness > 0 && now.Sub(last) > b.MaxStaleness:
+		state, reason = BudgetStale, fmt.Sprintf("no successful refresh for %v", now.Sub(last).Round(time.Second))
+	case b.MinSuccess > 0 && b.Window > 0 && ratio < b.MinSuccess:
+		state, reason = BudgetDegraded, fmt.Sprintf("%.0f%% of the refreshes succeeded over %v", ratio*100, b.Window)
+	}
+
+	if b.state == "" {
+		b.state = BudgetHealthy
+	}
+	if state == b.state {
+		return state, reason, false
+	}
+
+	b.state = state
+	return state, reason, true
+}
+
+// checkStale marks the budget stale when no refresh succeeded for longer
+// than MaxStaleness, unlike record it doesn't need a refresh to finish,
+// so a resolver whose refreshes hang still goes stale
+func (b *budget) checkStale(now time.Time) (reason string, changed bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.started.IsZero() {
+		b.started = now
+	}
+	last := b.lastSince()
+	if b.MaxStaleness <= 0 || b.state == BudgetStale || now.Sub(last) <= b.MaxStaleness {
+		return "", false
+	}
+
+	b.state = BudgetStale
+	return fmt.Sprintf("no successful refresh for %v", now.Sub(last).Round(time.Second)), true
+}
+
+// lastSince returns the time of the last successful refresh, or the
+// start if none succeeded yet, it's expected to be called with the lock held
+func (b *budget) lastSince() time.Time {
+	if b.lastSuccess.IsZero() {
+		return b.started
+	}
+
+	return b.lastSuccess
+}
+
+// current returns the current state, healthy if nothing was recorded yet
+func (b *budget) current() string {
+	b.Lock()
+	defer b.Unlock()
+	if b.state == "" {
+		return BudgetHealthy
+	}
+
+	return b.state
+}
+
+// recordBudget records the result of the resolution event and emits a
+// health event when the state changes
+func (r *DomainResolver) recordBudget(e Event) {
+	if r.budget == nil || e.Type != EventResolution {
+		return
+	}
+
+	state, reason, changed := r.budget.record(r.now(), e.Error == "")
+	if !changed {
+		return
+	}
+
+	r.reportHealth(state, reason, e.Addresses)
+}
+
+// checkStaleness emits a stale health event when no refresh succeeded
+// for longer than MaxStaleness, even if no resolution event arrived
+func (r *DomainResolver) checkStaleness() {
+	reason, changed := r.budget.checkStale(r.now())
+	if !changed {
+		return
+	}
+
+	r.m.Lock()
+	addrs := append([]string{}, r.Addresses...)
+	r.m.Unlock()
+	r.reportHealth(BudgetStale, reason, addrs)
+}
+
+// watchStaleness checks the staleness on a timer until the resolver is
+// closed, see checkStaleness
+func (r *DomainResolver) watchStaleness() {
+	ticker := time.NewTicker(r.budget.MaxStaleness / stalenessChecks)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.closing:
+			return
+		case <-ticker.C:
+			r.checkStaleness()
+		}
+	}
+}
+
+// reportHealth logs and emits the new error budget state
+func (r *DomainResolver) reportHealth(state, reason string, addrs []string) {
+	log.Printf("[grpc-resolver]: %s is %s %s%s", r.address, state, reason, r.labelsString())
+	r.emit(Event{Type: EventHealth, Time: r.now(), Health: state, Error: reason, Addresses: addrs})
+}
+
+// BudgetState returns the error budget state of the resolver, healthy
+// if it has no error budget, see WithErrorBudget
+func (r *DomainResolver) BudgetState() string {
+	if r.budget == nil {
+		return BudgetHealthy
+	}
+
+	return r.budget.current()
+}
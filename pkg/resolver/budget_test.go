@@ -0,0 +1,143 @@
+package resolver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorBudget(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ips := []string{"10.0.0.1"}
+	answers := []SimulatedAnswer{
+		{Time: start, IPs: ips},
+		{Time: start.Add(time.Minute)},                // 50% over the window
+		{Time: start.Add(2 * time.Minute)},            // still degraded, no event
+		{Time: start.Add(4 * time.Minute)},            // 4 minutes without success
+		{Time: start.Add(5 * time.Minute), IPs: ips},  // 2 of 5 in the window
+		{Time: start.Add(8 * time.Minute), IPs: ips},  // 2 of 3, still degraded
+		{Time: start.Add(9 * time.Minute), IPs: ips},  // 3 of 4
+		{Time: start.Add(10 * time.Minute), IPs: ips}, // 4 of 4, healthy again
+	}
+
+	buf := &bytes.Buffer{}
+	budget := ErrorBudget{Window: 5 * time.Minute, MinSuccess: 0.9, MaxStaleness: 3 * time.Minute}
+	Simulate("my-service", "8080", answers, WithErrorBudget(budget), WithEventLog(buf))
+
+	health := []Event{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		e := Event{}
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &e))
+		if e.Type == EventHealth {
+			health = append(health, e)
+		}
+	}
+
+	assert.Equal(t, 4, len(health))
+	assert.Equal(t, BudgetDegraded, health[0].Health)
+	assert.Equal(t, start.Add(time.Minute), health[0].Time)
+	assert.Equal(t, "50% of the refreshes succeeded over 5m0s", health[0].Error)
+	assert.Equal(t, BudgetStale, health[1].Health)
+	assert.Equal(t, "no successful refresh for 4m0s", health[1].Error)
+	assert.Equal(t, BudgetDegraded, health[2].Health)
+	assert.Equal(t, BudgetHealthy, health[3].Health)
+	assert.Equal(t, start.Add(10*time.Minute), health[3].Time)
+}
+
+func TestBudgetState(t *testing.T) {
+	r := NewResolver("my-service", "8080", false, nil, nil)
+	assert.Equal(t, BudgetHealthy, r.BudgetState())
+
+	r = NewResolver("my-service", "8080", false, nil, nil, WithErrorBudget(ErrorBudget{MaxStaleness: time.Minute}))
+	now := time.Now()
+	r.simulation = &simulation{now: now}
+	r.recordBudget(Event{Type: EventResolution, Error: "no records found"})
+	assert.Equal(t, BudgetHealthy, r.BudgetState())
+	r.simulation.now = now.Add(2 * time.Minute)
+	r.recordBudget(Event{Type: EventResolution, Error: "no records found"})
+	assert.Equal(t, BudgetStale, r.BudgetState())
+}
+
+func TestStalenessWithoutRefresh(t *testing.T) {
+	r := NewResolver("my-service", "8080", false, nil, nil, WithErrorBudget(ErrorBudget{MaxStaleness: time.Minute}))
+	defer r.Close()
+	events := r.WatchEvents()
+
+	now := time.Now()
+	r.simulation = &simulation{now: now}
+	r.checkStaleness()
+	assert.Equal(t, BudgetHealthy, r.BudgetState())
+
+	// no resolution event arrived, the check alone makes it stale
+	r.simulation.now = now.Add(2 * time.Minute)
+	r.checkStaleness()
+	assert.Equal(t, BudgetStale, r.BudgetState())
+	e := <-events
+	assert.Equal(t, EventHealth, e.Type)
+	assert.Equal(t, BudgetStale, e.Health)
+	assert.Equal(t, "no successful refresh for 2m0s", e.Error)
+
+	// reported once
+	r.checkStaleness()
+	assert.Equal(t, 0, len(events))
+
+	r.simulation.now = now.Add(3 * time.Minute)
+	r.recordBudget(Event{Type: EventResolution})
+	assert.Equal(t, BudgetHealthy, r.BudgetState())
+	assert.Equal(t, BudgetHealthy, (<-events).Health)
+}
+
+func TestWatchStaleness(t *testing.T) {
+	r := NewResolver("my-service", "8080", false, nil, nil, WithErrorBudget(ErrorBudget{MaxStaleness: 20 * time.Millisecond}))
+	defer r.Close()
+	events := r.WatchEvents()
+
+	r.budget.checkStale(time.Now())
+	go r.watchStaleness()
+	select {
+	case e := <-events:
+		assert.Equal(t, BudgetStale, e.Health)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no stale event")
+	}
+}
+
+func TestHealthEventsNotified(t *testing.T) {
+	posted := make(chan Event, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e := Event{}
+		json.NewDecoder(req.Body).Decode(&e)
+		posted <- e
+	}))
+	defer srv.Close()
+
+	r := NewResolver("my-service", "8080", false, nil, nil, WithErrorBudget(ErrorBudget{MaxStaleness: time.Minute}), WithWebhook(srv.URL, 0))
+	defer r.Close()
+	events := r.WatchEvents()
+
+	now := time.Now()
+	r.simulation = &simulation{now: now}
+	r.recordBudget(Event{Type: EventResolution, Error: "no records found"})
+	r.simulation.now = now.Add(2 * time.Minute)
+	r.emit(Event{Type: EventResolution, Error: "no records found"})
+	r.emit(Event{Type: EventWarning, Error: "too many resolvers"})
+
+	// the resolution events are not notified
+	e := <-events
+	assert.Equal(t, EventHealth, e.Type)
+	assert.Equal(t, BudgetStale, e.Health)
+	assert.Equal(t, EventWarning, (<-events).Type)
+	assert.Equal(t, 0, len(events))
+
+	e = <-posted
+	assert.Equal(t, EventHealth, e.Type)
+	assert.Equal(t, "my-service", e.Target)
+	assert.Equal(t, EventWarning, (<-posted).Type)
+}
@@ -1,6 +1,8 @@
 package resolver
 
 import (
+	"log"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc/resolver"
@@ -8,7 +10,7 @@ import (
 
 // DomainResolverBuilder implements the Resolver.Builder interface
 // the target field in the Dial gRPC function is in the way
-// fmt.Sprintf("%s:///%s", scheme, address+":"+port)
+// FormatTarget(scheme, "", address+":"+port)
 // where the scheme is the name set in this constructor
 type DomainResolverBuilder struct {
 	address     string
@@ -16,19 +18,45 @@ type DomainResolverBuilder struct {
 	scheme      string
 	needWatcher bool
 	refreshRate *time.Duration
+	opts        []Option
+
+	m           sync.Mutex
+	pending     map[string][]*DomainResolver // resolvers built with WithLazyStart and not started yet, by target
+	intercepted bool                         // the interceptors of the builder were requested, see WithLazyStart
 }
 
 // NewDomainResolverBuilder creates a new instance for the DomainResolverBuilder
-func NewDomainResolverBuilder(scheme, address, port string, needWatcher bool, refreshRate *time.Duration) *DomainResolverBuilder {
-	return &DomainResolverBuilder{address, port, scheme, needWatcher, refreshRate}
+func NewDomainResolverBuilder(scheme, address, port string, needWatcher bool, refreshRate *time.Duration, opts ...Option) *DomainResolverBuilder {
+	return &DomainResolverBuilder{address: address, port: port, scheme: scheme, needWatcher: needWatcher, refreshRate: refreshRate, opts: opts}
 }
 
-// Build ...
+// Build creates and starts the resolver, if the target has an authority
+// (e.g. scheme://10.1.2.3:8600/service:443) it is used as DNS server
+// following the grpc-go dns scheme convention, a ?type=srv param in the
+// endpoint resolves the SRV records instead, the default options set by
+// SetDefaultOptions are applied first, with WithLazyStart the resolver is
+// started by the first ResolveNow or the first call going through the
+// interceptors of the builder or the resolver, a warning is logged if the
+// interceptors of the builder were never requested since gRPC doesn't
+// call ResolveNow before the first state
 func (b *DomainResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
-	r := NewResolver(b.address, b.port, b.needWatcher, b.refreshRate, nil)
+	t, err := FromResolverTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	ropts := append(append(DefaultOptions(), b.opts...), t.Options()...)
+
+	r := NewGRPCResolver(cc, b.address, b.port, b.needWatcher, b.refreshRate, ropts...)
 	r.target = target
-	r.cc = cc
-	r.updateState = true
+	if r.lazyStart {
+		key := FormatTarget(target.Scheme, target.Authority, target.Endpoint)
+		if !b.addPending(key, r) {
+			log.Println("[grpc-resolver]: ", key, " starts lazily but the interceptors of the builder are not in use, the calls of the ClientConn wait until the interceptors of the resolver start it")
+		}
+		r.unpend = func() { b.removePending(key, r) }
+		return r, nil
+	}
+
 	r.StartResolver()
 	return r, nil
 }
@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/resolver"
 	"google.golang.org/grpc/serviceconfig"
@@ -28,3 +30,54 @@ func TestNewDomainResolverBuilder(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "test-schema", r.Scheme())
 }
+
+func TestBuildUpdatesClientConn(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	rb := NewDomainResolverBuilder("test-schema", "localhost", "8080", false, nil)
+	_, err := rb.Build(resolver.Target{Scheme: "test-schema", Endpoint: "localhost:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+
+	st, ok := cc.LastState()
+	assert.True(t, ok)
+	assert.True(t, len(st.Addresses) > 0)
+}
+
+func TestBuildWithAuthority(t *testing.T) {
+	rb := NewDomainResolverBuilder("test-schema", "127.0.0.1", "8080", false, nil)
+	rr, err := rb.Build(resolver.Target{Scheme: "test-schema", Authority: "10.1.2.3:8600", Endpoint: "127.0.0.1:8080"}, &TestResolver{}, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "10.1.2.3:8600", rr.(*DomainResolver).dnsServer)
+	assert.Equal(t, 0, len(rb.opts))
+}
+
+func TestBuildDryRun(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	rb := NewDomainResolverBuilder("test-schema", "localhost", "8080", false, nil, WithDryRun())
+	rr, err := rb.Build(resolver.Target{Scheme: "test-schema", Endpoint: "localhost:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	assert.True(t, len(rr.(*DomainResolver).Addresses) > 0)
+	assert.Equal(t, 0, len(cc.States()))
+}
+
+func TestBuildHostnameFallback(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	rb := NewDomainResolverBuilder("test-schema", "localhost", "8080", false, nil, WithHostnameFallback())
+	rr, err := rb.Build(resolver.Target{Scheme: "test-schema", Endpoint: "localhost:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+
+	// resolved, the hostname isn't published next to the IPs
+	st, _ := cc.LastState()
+	assert.Equal(t, len(rr.(*DomainResolver).Addresses), len(st.Addresses))
+	assert.NotContains(t, list.FromAddrToString(st.Addresses), "localhost:8080")
+
+	// nothing resolved, the hostname is published alone
+	cc = resolvertest.NewClientConn()
+	rb = NewDomainResolverBuilder("test-schema", "unresolved.test", "8080", false, nil, WithHostnameFallback(),
+		WithHosts(map[string][]string{"unresolved.test": nil}, true))
+	rr, err = rb.Build(resolver.Target{Scheme: "test-schema", Endpoint: "unresolved.test:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+
+	st, _ = cc.LastState()
+	assert.Equal(t, []string{"unresolved.test:8080"}, list.FromAddrToString(st.Addresses))
+	assert.Empty(t, rr.(*DomainResolver).Addresses)
+}
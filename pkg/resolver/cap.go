@@ -0,0 +1,43 @@
+package resolver
+
+import (
+	"sort"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// capAddresses limits the number of addresses to the configured maximum,
+// taking the first ones after sorting or a random sample, the random
+// sample keeps the current addresses still resolved to avoid churn
+func (r *DomainResolver) capAddresses(addrs []resolver.Address) []resolver.Address {
+	if r.maxAddresses <= 0 || len(addrs) <= r.maxAddresses {
+		return addrs
+	}
+
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Addr < addrs[j].Addr })
+	if !r.randomSample {
+		return addrs[:r.maxAddresses]
+	}
+
+	r.m.Lock()
+	current := make(map[string]bool, len(r.Addresses))
+	for _, a := range r.Addresses {
+		current[a] = true
+	}
+	r.m.Unlock()
+
+	picked := []resolver.Address{}
+	rest := []resolver.Address{}
+	for _, a := range addrs {
+		if current[a.Addr] && len(picked) < r.maxAddresses {
+			picked = append(picked, a)
+		} else {
+			rest = append(rest, a)
+		}
+	}
+
+	r.random.shuffle(len(rest), func(i, j int) { rest[i], rest[j] = rest[j], rest[i] })
+	picked = append(picked, rest[:r.maxAddresses-len(picked)]...)
+	sort.Slice(picked, func(i, j int) bool { return picked[i].Addr < picked[j].Addr })
+	return picked
+}
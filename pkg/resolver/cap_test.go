@@ -0,0 +1,51 @@
+package resolver
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func testAddresses() []resolver.Address {
+	return []resolver.Address{{Addr: "10.0.0.4:80"}, {Addr: "10.0.0.2:80"}, {Addr: "10.0.0.3:80"}, {Addr: "10.0.0.1:80"}}
+}
+
+func TestCapAddresses(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, 4, len(r.capAddresses(testAddresses())))
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithMaxAddresses(2, false))
+	assert.Equal(t, []string{"10.0.0.1:80", "10.0.0.2:80"}, list.FromAddrToString(r.capAddresses(testAddresses())))
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithMaxAddresses(10, false))
+	assert.Equal(t, 4, len(r.capAddresses(testAddresses())))
+}
+
+func TestCapAddressesRandom(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithMaxAddresses(2, true))
+	r.Addresses = []string{"10.0.0.3:80"}
+	addrs := list.FromAddrToString(r.capAddresses(testAddresses()))
+	assert.Equal(t, 2, len(addrs))
+	assert.Contains(t, addrs, "10.0.0.3:80")
+
+	// the sample is stable across refreshes
+	r.Addresses = addrs
+	assert.Equal(t, addrs, list.FromAddrToString(r.capAddresses(testAddresses())))
+}
+
+func TestCapAddressesRandSource(t *testing.T) {
+	sample := func() []string {
+		r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithMaxAddresses(2, true), WithRandSource(rand.NewSource(42)))
+		return list.FromAddrToString(r.capAddresses(testAddresses()))
+	}
+
+	// the same seed always picks the same sample
+	first := sample()
+	assert.Equal(t, 2, len(first))
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, first, sample())
+	}
+}
@@ -0,0 +1,7 @@
+package resolver
+
+// Comparator returns true if there is any difference between the current
+// and the newly resolved address lists that is worth a state update,
+// list.CompareListStr is used by default, list.NewHashComparator().Compare
+// avoids sorting on every refresh for large address lists
+type Comparator func(current, resolved []string) (hasDiff bool)
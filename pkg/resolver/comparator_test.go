@@ -0,0 +1,23 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComparator(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithComparator(list.CompareHostsStr))
+	r.StartResolver()
+
+	// a port change is not a change for the hosts comparator
+	r.port = "9090"
+	_, isUpdated := r.getState(context.Background())
+	assert.False(t, isUpdated)
+
+	r.compare = func(current, resolved []string) bool { return true }
+	_, isUpdated = r.getState(context.Background())
+	assert.True(t, isUpdated)
+}
@@ -0,0 +1,25 @@
+package resolver
+
+import "context"
+
+type resolverKey struct{}
+
+// Info identifies the resolver a hook or a backend call is made for
+type Info struct {
+	Target string
+	Labels map[string]string // see WithLabels
+}
+
+// InfoFromContext returns the resolver the context belongs to, the
+// contexts given to the lookups, the DNS dialer and the warm-up hooks
+// carry it along with the values of the context set by WithContext,
+// e.g. a tenant ID, so the custom dialers and hooks can isolate and
+// audit the calls of every resolver
+func InfoFromContext(ctx context.Context) (Info, bool) {
+	r, ok := ctx.Value(resolverKey{}).(*DomainResolver)
+	if !ok {
+		return Info{}, false
+	}
+
+	return Info{Target: r.address, Labels: r.copyLabels()}, true
+}
@@ -0,0 +1,30 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantKey struct{}
+
+func TestInfoFromContext(t *testing.T) {
+	_, ok := InfoFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctxs := make(chan context.Context, 1)
+	parent := context.WithValue(context.Background(), tenantKey{}, "tenant-a")
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil,
+		WithContext(parent),
+		WithLabels(map[string]string{"team": "payments"}),
+		WithWarmUp(func(ctx context.Context, addr string) { ctxs <- ctx }, 1))
+	r.StartResolver()
+	defer r.Close()
+
+	ctx := <-ctxs
+	assert.Equal(t, "tenant-a", ctx.Value(tenantKey{}))
+	info, ok := InfoFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, Info{Target: "localhost", Labels: map[string]string{"team": "payments"}}, info)
+}
@@ -0,0 +1,32 @@
+package resolver
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// refreshDeadlineRatio is the part of the refresh interval a refresh
+// can take before its lookups are cancelled, so a slow DNS server can't
+// make the refreshes overlap
+const refreshDeadlineRatio = 0.8
+
+// refreshContext returns the context for the lookups of a refresh, with
+// a deadline derived from the refresh interval when there is a watcher
+func (r *DomainResolver) refreshContext() (context.Context, context.CancelFunc) {
+	if r.interval <= 0 {
+		return context.WithCancel(r.ctx)
+	}
+
+	return context.WithTimeout(r.ctx, time.Duration(float64(r.interval)*refreshDeadlineRatio))
+}
+
+// refreshTick refreshes the addresses on every tick of the watcher,
+// logging the refreshes outliving the refresh interval
+func (r *DomainResolver) refreshTick() {
+	start := time.Now()
+	r.Refresh()
+	if elapsed := time.Since(start); r.interval > 0 && elapsed > r.interval {
+		log.Println("[grpc-resolver]: refresh overrun for ", r.address, ", took ", elapsed, " with a refresh rate of ", r.interval, r.labelsString())
+	}
+}
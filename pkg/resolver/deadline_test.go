@@ -0,0 +1,36 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshContext(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	ctx, cancel := r.refreshContext()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	cancel()
+	assert.NotNil(t, ctx.Err())
+
+	rate := time.Duration(10)
+	r = NewResolver("localhost", "8080", true, &rate, nil)
+	defer r.Close()
+	assert.Equal(t, 10*time.Second, r.interval)
+
+	ctx, cancel = r.refreshContext()
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(8*time.Second), deadline, time.Second)
+}
+
+func TestRefreshTick(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	r.interval = time.Nanosecond
+	// the lookups are cancelled by the deadline, the overrun is logged
+	r.refreshTick()
+	assert.Empty(t, r.Addresses)
+}
@@ -0,0 +1,26 @@
+package resolver
+
+import "sync"
+
+var defaults struct {
+	sync.RWMutex
+	opts []Option
+}
+
+// SetDefaultOptions sets the options every resolver built by a
+// DomainResolverBuilder starts with, e.g. fleet-wide timeouts, expvar or
+// event sinks, the options of the builder and the target are applied
+// after them so they take precedence, only the resolvers built afterwards
+// are affected, calling it again replaces the defaults
+func SetDefaultOptions(opts ...Option) {
+	defaults.Lock()
+	defaults.opts = append([]Option{}, opts...)
+	defaults.Unlock()
+}
+
+// DefaultOptions returns the options set by SetDefaultOptions
+func DefaultOptions() []Option {
+	defaults.RLock()
+	defer defaults.RUnlock()
+	return append([]Option{}, defaults.opts...)
+}
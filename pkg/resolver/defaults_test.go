@@ -0,0 +1,27 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestSetDefaultOptions(t *testing.T) {
+	SetDefaultOptions(WithDryRun(), WithServerName("default"))
+	defer SetDefaultOptions()
+	assert.Equal(t, 2, len(DefaultOptions()))
+
+	// the builder options take precedence over the defaults
+	rb := NewDomainResolverBuilder("test-schema", "127.0.0.1", "8080", false, nil, WithServerName("builder"))
+	rr, err := rb.Build(resolver.Target{Scheme: "test-schema", Endpoint: "127.0.0.1:8080"}, &TestResolver{}, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	assert.True(t, rr.(*DomainResolver).dryRun)
+	assert.Equal(t, "builder", rr.(*DomainResolver).serverName)
+
+	// the resolvers created directly don't inherit them
+	assert.False(t, NewResolver("127.0.0.1", "8080", false, nil, nil).dryRun)
+
+	SetDefaultOptions()
+	assert.Equal(t, 0, len(DefaultOptions()))
+}
@@ -0,0 +1,45 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// DialScheme is the scheme of the resolver built by Dial
+	DialScheme = "dmresolver"
+	// DialRefreshRate is the refresh rate in seconds of the resolver built by Dial
+	DialRefreshRate = time.Duration(30)
+
+	dialServiceConfig = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+)
+
+// Dial creates a ClientConn to the target (host:port) with a watched
+// resolver refreshed every 30 seconds, IPv4 and IPv6 addresses and the
+// round_robin balancer, it blocks until the connection is ready or the
+// context is done, the given options are applied after the defaults so
+// they can override them, the transport credentials have to be given,
+// the target is rejected if it has no port
+func Dial(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	t, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	if t.Port == "" {
+		return nil, fmt.Errorf("invalid target %s, missing port", target)
+	}
+
+	refreshRate := DialRefreshRate
+	builder := NewDomainResolverBuilder(DialScheme, t.Host, t.Port, true, &refreshRate)
+	dopts := append([]grpc.DialOption{
+		grpc.WithResolvers(builder),
+		grpc.WithDefaultServiceConfig(dialServiceConfig),
+		grpc.WithBlock(),
+	}, opts...)
+
+	t.Scheme = DialScheme
+	return grpc.DialContext(ctx, t.URI(), dopts...)
+}
@@ -0,0 +1,41 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestDial(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	gs := grpc.NewServer()
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	_, port, _ := net.SplitHostPort(lis.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := Dial(ctx, "localhost:"+port, grpc.WithInsecure())
+	assert.Nil(t, err)
+	assert.Equal(t, connectivity.Ready, conn.GetState())
+	assert.Equal(t, FormatTarget(DialScheme, "", "localhost:"+port), conn.Target())
+	conn.Close()
+
+	_, err = Dial(ctx, ":"+port, grpc.WithInsecure())
+	assert.NotNil(t, err)
+
+	// no port
+	_, err = Dial(ctx, "localhost", grpc.WithInsecure())
+	assert.EqualError(t, err, "invalid target localhost, missing port")
+
+	// no transport credentials
+	_, err = Dial(ctx, "localhost:"+port)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,210 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DialFunc dials the DNS servers, e.g. a net.Dialer bound to an interface,
+// a VRF or setting SO_MARK through its Control function
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// dial dials with the dialer of the resolver, a zero net.Dialer if none
+func (r *DomainResolver) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	return dialWith(r.dialer)(ctx, network, address)
+}
+
+// dialWith returns the dialer, a zero net.Dialer if nil
+func dialWith(dial DialFunc) DialFunc {
+	if dial != nil {
+		return dial
+	}
+
+	var d net.Dialer
+	return d.DialContext
+}
+
+// newNetResolver returns the resolver used for the lookups according
+// to the DNS options, net.DefaultResolver if none is set, the pure Go
+// resolver is used otherwise since the cgo one can't be customized
+func (r *DomainResolver) newNetResolver() *net.Resolver {
+	return r.netResolverFor(r.dnsServer)
+}
+
+// netResolverFor returns a resolver sending the queries to the given
+// server honoring the rest of the DNS options
+func (r *DomainResolver) netResolverFor(server string) *net.Resolver {
+	return dnsResolver(r.dnsConfig(server))
+}
+
+// dnsConfig is what the resolvers built by dnsResolver need
+type dnsConfig struct {
+	server        string        // server the queries are sent to, the system ones if empty
+	host          func() string // name looked up, used to discover the zone name servers
+	forceTCP      bool          // the queries are sent over TCP
+	authoritative bool          // the queries are sent to the zone name servers
+	preferGo      bool          // the pure Go resolver is used
+	ecs           *clientSubnet // client subnet added to the queries, optional
+	log           *queryLog     // logs a summary of the exchanges, optional
+	dial          DialFunc      // dials the servers, a zero net.Dialer if nil
+	bufferSize    uint16        // EDNS0 UDP buffer size advertised, the Go default if 0
+	noRecursion   bool          // the RD bit of the queries is cleared
+	conns         *dnsConnPool  // keeps the conns open between the queries, optional
+}
+
+// dnsConfig returns the DNS options of the resolver sending the queries
+// to the given server
+func (r *DomainResolver) dnsConfig(server string) dnsConfig {
+	return dnsConfig{
+		server:        server,
+		host:          r.lookupName,
+		forceTCP:      r.forceTCP,
+		authoritative: r.authoritative,
+		preferGo:      r.preferGo,
+		ecs:           r.clientSubnet,
+		log:           r.queryLog,
+		dial:          r.dialer,
+		bufferSize:    r.ednsBufferSize,
+		noRecursion:   r.noRecursion,
+		conns:         r.dnsConns,
+	}
+}
+
+// dnsResolver returns a resolver sending the queries of the host to the
+// server, or its zone name servers if authoritative, over TCP if forced,
+// the queries are rewritten with the client subnet, the buffer size and
+// without the RD bit if set, the answers are recorded in the context of the lookups if it
+// has a recorder and logged if the query log is set, the conns are reused
+// if there's a pool
+func dnsResolver(c dnsConfig) *net.Resolver {
+	if c.server == "" && !c.forceTCP && !c.authoritative && c.ecs == nil && c.log == nil && c.dial == nil && c.bufferSize == 0 && !c.noRecursion && c.conns == nil {
+		if c.preferGo {
+			return &net.Resolver{PreferGo: true}
+		}
+		return net.DefaultResolver
+	}
+
+	prefetchSystemServers()
+
+	// rotated on every dial so the retries of the Go resolver go to the
+	// next zone name server when one doesn't answer
+	var rotation uint32
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var servers []string
+			switch {
+			case c.server != "":
+				servers = []string{c.server}
+			case c.authoritative:
+				ns, err := authoritativeServers(ctx, c.host(), c.dial)
+				if err != nil {
+					return nil, err
+				}
+				servers = ns
+			default:
+				servers = []string{systemServer(address, c.host())}
+			}
+
+			// the Go resolver frames the messages for TCP when the conn is not a PacketConn
+			if c.forceTCP {
+				network = "tcp"
+			}
+
+			start := int(atomic.AddUint32(&rotation, 1))
+			var err error
+			for i := range servers {
+				address := servers[(start+i)%len(servers)]
+				var conn net.Conn
+				if c.conns != nil {
+					conn, err = c.conns.dial(ctx, dialWith(c.dial), network, address)
+				} else {
+					conn, err = dialWith(c.dial)(ctx, network, address)
+				}
+				if err == nil {
+					return wrapDNSConn(conn, dnsHooks{
+						ecs: c.ecs, answers: dnsAnswersFrom(ctx), log: c.log, server: address,
+						bufferSize: c.bufferSize, noRecursion: c.noRecursion,
+					}), nil
+				}
+			}
+
+			return nil, err
+		},
+	}
+}
+
+// nsCacheTTL is how long the name servers of a zone are cached, the Go
+// resolver doesn't expose the TTL of the NS records
+const nsCacheTTL = 5 * time.Minute
+
+// zoneServers caches the name servers of the zones for every resolver
+var zoneServers = &nsCache{zones: map[string]nsZone{}}
+
+// nsCache holds the name servers discovered per zone, the names without
+// NS records are cached too so the walk doesn't query them every time
+type nsCache struct {
+	sync.Mutex
+	zones map[string]nsZone
+}
+
+type nsZone struct {
+	servers []string // host:53 of the name servers, empty if the name is not a zone
+	expires time.Time
+}
+
+// authoritativeServers returns the name servers of the zone holding the
+// host, the system resolver is used for the discovery, through dial if set
+func authoritativeServers(ctx context.Context, host string, dial DialFunc) ([]string, error) {
+	res := net.DefaultResolver
+	if dial != nil {
+		res = &net.Resolver{PreferGo: true, Dial: dial}
+	}
+
+	return zoneServers.servers(ctx, host, res.LookupNS, time.Now())
+}
+
+// servers walks up the labels of the host until a zone with name
+// servers is found, looking up the names not cached or expired
+func (c *nsCache) servers(ctx context.Context, host string, lookupNS func(context.Context, string) ([]*net.NS, error), now time.Time) ([]string, error) {
+	zone := strings.TrimSuffix(host, ".")
+	for zone != "" {
+		c.Lock()
+		cached, ok := c.zones[zone]
+		c.Unlock()
+
+		if !ok || now.After(cached.expires) {
+			cached, ok = nsZone{expires: now.Add(nsCacheTTL)}, false
+			ns, err := lookupNS(ctx, zone)
+			for _, n := range ns {
+				cached.servers = append(cached.servers, net.JoinHostPort(strings.TrimSuffix(n.Host, "."), "53"))
+			}
+
+			// the temporary failures are not cached
+			var dnsErr *net.DNSError
+			if err == nil || (errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+				c.Lock()
+				c.zones[zone] = cached
+				c.Unlock()
+			}
+		}
+
+		if len(cached.servers) > 0 {
+			return cached.servers, nil
+		}
+
+		i := strings.Index(zone, ".")
+		if i < 0 {
+			break
+		}
+		zone = zone[i+1:]
+	}
+
+	return nil, fmt.Errorf("no name servers found for %s", host)
+}
@@ -0,0 +1,173 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestNewNetResolver(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, net.DefaultResolver, r.newNetResolver())
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithForceTCP())
+	assert.True(t, r.forceTCP)
+	assert.True(t, r.netResolver.PreferGo)
+}
+
+func TestForceTCPDial(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithDNSServer(lis.Addr().String()), WithForceTCP())
+	conn, err := r.netResolver.Dial(context.Background(), "udp", "10.255.255.1:53")
+	assert.Nil(t, err)
+	assert.Equal(t, "tcp", conn.RemoteAddr().Network())
+	assert.Equal(t, lis.Addr().String(), conn.RemoteAddr().String())
+	conn.Close()
+}
+
+func TestAuthoritative(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithAuthoritative())
+	assert.True(t, r.authoritative)
+	assert.NotEqual(t, net.DefaultResolver, r.netResolver)
+
+	_, err := authoritativeServers(context.Background(), "no-zone.invalid", nil)
+	assert.NotNil(t, err)
+
+	r = NewResolver("no-zone.invalid", "8080", false, &refreshRate, nil, WithAuthoritative())
+	_, err = r.netResolver.Dial(context.Background(), "udp", "10.255.255.1:53")
+	assert.NotNil(t, err)
+}
+
+func TestGoResolver(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithGoResolver())
+	assert.True(t, r.preferGo)
+	assert.NotEqual(t, net.DefaultResolver, r.netResolver)
+	assert.True(t, r.netResolver.PreferGo)
+	assert.Nil(t, r.netResolver.Dial)
+}
+
+func TestDialer(t *testing.T) {
+	dialed := []string{}
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, network+" "+address)
+		return nil, errors.New("unreachable")
+	}
+
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithDialer(dial))
+	assert.NotEqual(t, net.DefaultResolver, r.netResolver)
+	assert.True(t, r.netResolver.PreferGo)
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithDialer(dial), WithDNSServer("10.255.255.1:53"))
+	_, err := r.netResolver.Dial(context.Background(), "udp", "127.0.0.53:53")
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"udp 10.255.255.1:53"}, dialed)
+
+	// the names are looked up again once not cached
+	zoneServers.Lock()
+	zoneServers.zones = map[string]nsZone{}
+	zoneServers.Unlock()
+	_, err = authoritativeServers(context.Background(), "no-zone.invalid", dial)
+	assert.NotNil(t, err)
+	assert.True(t, len(dialed) > 1)
+}
+
+func TestNSCache(t *testing.T) {
+	lookups := []string{}
+	temporary := false
+	lookupNS := func(ctx context.Context, zone string) ([]*net.NS, error) {
+		lookups = append(lookups, zone)
+		switch {
+		case temporary:
+			return nil, &net.DNSError{Err: "timeout", Name: zone, IsTemporary: true}
+		case zone == "example.test":
+			return []*net.NS{{Host: "ns1.example.test."}, {Host: "ns2.example.test."}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: zone, IsNotFound: true}
+	}
+
+	c := &nsCache{zones: map[string]nsZone{}}
+	now := time.Now()
+	servers, err := c.servers(context.Background(), "api.example.test.", lookupNS, now)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ns1.example.test:53", "ns2.example.test:53"}, servers)
+	assert.Equal(t, []string{"api.example.test", "example.test"}, lookups)
+
+	// the zone and the name without NS records are cached
+	servers, err = c.servers(context.Background(), "api.example.test.", lookupNS, now.Add(time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(servers))
+	assert.Equal(t, 2, len(lookups))
+
+	// the temporary failures are not cached
+	temporary = true
+	_, err = c.servers(context.Background(), "api.example.test.", lookupNS, now.Add(nsCacheTTL+time.Second))
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"api.example.test", "example.test", "test"}, lookups[2:])
+	temporary = false
+	servers, err = c.servers(context.Background(), "api.example.test.", lookupNS, now.Add(nsCacheTTL+2*time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(servers))
+	assert.Equal(t, []string{"api.example.test", "example.test"}, lookups[5:])
+}
+
+func TestAuthoritativeFailover(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	zoneServers.Lock()
+	zoneServers.zones["failover.test"] = nsZone{servers: []string{"ns1.failover.test:53", lis.Addr().String()}, expires: time.Now().Add(time.Minute)}
+	zoneServers.Unlock()
+
+	dialed := []string{}
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		if address != lis.Addr().String() {
+			return nil, errors.New("unreachable")
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, address)
+	}
+
+	r := NewResolver("failover.test", "8080", false, &refreshRate, nil, WithAuthoritative(), WithForceTCP(), WithDialer(dial))
+	for i := 0; i < 2; i++ {
+		conn, err := r.netResolver.Dial(context.Background(), "udp", "127.0.0.53:53")
+		assert.Nil(t, err)
+		assert.Equal(t, lis.Addr().String(), conn.RemoteAddr().String())
+		conn.Close()
+	}
+
+	// the first server alternates, the unreachable one is skipped
+	assert.Equal(t, []string{lis.Addr().String(), "ns1.failover.test:53", lis.Addr().String()}, dialed)
+}
+
+func TestRecursionDesired(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.False(t, r.noRecursion)
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithRecursionDesired(false))
+	assert.True(t, r.noRecursion)
+	assert.NotEqual(t, net.DefaultResolver, r.netResolver)
+
+	query := dnsmessage.Message{Header: dnsmessage.Header{ID: 3, RecursionDesired: true}, Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName("rd.test."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}}}
+	packed, err := query.Pack()
+	assert.Nil(t, err)
+
+	rewritten := dnsHooks{noRecursion: true}.query(packed)
+	received := dnsmessage.Message{}
+	assert.Nil(t, received.Unpack(rewritten))
+	assert.False(t, received.Header.RecursionDesired)
+	assert.Equal(t, query.Questions, received.Questions)
+
+	// the query of the Go resolver is left untouched
+	assert.Nil(t, received.Unpack(packed))
+	assert.True(t, received.Header.RecursionDesired)
+}
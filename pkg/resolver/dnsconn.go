@@ -0,0 +1,167 @@
+package resolver
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+)
+
+// dnsHooks rewrite the queries and inspect the answers going through
+// the conns of the Go resolver
+type dnsHooks struct {
+	ecs     *clientSubnet // adds the client subnet to the queries, optional
+	answers *dnsAnswers   // records the metadata of the answers, optional
+	log     *queryLog     // logs a summary of the exchanges, optional
+	server  string        // address of the DNS server of the conn
+
+	bufferSize  uint16 // EDNS0 UDP buffer size set in the queries, unchanged if 0
+	noRecursion bool   // the RD bit of the queries is cleared
+}
+
+func (h dnsHooks) query(b []byte) []byte {
+	if h.log != nil {
+		h.log.query(b, h.server)
+	}
+	if h.ecs != nil {
+		b = h.ecs.addOption(b)
+	}
+	if h.bufferSize > 0 {
+		b = setBufferSize(b, h.bufferSize)
+	}
+	// RD is the lowest bit of the third byte of the header
+	if h.noRecursion && len(b) > 2 {
+		b = append([]byte{}, b...)
+		b[2] &^= 1
+	}
+
+	return b
+}
+
+func (h dnsHooks) answer(b []byte) {
+	if h.ecs != nil {
+		h.ecs.recordScope(b)
+	}
+	if h.answers != nil {
+		h.answers.record(b, h.server)
+	}
+	if h.log != nil {
+		h.log.answer(b, h.server)
+	}
+}
+
+// wrapDNSConn returns the conn applying the hooks, the Go resolver frames
+// the messages depending on the conn being a PacketConn so the UDP conns
+// keep implementing it
+func wrapDNSConn(conn net.Conn, hooks dnsHooks) net.Conn {
+	if hooks.ecs == nil && hooks.answers == nil && hooks.log == nil && hooks.bufferSize == 0 && !hooks.noRecursion {
+		return conn
+	}
+
+	if pc, ok := conn.(net.PacketConn); ok {
+		return &dnsPacketConn{Conn: conn, pc: pc, hooks: hooks}
+	}
+
+	return &dnsStreamConn{Conn: conn, hooks: hooks}
+}
+
+// dnsPacketConn handles a message per Write and Read
+type dnsPacketConn struct {
+	net.Conn
+	pc    net.PacketConn
+	hooks dnsHooks
+}
+
+func (c *dnsPacketConn) Write(b []byte) (int, error) {
+	if _, err := c.Conn.Write(c.hooks.query(b)); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+func (c *dnsPacketConn) Read(b []byte) (int, error) {
+	if int(c.hooks.bufferSize) <= len(b) {
+		n, err := c.Conn.Read(b)
+		if err == nil {
+			c.hooks.answer(b[:n])
+		}
+
+		return n, err
+	}
+
+	// the answer can be larger than the buffer of the Go resolver
+	pooled := dnsBuffers.Get().(*[]byte)
+	defer dnsBuffers.Put(pooled)
+	buf := (*pooled)[:c.hooks.bufferSize]
+	n, err := c.Conn.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+
+	answer := buf[:n]
+	if n > len(b) {
+		truncated, ok := truncatedAnswer(answer)
+		if !ok {
+			return copy(b, answer), nil
+		}
+		answer = truncated
+	}
+
+	c.hooks.answer(answer)
+	return copy(b, answer), nil
+}
+
+func (c *dnsPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	return c.pc.ReadFrom(b)
+}
+
+func (c *dnsPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	return c.pc.WriteTo(b, addr)
+}
+
+// dnsStreamConn handles the messages prefixed by their length, the Go
+// resolver writes each query at once and the answers are read whole
+// before serving them
+type dnsStreamConn struct {
+	net.Conn
+	hooks dnsHooks
+	buf   []byte
+}
+
+func (c *dnsStreamConn) Write(b []byte) (int, error) {
+	if len(b) < 2 {
+		return c.Conn.Write(b)
+	}
+
+	query := c.hooks.query(b[2:])
+	pooled := dnsBuffers.Get().(*[]byte)
+	defer dnsBuffers.Put(pooled)
+	framed := (*pooled)[:2]
+	binary.BigEndian.PutUint16(framed, uint16(len(query)))
+	if _, err := c.Conn.Write(append(framed, query...)); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+func (c *dnsStreamConn) Read(b []byte) (int, error) {
+	if len(c.buf) == 0 {
+		framed := make([]byte, 2)
+		if _, err := io.ReadFull(c.Conn, framed); err != nil {
+			return 0, err
+		}
+
+		answer := make([]byte, binary.BigEndian.Uint16(framed))
+		if _, err := io.ReadFull(c.Conn, answer); err != nil {
+			return 0, err
+		}
+
+		c.hooks.answer(answer)
+		c.buf = append(framed, answer...)
+	}
+
+	n := copy(b, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,135 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// persistentInterval is the refresh interval below which the DNS conns
+// are kept open between the refreshes, see WithRefreshInterval
+const persistentInterval = time.Second
+
+// dnsConnPool keeps a conn per DNS server open between the queries so
+// the sub-second refreshes don't dial a conn for every query, a conn
+// is only handed to one query at a time
+type dnsConnPool struct {
+	sync.Mutex
+	idle   map[string]*pooledConn // by network and address
+	closed bool
+}
+
+func newDNSConnPool() *dnsConnPool {
+	return &dnsConnPool{idle: map[string]*pooledConn{}}
+}
+
+// dial returns the idle conn to the address if any, dialing a new one
+// otherwise
+func (p *dnsConnPool) dial(ctx context.Context, dial DialFunc, network, address string) (net.Conn, error) {
+	key := network + " " + address
+	p.Lock()
+	c := p.idle[key]
+	delete(p.idle, key)
+	p.Unlock()
+	if c != nil {
+		return c.conn(), nil
+	}
+
+	conn, err := dial(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&pooledConn{Conn: conn, pool: p, key: key}).conn(), nil
+}
+
+// put keeps the conn for the next query, it's closed if the pool is
+// closed or already has one for the address
+func (p *dnsConnPool) put(c *pooledConn) error {
+	p.Lock()
+	if p.closed || p.idle[c.key] != nil {
+		p.Unlock()
+		return c.Conn.Close()
+	}
+	p.idle[c.key] = c
+	p.Unlock()
+	return nil
+}
+
+// close closes the idle conns, the conns in use are closed when released
+func (p *dnsConnPool) close() {
+	p.Lock()
+	defer p.Unlock()
+	p.closed = true
+	for key, c := range p.idle {
+		c.Conn.Close()
+		delete(p.idle, key)
+	}
+}
+
+// pooledConn goes back to the pool when the Go resolver closes it, unless
+// a read or a write failed since the conn may hold a partial answer then
+type pooledConn struct {
+	net.Conn
+	pool   *dnsConnPool
+	key    string
+	broken bool
+}
+
+// conn returns the conn ready for a query, a PacketConn for the UDP ones
+// since the Go resolver frames the messages depending on it
+func (c *pooledConn) conn() net.Conn {
+	c.broken = false
+	if pc, ok := c.Conn.(net.PacketConn); ok {
+		return &pooledPacketConn{pooledConn: c, pc: pc}
+	}
+
+	return c
+}
+
+func (c *pooledConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err != nil {
+		c.broken = true
+	}
+
+	return n, err
+}
+
+func (c *pooledConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if err != nil {
+		c.broken = true
+	}
+
+	return n, err
+}
+
+func (c *pooledConn) Close() error {
+	if c.broken || c.Conn.SetDeadline(time.Time{}) != nil {
+		return c.Conn.Close()
+	}
+
+	return c.pool.put(c)
+}
+
+type pooledPacketConn struct {
+	*pooledConn
+	pc net.PacketConn
+}
+
+func (c *pooledPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	return c.pc.ReadFrom(b)
+}
+
+func (c *pooledPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	return c.pc.WriteTo(b, addr)
+}
+
+// dnsBuffers holds the buffers of the DNS messages rewritten or read by
+// the conn hooks, reused between the queries
+var dnsBuffers = sync.Pool{New: func() interface{} {
+	b := make([]byte, 0, 1<<16)
+	return &b
+}}
@@ -0,0 +1,78 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistentDNSConns(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+	go serveBufferSize(t, pc, 1, make(chan int))
+
+	var dials int32
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		var d net.Dialer
+		return d.DialContext(ctx, network, address)
+	}
+
+	// sub-second intervals reuse the conn between the lookups
+	r := NewResolver("pool.test.", "8080", false, nil, nil, WithDNSServer(pc.LocalAddr().String()), WithDialer(dial), WithRefreshInterval(200*time.Millisecond))
+	assert.NotNil(t, r.dnsConns)
+	for i := 0; i < 3; i++ {
+		ips, err := r.netResolver.LookupIP(context.Background(), "ip4", "pool.test.")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(ips))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&dials))
+
+	r.Close()
+	r.dnsConns.Lock()
+	assert.Equal(t, 0, len(r.dnsConns.idle))
+	r.dnsConns.Unlock()
+
+	// longer intervals dial for every query
+	atomic.StoreInt32(&dials, 0)
+	r = NewResolver("pool.test.", "8080", false, nil, nil, WithDNSServer(pc.LocalAddr().String()), WithDialer(dial), WithRefreshInterval(2*time.Second))
+	defer r.Close()
+	assert.Nil(t, r.dnsConns)
+	for i := 0; i < 3; i++ {
+		_, err := r.netResolver.LookupIP(context.Background(), "ip4", "pool.test.")
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&dials))
+}
+
+func TestPooledConnBroken(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+
+	p := newDNSConnPool()
+	conn, err := p.dial(context.Background(), dialWith(nil), "udp", pc.LocalAddr().String())
+	assert.Nil(t, err)
+	_, ok := conn.(net.PacketConn)
+	assert.True(t, ok)
+
+	// a failed read may leave a partial answer, the conn is not reused
+	conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	_, err = conn.Read(make([]byte, 512))
+	assert.NotNil(t, err)
+	assert.Nil(t, conn.Close())
+	assert.Equal(t, 0, len(p.idle))
+
+	conn, err = p.dial(context.Background(), dialWith(nil), "udp", pc.LocalAddr().String())
+	assert.Nil(t, err)
+	assert.Nil(t, conn.Close())
+	assert.Equal(t, 1, len(p.idle))
+
+	p.close()
+	assert.Equal(t, 0, len(p.idle))
+}
@@ -0,0 +1,245 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Severity tells how bad a finding of Doctor is
+type Severity string
+
+const (
+	// SeverityOK means the check passed
+	SeverityOK Severity = "ok"
+	// SeverityWarning means the discovery works but may misbehave
+	SeverityWarning Severity = "warning"
+	// SeverityError means the discovery can't work as configured
+	SeverityError Severity = "error"
+)
+
+const (
+	// lowTTL is the TTL below which the answers are mostly served uncached
+	lowTTL = 5 * time.Second
+	// udpSize is the largest answer guaranteed to fit a UDP message without EDNS
+	udpSize = 512
+	// ipv6Probe is the address used to check if the host has an IPv6 route,
+	// no packet is sent as dialing UDP only selects the route
+	ipv6Probe = "[2001:4860:4860::8888]:53"
+)
+
+// Finding is the outcome of a check run by Doctor
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// DoctorReport lists the findings of Doctor in the order they were checked
+type DoctorReport struct {
+	Target   string    `json:"target"`
+	Server   string    `json:"server"`
+	Findings []Finding `json:"findings"`
+}
+
+// Healthy returns false if any finding is an error
+func (d DoctorReport) Healthy() bool {
+	for _, f := range d.Findings {
+		if f.Severity == SeverityError {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns a finding per line, e.g. for a doctor command
+func (d DoctorReport) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s via %s\n", d.Target, d.Server)
+	for _, f := range d.Findings {
+		fmt.Fprintf(b, "[%s] %s: %s\n", f.Severity, f.Check, f.Message)
+	}
+
+	return b.String()
+}
+
+func (d *DoctorReport) add(check string, severity Severity, format string, args ...interface{}) {
+	d.Findings = append(d.Findings, Finding{Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// dnsExchange is the outcome of a raw query sent by Doctor
+type dnsExchange struct {
+	header  dnsmessage.Header
+	answers []dnsmessage.Resource
+	size    int
+}
+
+// Doctor runs the checks maintainers walk users through when the discovery
+// misbehaves: system resolver configuration, A/AAAA/SRV availability, TTLs,
+// answer sizes, truncation and IPv6 reachability, with the DNS server and
+// the names of the resolver, returning actionable findings
+func (r *DomainResolver) Doctor(ctx context.Context) DoctorReport {
+	report := DoctorReport{Target: r.address, Server: r.dnsServer}
+	if ip := net.ParseIP(r.address); ip != nil || r.passthrough {
+		report.add("target", SeverityOK, "%s is not resolved, nothing to check", r.address)
+		return report
+	}
+
+	if report.Server == "" {
+		servers, err := SystemServers()
+		switch {
+		case err != nil:
+			report.add("system", SeverityError, "reading the system resolver configuration: %v", err)
+			return report
+		case len(servers) == 0:
+			report.add("system", SeverityError, "no name servers configured, set one with WithDNSServer")
+			return report
+		}
+		report.Server = servers[0]
+		report.add("system", SeverityOK, "name servers %s", strings.Join(servers, ", "))
+	}
+
+	var name string
+	results := map[dnsmessage.Type]dnsExchange{}
+	for _, n := range r.queryNames() {
+		name = strings.TrimSuffix(n, ".") + "."
+		for _, t := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+			ex, err := exchange(ctx, r.dial, report.Server, name, t)
+			if err != nil {
+				report.add("server", SeverityError, "querying %s: %v", report.Server, err)
+				return report
+			}
+			results[t] = ex
+		}
+
+		if results[dnsmessage.TypeA].header.RCode != dnsmessage.RCodeNameError {
+			break
+		}
+	}
+
+	found := false
+	for _, t := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		found = r.checkAnswer(&report, name, t, results[t]) || found
+	}
+	if !found && !r.srv {
+		report.add("records", SeverityError, "no A nor AAAA records for %s, the resolver publishes no address", name)
+	}
+
+	if r.srv {
+		_, records, err := r.netResolverFor(report.Server).LookupSRV(ctx, r.srvService, r.srvProto, r.lookupName())
+		if err != nil || len(records) == 0 {
+			report.add("srv", SeverityError, "no SRV records for _%s._%s.%s: %v", r.srvService, r.srvProto, r.lookupName(), err)
+		} else {
+			report.add("srv", SeverityOK, "%d SRV records", len(records))
+		}
+	}
+
+	if len(results[dnsmessage.TypeAAAA].answers) > 0 {
+		conn, err := r.dial(ctx, "udp", ipv6Probe)
+		if err != nil {
+			report.add("ipv6", SeverityWarning, "AAAA records published but the host has no IPv6 route (%v), the IPv6 addresses will fail to connect", err)
+		} else {
+			conn.Close()
+			report.add("ipv6", SeverityOK, "the host has an IPv6 route")
+		}
+	}
+
+	return report
+}
+
+// checkAnswer adds the findings of the answer of the given type,
+// returning whether it has records
+func (r *DomainResolver) checkAnswer(report *DoctorReport, name string, t dnsmessage.Type, ex dnsExchange) bool {
+	check := strings.TrimPrefix(t.String(), "Type")
+	if ex.header.RCode != dnsmessage.RCodeSuccess {
+		severity := SeverityWarning
+		if ex.header.RCode == dnsmessage.RCodeNameError {
+			severity = SeverityError
+		}
+		report.add(check, severity, "%s answered %s for %s", report.Server, ex.header.RCode, name)
+		return false
+	}
+
+	if ex.header.Truncated {
+		report.add(check, SeverityWarning, "answer of %d bytes truncated over UDP, some addresses may be missing, use WithForceTCP", ex.size)
+	} else if ex.size > udpSize {
+		report.add(check, SeverityWarning, "answer of %d bytes exceeds %d bytes and may be truncated by servers without EDNS", ex.size, udpSize)
+	}
+
+	count, ttl := 0, uint32(0)
+	for _, a := range ex.answers {
+		if a.Header.Type != t {
+			continue
+		}
+		if count == 0 || a.Header.TTL < ttl {
+			ttl = a.Header.TTL
+		}
+		count++
+	}
+
+	if count == 0 {
+		report.add(check, SeverityOK, "no records")
+		return false
+	}
+
+	report.add(check, SeverityOK, "%d records, TTL %ds", count, ttl)
+	if d := time.Duration(ttl) * time.Second; d < lowTTL {
+		report.add(check, SeverityWarning, "TTL %v below %v, the answers are barely cached, avoid refreshing faster than the TTL", d, lowTTL)
+	}
+
+	return true
+}
+
+// exchange sends a single query over UDP, without EDNS so the
+// truncation seen is the one of the clients without it
+func exchange(ctx context.Context, dial DialFunc, server, name string, t dnsmessage.Type) (dnsExchange, error) {
+	n, err := dnsmessage.NewName(name)
+	if err != nil {
+		return dnsExchange{}, err
+	}
+
+	id := uint16(time.Now().UnixNano())
+	query, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: n, Type: t, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return dnsExchange{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	conn, err := dial(ctx, "udp", server)
+	if err != nil {
+		return dnsExchange{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return dnsExchange{}, err
+	}
+
+	buf := make([]byte, 65535)
+	for {
+		size, err := conn.Read(buf)
+		if err != nil {
+			return dnsExchange{}, err
+		}
+
+		var msg dnsmessage.Message
+		if err := msg.Unpack(buf[:size]); err != nil || msg.ID != id {
+			continue
+		}
+
+		return dnsExchange{header: msg.Header, answers: msg.Answers, size: size}, nil
+	}
+}
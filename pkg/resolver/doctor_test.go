@@ -0,0 +1,126 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDoctor answers A queries with two records with a low TTL
+// and AAAA queries with an empty truncated answer
+func serveDoctor(pc net.PacketConn) {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		query := dnsmessage.Message{}
+		if err := query.Unpack(buf[:n]); err != nil {
+			continue
+		}
+
+		answer := dnsmessage.Message{Header: dnsmessage.Header{ID: query.ID, Response: true}, Questions: query.Questions}
+		q := query.Questions[0]
+		if q.Type == dnsmessage.TypeA {
+			for _, ip := range [][4]byte{{10, 0, 0, 1}, {10, 0, 0, 2}} {
+				answer.Answers = append(answer.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 2},
+					Body:   &dnsmessage.AResource{A: ip},
+				})
+			}
+		} else {
+			answer.Truncated = true
+		}
+
+		packed, _ := answer.Pack()
+		pc.WriteTo(packed, addr)
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+	go serveDoctor(pc)
+
+	r := NewResolver("my-service.test", "8080", false, &refreshRate, nil, WithDNSServer(pc.LocalAddr().String()))
+	report := r.Doctor(context.Background())
+	assert.True(t, report.Healthy())
+	assert.Equal(t, []Finding{
+		{Check: "A", Severity: SeverityOK, Message: "2 records, TTL 2s"},
+		{Check: "A", Severity: SeverityWarning, Message: "TTL 2s below 5s, the answers are barely cached, avoid refreshing faster than the TTL"},
+		{Check: "AAAA", Severity: SeverityWarning, Message: "answer of 33 bytes truncated over UDP, some addresses may be missing, use WithForceTCP"},
+		{Check: "AAAA", Severity: SeverityOK, Message: "no records"},
+	}, report.Findings)
+	assert.Contains(t, report.String(), "[warning] A: TTL 2s")
+}
+
+func TestDoctorErrors(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+	go serveNXDomain(pc)
+
+	r := NewResolver("missing.test", "8080", false, &refreshRate, nil, WithDNSServer(pc.LocalAddr().String()))
+	report := r.Doctor(context.Background())
+	assert.False(t, report.Healthy())
+	assert.Equal(t, 3, len(report.Findings))
+	assert.Equal(t, "records", report.Findings[2].Check)
+
+	report = NewResolver("127.0.0.1", "8080", false, &refreshRate, nil).Doctor(context.Background())
+	assert.True(t, report.Healthy())
+	assert.Equal(t, "target", report.Findings[0].Check)
+}
+
+func TestDoctorIPv6Dialer(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			query := dnsmessage.Message{}
+			if err := query.Unpack(buf[:n]); err != nil {
+				continue
+			}
+
+			q := query.Questions[0]
+			answer := dnsmessage.Message{Header: dnsmessage.Header{ID: query.ID, Response: true}, Questions: query.Questions}
+			if q.Type == dnsmessage.TypeAAAA {
+				answer.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeAAAA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}},
+				}}
+			}
+			packed, _ := answer.Pack()
+			pc.WriteTo(packed, addr)
+		}
+	}()
+
+	// the probe goes through the dialer of the resolver
+	probed := false
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		if address == ipv6Probe {
+			probed = true
+			return nil, errors.New("no route")
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, address)
+	}
+
+	r := NewResolver("v6.test", "8080", false, &refreshRate, nil, WithDNSServer(pc.LocalAddr().String()), WithDialer(dial))
+	report := r.Doctor(context.Background())
+	assert.True(t, probed)
+	assert.Contains(t, report.String(), "the host has no IPv6 route (no route)")
+}
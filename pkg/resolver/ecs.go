@@ -0,0 +1,128 @@
+package resolver
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ecsOptionCode is the EDNS option code of the client subnet, RFC 7871
+const ecsOptionCode = 8
+
+// ClientSubnet is the EDNS client subnet sent with the queries and the
+// scope returned by the server, a zero scope usually means the answer
+// is not tailored to the subnet
+type ClientSubnet struct {
+	Subnet     string    // subnet sent with the queries in CIDR notation
+	Scope      int       // scope prefix length of the last answer carrying the option
+	LastAnswer time.Time // time of the last answer carrying the option, zero if none
+}
+
+// clientSubnet adds the subnet to the outgoing queries and records the
+// scope of the answers, it's shared by the resolvers of all the views
+type clientSubnet struct {
+	option dnsmessage.Option
+
+	m          sync.Mutex
+	subnet     string
+	scope      int
+	lastAnswer time.Time
+}
+
+// newClientSubnet returns the client subnet for the CIDR, nil if invalid
+func newClientSubnet(cidr string) *clientSubnet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil
+	}
+
+	family, ip := uint16(1), ipNet.IP.To4()
+	if ip == nil {
+		family, ip = 2, ipNet.IP.To16()
+	}
+
+	prefix, _ := ipNet.Mask.Size()
+	data := make([]byte, 4, 4+(prefix+7)/8)
+	binary.BigEndian.PutUint16(data, family)
+	data[2] = byte(prefix)
+	data = append(data, ip[:(prefix+7)/8]...)
+
+	return &clientSubnet{option: dnsmessage.Option{Code: ecsOptionCode, Data: data}, subnet: ipNet.String()}
+}
+
+// clientSubnetStatus returns the client subnet status, nil if not set
+func (r *DomainResolver) clientSubnetStatus() *ClientSubnet {
+	if r.clientSubnet == nil {
+		return nil
+	}
+
+	s := r.clientSubnet.status()
+	return &s
+}
+
+// status returns the subnet and the last scope returned
+func (c *clientSubnet) status() ClientSubnet {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return ClientSubnet{Subnet: c.subnet, Scope: c.scope, LastAnswer: c.lastAnswer}
+}
+
+// addOption returns the query with the subnet option added to its OPT
+// record, or a new OPT record if none, the query is unchanged on errors
+func (c *clientSubnet) addOption(query []byte) []byte {
+	msg := dnsmessage.Message{}
+	if err := msg.Unpack(query); err != nil {
+		return query
+	}
+
+	found := false
+	for i, rr := range msg.Additionals {
+		if opt, ok := rr.Body.(*dnsmessage.OPTResource); ok {
+			opt.Options = append(opt.Options, c.option)
+			msg.Additionals[i].Body = opt
+			found = true
+		}
+	}
+
+	if !found {
+		opt := dnsmessage.Resource{Body: &dnsmessage.OPTResource{Options: []dnsmessage.Option{c.option}}}
+		if err := opt.Header.SetEDNS0(defaultBufferSize, dnsmessage.RCodeSuccess, false); err != nil {
+			return query
+		}
+		msg.Additionals = append(msg.Additionals, opt)
+	}
+
+	packed, err := msg.Pack()
+	if err != nil {
+		return query
+	}
+
+	return packed
+}
+
+// recordScope stores the scope of the answer if it carries the option
+func (c *clientSubnet) recordScope(answer []byte) {
+	msg := dnsmessage.Message{}
+	if err := msg.Unpack(answer); err != nil {
+		return
+	}
+
+	for _, rr := range msg.Additionals {
+		opt, ok := rr.Body.(*dnsmessage.OPTResource)
+		if !ok {
+			continue
+		}
+
+		for _, o := range opt.Options {
+			if o.Code == ecsOptionCode && len(o.Data) >= 4 {
+				c.m.Lock()
+				c.scope = int(o.Data[3])
+				c.lastAnswer = time.Now()
+				c.m.Unlock()
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveECS answers the A queries with 10.0.0.1 and echoes the client
+// subnet option with a /16 scope, the received options are sent to subnets
+func serveECS(t *testing.T, pc net.PacketConn, subnets chan<- []byte) {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		query := dnsmessage.Message{}
+		if err := query.Unpack(buf[:n]); err != nil {
+			continue
+		}
+
+		answer := dnsmessage.Message{Header: dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true}, Questions: query.Questions}
+		q := query.Questions[0]
+		if q.Type == dnsmessage.TypeA {
+			answer.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}},
+			}}
+		}
+
+		for _, rr := range query.Additionals {
+			if opt, ok := rr.Body.(*dnsmessage.OPTResource); ok {
+				for _, o := range opt.Options {
+					if o.Code != ecsOptionCode {
+						continue
+					}
+
+					select {
+					case subnets <- o.Data:
+					default:
+					}
+
+					data := append([]byte{}, o.Data...)
+					data[3] = 16
+					echo := dnsmessage.Resource{Body: &dnsmessage.OPTResource{Options: []dnsmessage.Option{{Code: ecsOptionCode, Data: data}}}}
+					assert.Nil(t, echo.Header.SetEDNS0(1232, dnsmessage.RCodeSuccess, false))
+					answer.Additionals = append(answer.Additionals, echo)
+				}
+			}
+		}
+
+		packed, err := answer.Pack()
+		assert.Nil(t, err)
+		pc.WriteTo(packed, addr)
+	}
+}
+
+func TestClientSubnet(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+
+	subnets := make(chan []byte, 1)
+	go serveECS(t, pc, subnets)
+
+	r := NewResolver("ecs.test.", "8080", false, &refreshRate, nil, WithDNSServer(pc.LocalAddr().String()), WithClientSubnet("192.0.2.77/24"))
+	assert.Equal(t, &ClientSubnet{Subnet: "192.0.2.0/24"}, r.Status().ClientSubnet)
+
+	ips, err := r.netResolver.LookupIPAddr(context.Background(), "ecs.test.")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ips))
+	assert.Equal(t, "10.0.0.1", ips[0].IP.String())
+
+	// family 1, source prefix 24, scope 0 and the first 3 bytes of the address
+	assert.Equal(t, []byte{0, 1, 24, 0, 192, 0, 2}, <-subnets)
+	status := r.Status().ClientSubnet
+	assert.Equal(t, 16, status.Scope)
+	assert.False(t, status.LastAnswer.IsZero())
+}
+
+func TestClientSubnetInvalid(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithClientSubnet("not a subnet"))
+	assert.Nil(t, r.clientSubnet)
+	assert.Nil(t, r.Status().ClientSubnet)
+	assert.Equal(t, net.DefaultResolver, r.netResolver)
+
+	ecs := newClientSubnet("2001:db8::/32")
+	assert.Equal(t, []byte{0, 2, 32, 0, 0x20, 0x01, 0x0d, 0xb8}, ecs.option.Data)
+	assert.Equal(t, []byte("garbage"), ecs.addOption([]byte("garbage")))
+}
+
+func TestClientSubnetStream(t *testing.T) {
+	ecs := newClientSubnet("192.0.2.0/24")
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := wrapDNSConn(client, dnsHooks{ecs: ecs})
+	defer conn.Close()
+
+	query := dnsmessage.Message{Header: dnsmessage.Header{ID: 7}, Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName("ecs.test."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}}}
+	packed, err := query.Pack()
+	assert.Nil(t, err)
+
+	go func() {
+		framed := append([]byte{byte(len(packed) >> 8), byte(len(packed))}, packed...)
+		_, err := conn.Write(framed)
+		assert.Nil(t, err)
+	}()
+
+	buf := make([]byte, 512)
+	n, err := server.Read(buf)
+	assert.Nil(t, err)
+	received := dnsmessage.Message{}
+	assert.Nil(t, received.Unpack(buf[2:n]))
+	assert.Equal(t, 1, len(received.Additionals))
+
+	// the answer echoes the query with a /20 scope
+	opt := received.Additionals[0].Body.(*dnsmessage.OPTResource)
+	opt.Options[0].Data[3] = 20
+	received.Header.Response = true
+	answer, err := received.Pack()
+	assert.Nil(t, err)
+	go server.Write(append([]byte{byte(len(answer) >> 8), byte(len(answer))}, answer...))
+
+	length := make([]byte, 2)
+	_, err = conn.Read(length)
+	assert.Nil(t, err)
+	body := make([]byte, len(answer))
+	_, err = conn.Read(body)
+	assert.Nil(t, err)
+	assert.Equal(t, answer, body)
+	assert.Equal(t, 20, ecs.status().Scope)
+}
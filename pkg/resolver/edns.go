@@ -0,0 +1,66 @@
+package resolver
+
+import (
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// defaultBufferSize is the EDNS0 UDP buffer size advertised by the Go
+// resolver, also the size of the buffer it reads the UDP answers into
+const defaultBufferSize = 1232
+
+// setBufferSize returns the query with the UDP size of its OPT record set
+// to size, or a new OPT record if none, the query is unchanged on errors
+func setBufferSize(query []byte, size uint16) []byte {
+	msg := dnsmessage.Message{}
+	if err := msg.Unpack(query); err != nil {
+		return query
+	}
+
+	found := false
+	for i, rr := range msg.Additionals {
+		if rr.Header.Type == dnsmessage.TypeOPT {
+			msg.Additionals[i].Header.Class = dnsmessage.Class(size)
+			found = true
+		}
+	}
+
+	if !found {
+		opt := dnsmessage.Resource{Body: &dnsmessage.OPTResource{}}
+		if err := opt.Header.SetEDNS0(int(size), dnsmessage.RCodeSuccess, false); err != nil {
+			return query
+		}
+		msg.Additionals = append(msg.Additionals, opt)
+	}
+
+	packed, err := msg.Pack()
+	if err != nil {
+		return query
+	}
+
+	return packed
+}
+
+// truncatedAnswer returns the header and the question of the answer with
+// the TC bit set, so the Go resolver retries over TCP the answers larger
+// than its buffer instead of failing to parse them
+func truncatedAnswer(answer []byte) ([]byte, bool) {
+	var p dnsmessage.Parser
+	h, err := p.Start(answer)
+	if err != nil {
+		return nil, false
+	}
+
+	q, err := p.Question()
+	if err != nil {
+		return nil, false
+	}
+
+	h.Truncated = true
+	msg := dnsmessage.Message{Header: h, Questions: []dnsmessage.Question{q}}
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, false
+	}
+
+	return packed, true
+}
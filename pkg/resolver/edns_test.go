@@ -0,0 +1,105 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveBufferSize answers the queries with count A records and sends the
+// UDP size advertised by their OPT record to sizes, 0 if none
+func serveBufferSize(t *testing.T, pc net.PacketConn, count int, sizes chan<- int) {
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		query := dnsmessage.Message{}
+		if err := query.Unpack(buf[:n]); err != nil {
+			continue
+		}
+
+		size := 0
+		for _, rr := range query.Additionals {
+			if rr.Header.Type == dnsmessage.TypeOPT {
+				size = int(rr.Header.Class)
+			}
+		}
+		select {
+		case sizes <- size:
+		default:
+		}
+
+		answer := dnsmessage.Message{Header: dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true}, Questions: query.Questions}
+		q := query.Questions[0]
+		for i := 0; i < count && q.Type == dnsmessage.TypeA; i++ {
+			answer.Answers = append(answer.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{10, 0, byte(i >> 8), byte(i)}},
+			})
+		}
+
+		packed, err := answer.Pack()
+		assert.Nil(t, err)
+		pc.WriteTo(packed, addr)
+	}
+}
+
+func TestEDNS0BufferSize(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+
+	sizes := make(chan int, 1)
+	go serveBufferSize(t, pc, 1, sizes)
+
+	r := NewResolver("edns.test.", "8080", false, &refreshRate, nil, WithDNSServer(pc.LocalAddr().String()), WithEDNS0BufferSize(4096))
+	ips, err := r.netResolver.LookupIPAddr(context.Background(), "edns.test.")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ips))
+	assert.Equal(t, 4096, <-sizes)
+
+	// the client subnet OPT record gets the size too
+	r = NewResolver("edns.test.", "8080", false, &refreshRate, nil, WithDNSServer(pc.LocalAddr().String()), WithClientSubnet("192.0.2.0/24"), WithEDNS0BufferSize(1400))
+	_, err = r.netResolver.LookupIPAddr(context.Background(), "edns.test.")
+	assert.Nil(t, err)
+	assert.Equal(t, 1400, <-sizes)
+
+	assert.Equal(t, []byte("garbage"), setBufferSize([]byte("garbage"), 4096))
+}
+
+func TestEDNS0BufferSizeTruncated(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+
+	// 200 A records don't fit the 1232 bytes of the Go resolver buffer
+	go serveBufferSize(t, pc, 200, make(chan int))
+
+	raw, err := net.Dial("udp", pc.LocalAddr().String())
+	assert.Nil(t, err)
+	conn := wrapDNSConn(raw, dnsHooks{bufferSize: 4096})
+	defer conn.Close()
+
+	query := dnsmessage.Message{Header: dnsmessage.Header{ID: 9}, Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName("edns.test."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}}}
+	packed, err := query.Pack()
+	assert.Nil(t, err)
+	_, err = conn.Write(packed)
+	assert.Nil(t, err)
+
+	buf := make([]byte, defaultBufferSize)
+	n, err := conn.Read(buf)
+	assert.Nil(t, err)
+
+	answer := dnsmessage.Message{}
+	assert.Nil(t, answer.Unpack(buf[:n]))
+	assert.True(t, answer.Header.Truncated)
+	assert.Equal(t, uint16(9), answer.Header.ID)
+	assert.Equal(t, query.Questions, answer.Questions)
+	assert.Equal(t, 0, len(answer.Answers))
+}
@@ -0,0 +1,79 @@
+package resolver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultEndpointsInterval is how often the endpoints file is checked if
+// no interval is given to WithEndpointsDir
+const DefaultEndpointsInterval = 5 * time.Second
+
+// endpointsFile returns the file holding the addresses of the target
+func (r *DomainResolver) endpointsFile() string {
+	return filepath.Join(r.endpointsDir, r.lookupName())
+}
+
+// readEndpoints returns the addresses of the endpoints file, IPs or
+// IP:port separated by spaces, commas or new lines, the port of the
+// resolver is used for the IPs without one, the lines starting with #
+// are ignored
+func (r *DomainResolver) readEndpoints() []string {
+	data, err := ioutil.ReadFile(r.endpointsFile())
+	if err != nil {
+		log.Println("[grpc-resolver]: error reading endpoints file ", err)
+		return []string{}
+	}
+
+	return parseEndpoints(data, r.port)
+}
+
+func parseEndpoints(data []byte, port string) []string {
+	addrs := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, e := range strings.FieldsFunc(line, func(c rune) bool { return c == ',' || c == ' ' || c == '\t' }) {
+			host, p, err := net.SplitHostPort(e)
+			if err != nil {
+				host, p = strings.TrimSuffix(strings.TrimPrefix(e, "["), "]"), port
+			}
+
+			addrs = append(addrs, net.JoinHostPort(host, p))
+		}
+	}
+
+	return addrs
+}
+
+// watchEndpoints polls the endpoints file and refreshes the addresses
+// when its content differs from the last one until the resolver is
+// closed, the file is read through the symlinks of the ConfigMap and
+// Secret volumes so the atomic swap of their ..data link is seen as a
+// single change
+func (r *DomainResolver) watchEndpoints(last []byte) {
+	ticker := time.NewTicker(r.endpointsInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if r.isShutdown() {
+			return
+		}
+
+		data, err := ioutil.ReadFile(r.endpointsFile())
+		if err != nil || bytes.Equal(data, last) {
+			continue
+		}
+
+		log.Println("[grpc-resolver]: endpoints file changed ", r.endpointsFile())
+		last = data
+		r.Refresh()
+	}
+}
@@ -0,0 +1,47 @@
+package resolver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEndpoints(t *testing.T) {
+	data := []byte("# my-service\n10.0.0.1\n10.0.0.2:9090, [::1]:8080\n\n::2\n")
+	assert.Equal(t, []string{"10.0.0.1:8080", "10.0.0.2:9090", "[::1]:8080", "[::2]:8080"}, parseEndpoints(data, "8080"))
+}
+
+// writeConfigMap writes the file the way the kubelet does, in a new
+// timestamped directory swapped atomically through the ..data link
+func writeConfigMap(t *testing.T, dir, name, content string) {
+	data, err := ioutil.TempDir(dir, "..data_")
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(data, name), []byte(content), 0644))
+
+	link := filepath.Join(dir, "..data_tmp")
+	assert.Nil(t, os.Symlink(filepath.Base(data), link))
+	assert.Nil(t, os.Rename(link, filepath.Join(dir, "..data")))
+	if _, err := os.Lstat(filepath.Join(dir, name)); os.IsNotExist(err) {
+		assert.Nil(t, os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)))
+	}
+}
+
+func TestWithEndpointsDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "endpoints")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeConfigMap(t, dir, "my-service", "10.0.0.1\n")
+	r := NewResolver("my-service", "8080", false, &refreshRate, nil, WithEndpointsDir(dir, 10*time.Millisecond))
+	r.StartResolver()
+	defer r.Close()
+	assert.Equal(t, []string{"10.0.0.1:8080"}, r.Addresses)
+	assert.Equal(t, SourceFile, r.Status().Provenance["10.0.0.1:8080"].Source)
+
+	writeConfigMap(t, dir, "my-service", "10.0.0.1\n10.0.0.2\n")
+	assert.Eventually(t, func() bool { return len(r.Status().Addresses) == 2 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,82 @@
+package resolver
+
+import (
+	"log"
+	"time"
+)
+
+// event types
+const (
+	// EventResolution is emitted after every resolution attempt
+	EventResolution = "resolution"
+	// EventChange is emitted every time the address list changes
+	EventChange = "change"
+	// EventStall is emitted when the watcher missed ticks
+	EventStall = "stall"
+	// EventSnapshot carries the current address list, only sent by WatchEvents
+	EventSnapshot = "snapshot"
+)
+
+// Event describes a resolution attempt, an address list change, a warning
+// or an error budget state change
+type Event struct {
+	Schema     string              `json:"schema"` // see SchemaVersion
+	Type       string              `json:"type"`
+	Target     string              `json:"target"`
+	Time       time.Time           `json:"time"`
+	Version    uint64              `json:"version"`               // address list version, increased on every change
+	DurationMs float64             `json:"duration_ms,omitempty"` // only for resolution and stall events
+	Addresses  []string            `json:"addresses"`
+	Added      []string            `json:"added,omitempty"`   // only for change events
+	Removed    []string            `json:"removed,omitempty"` // only for change events
+	Base       uint64              `json:"base,omitempty"`    // only for delta change events, version the added and removed addresses apply to
+	Error      string              `json:"error,omitempty"`
+	DNS        []DNSAnswer         `json:"dns,omitempty"`         // only for resolution events, see DNSAnswer
+	Health     string              `json:"health,omitempty"`      // only for health events, BudgetHealthy, BudgetDegraded or BudgetStale
+	Hostnames  map[string][]string `json:"hostnames,omitempty"`   // only for change events with WithReverseLookup, PTR hostnames by address
+	CNAMEChain []string            `json:"cname_chain,omitempty"` // only for change events with WithCNAMETracking, names from the domain to its canonical name
+	Labels     map[string]string   `json:"labels,omitempty"`      // see WithLabels
+}
+
+// notified returns whether the event is sent to the webhook and to the
+// WatchEvents subscribers, the resolution and stall events only go to the
+// event log and the counters
+func notified(e Event) bool {
+	return e.Type == EventChange || e.Type == EventHealth || e.Type == EventWarning
+}
+
+// emit sends the event to the configured sinks
+func (r *DomainResolver) emit(e Event) {
+	e.Target = r.address
+	e.Labels = r.copyLabels()
+	r.recordStats(e)
+	r.recordMetrics(e)
+	r.recordUsage(e)
+	r.recordBudget(e)
+
+	// checked under em, Close takes it once the shutdown is set, so no
+	// event is delivered after Close returns
+	r.em.Lock()
+	defer r.em.Unlock()
+	if r.isShutdown() {
+		return
+	}
+
+	if r.webhook != nil && notified(e) && r.isLeader() {
+		r.webhook.enqueue(e)
+	}
+
+	r.deliverEvent(e)
+	if r.eventLog == nil {
+		return
+	}
+
+	data, err := MarshalEvent(e)
+	if err == nil {
+		_, err = r.eventLog.Write(append(data, '\n'))
+	}
+
+	if err != nil {
+		log.Println("[grpc-resolver]: error writing event ", err)
+	}
+}
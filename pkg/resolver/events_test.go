@@ -0,0 +1,44 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("write error") }
+
+func TestEventLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithEventLog(buf))
+	r.StartResolver()
+
+	r.Addresses = []string{"127.0.0.2:8080"}
+	_, isUpdated := r.getState(context.Background())
+	assert.True(t, isUpdated)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+
+	e := Event{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &e))
+	assert.Equal(t, EventResolution, e.Type)
+	assert.Equal(t, "localhost", e.Target)
+	assert.True(t, len(e.Addresses) > 0)
+
+	assert.Nil(t, json.Unmarshal([]byte(lines[2]), &e))
+	assert.Equal(t, EventChange, e.Type)
+	assert.Equal(t, []string{"127.0.0.2:8080"}, e.Removed)
+	assert.Equal(t, r.Addresses, e.Addresses)
+
+	// errors writing the events are only logged
+	r = NewResolver("no-domain1234.com", "8080", false, &refreshRate, nil, WithEventLog(failingWriter{}))
+	r.StartResolver()
+}
@@ -0,0 +1,62 @@
+package resolver
+
+import (
+	"log"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"google.golang.org/grpc/resolver"
+)
+
+// touch records the given addresses as seen now, the addresses
+// no longer returned are forgotten, expected to be called with
+// the lock held
+func (r *DomainResolver) touch(addrs []string) {
+	if r.addressTTL == 0 {
+		return
+	}
+
+	now := r.now()
+	seen := make(map[string]time.Time, len(addrs))
+	for _, a := range addrs {
+		seen[a] = now
+	}
+
+	r.lastSeen = seen
+}
+
+// expire drops the addresses not seen for longer than the address TTL,
+// meant to be called with the lock held after a failed refresh so the
+// state can't become stale forever during long DNS outages, the change
+// event is returned for announceChange
+func (r *DomainResolver) expire() (_ resolver.State, change Event, isUpdated bool) {
+	if r.addressTTL == 0 {
+		return resolver.State{}, Event{}, false
+	}
+
+	now := r.now()
+	kept := []string{}
+	for _, a := range r.Addresses {
+		if seen, ok := r.lastSeen[a]; ok && now.Sub(seen) <= r.addressTTL {
+			kept = append(kept, a)
+		}
+	}
+
+	if len(kept) == len(r.Addresses) {
+		return resolver.State{}, Event{}, false
+	}
+
+	_, removed := list.DiffListStr(r.Addresses, kept)
+	log.Println("[grpc-resolver]: addresses expired ", removed)
+	r.Addresses = kept
+	change = r.recordChange(nil, removed)
+
+	// the kept addresses are published as they were resolved
+	addrs := []resolver.Address{}
+	for _, a := range kept {
+		addrs = append(addrs, r.resolvedAddress(a))
+	}
+	r.setResolved(addrs)
+
+	return resolver.State{Addresses: addrs}, change, true
+}
@@ -0,0 +1,45 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestExpire(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	r.Addresses = []string{"127.0.0.1:8080"}
+	_, _, isUpdated := r.expire()
+	assert.False(t, isUpdated)
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithAddressTTL(time.Minute))
+	r.Addresses = []string{"127.0.0.1:8080", "127.0.0.2:8080"}
+	r.setResolved(r.prepare([]resolver.Address{{Addr: "127.0.0.1:8080", ServerName: "svc"}, {Addr: "127.0.0.2:8080"}}))
+	r.touch(r.Addresses)
+	_, _, isUpdated = r.expire()
+	assert.False(t, isUpdated)
+
+	r.lastSeen["127.0.0.2:8080"] = time.Now().Add(-2 * time.Minute)
+	st, e, isUpdated := r.expire()
+	assert.Equal(t, []string{"127.0.0.2:8080"}, e.Removed)
+	assert.True(t, isUpdated)
+	assert.Equal(t, "127.0.0.1:8080", st.Addresses[0].Addr)
+	assert.Equal(t, "svc", st.Addresses[0].ServerName) // kept as resolved
+	assert.Equal(t, []string{"127.0.0.1:8080"}, r.Addresses)
+	assert.Equal(t, 1, r.Status().Churn.Removed)
+}
+
+func TestExpireOnFailedRefresh(t *testing.T) {
+	r := NewResolver("no-domain1234.com", "8080", false, &refreshRate, nil, WithAddressTTL(time.Nanosecond))
+	r.Addresses = []string{"127.0.0.1:8080"}
+	r.touch(r.Addresses)
+	time.Sleep(time.Millisecond)
+
+	st, isUpdated := r.getState(context.Background())
+	assert.True(t, isUpdated)
+	assert.Equal(t, 0, len(st.Addresses))
+	assert.Equal(t, 0, len(r.Addresses))
+}
@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ExportSnapshot returns the snapshots of the resolvers with a running
+// watcher as a JSON array sorted by target, every element in the format
+// of MarshalSnapshot, so the discovery view of a process can be attached
+// to a bug report and replayed with ImportSnapshot
+func ExportSnapshot() ([]byte, error) {
+	registry.Lock()
+	resolvers := make([]*DomainResolver, 0, len(registry.watchers))
+	for r := range registry.watchers {
+		resolvers = append(resolvers, r)
+	}
+	registry.Unlock()
+
+	snapshots := make([]Snapshot, 0, len(resolvers))
+	for _, r := range resolvers {
+		snapshots = append(snapshots, r.Snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Target < snapshots[j].Target })
+
+	elems := make([]json.RawMessage, 0, len(snapshots))
+	for _, s := range snapshots {
+		data, err := MarshalSnapshot(s)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, data)
+	}
+
+	return json.Marshal(elems)
+}
+
+// ImportSnapshot replaces the lookups of the resolver by the addresses of
+// its target in the output of ExportSnapshot and refreshes it, so they go
+// through the same pipeline (ports, validation, caps, scoring, ordering)
+// and reach the balancer as they did in the exporting process. The
+// imported addresses are kept until the resolver is closed
+func (r *DomainResolver) ImportSnapshot(data []byte) error {
+	if !r.needLookup {
+		return errors.New("the addresses of an IP or passthrough target are static")
+	}
+
+	elems := []json.RawMessage{}
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+
+	for _, e := range elems {
+		s, err := UnmarshalSnapshot(e)
+		if err != nil {
+			return err
+		}
+		if s.Target != r.address {
+			continue
+		}
+
+		addrs := s.Addresses
+		if addrs == nil {
+			addrs = []string{}
+		}
+
+		r.m.Lock()
+		r.imported = addrs
+		r.m.Unlock()
+		r.Refresh()
+		return nil
+	}
+
+	return fmt.Errorf("no snapshot of %s", r.address)
+}
+
+// importedAddresses returns the addresses set by ImportSnapshot, nil if none
+func (r *DomainResolver) importedAddresses() []string {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.imported
+}
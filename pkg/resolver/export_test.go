@@ -0,0 +1,47 @@
+package resolver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportImportSnapshot(t *testing.T) {
+	hosts := map[string][]string{"export.test": {"10.0.0.1", "10.0.0.2"}}
+	exporter := NewResolver("export.test", "8080", true, &refreshRate, nil, WithHosts(hosts, true))
+	exporter.StartResolver()
+	defer exporter.Close()
+
+	data, err := ExportSnapshot()
+	assert.Nil(t, err)
+	elems := []json.RawMessage{}
+	assert.Nil(t, json.Unmarshal(data, &elems))
+	found := false
+	for _, e := range elems {
+		s, err := UnmarshalSnapshot(e)
+		assert.Nil(t, err)
+		if s.Target == "export.test" {
+			found = true
+			assert.Equal(t, []string{"10.0.0.1:8080", "10.0.0.2:8080"}, s.Addresses)
+		}
+	}
+	assert.True(t, found)
+
+	cc := &simConn{}
+	r := NewGRPCResolver(cc, "export.test", "9090", false, nil, WithMaxAddresses(1, false))
+	cc.r = r
+	defer r.Close()
+	assert.Nil(t, r.ImportSnapshot(data))
+	assert.Equal(t, 1, len(cc.published))
+	assert.Equal(t, []string{"10.0.0.1:8080"}, cc.published[0].Addresses)
+	assert.Equal(t, SourceImport, r.Status().Provenance["10.0.0.1:8080"].Source)
+
+	r.Refresh()
+	assert.Equal(t, []string{"10.0.0.1:8080"}, r.Snapshot().Addresses)
+
+	assert.NotNil(t, NewResolver("other.test", "8080", false, nil, nil).ImportSnapshot(data))
+	assert.NotNil(t, NewResolver("127.0.0.1", "8080", false, nil, nil).ImportSnapshot(data))
+	assert.NotNil(t, r.ImportSnapshot([]byte(`{}`)))
+	assert.NotNil(t, r.ImportSnapshot([]byte(`[{"schema":"v0"}]`)))
+}
@@ -0,0 +1,55 @@
+package resolver
+
+import (
+	"expvar"
+	"sync"
+)
+
+// expvarMu serializes the lookup and creation of the published maps,
+// expvar panics if a name is published twice
+var expvarMu sync.Mutex
+
+// newExpvarStats returns the map holding the counters of the target
+// under the prefix map, both are created if not published yet so the
+// resolvers of the same target share their counters
+func newExpvarStats(prefix, target string) *expvar.Map {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	root, ok := expvar.Get(prefix).(*expvar.Map)
+	if !ok {
+		root = expvar.NewMap(prefix)
+	}
+
+	stats, ok := root.Get(target).(*expvar.Map)
+	if !ok {
+		stats = new(expvar.Map).Init()
+		root.Set(target, stats)
+	}
+
+	return stats
+}
+
+// recordStats updates the expvar counters with the event
+func (r *DomainResolver) recordStats(e Event) {
+	if r.stats == nil {
+		return
+	}
+
+	switch e.Type {
+	case EventResolution:
+		r.stats.Add("lookups", 1)
+		if e.Error != "" {
+			r.stats.Add("errors", 1)
+			return
+		}
+	case EventChange:
+		r.stats.Add("changes", 1)
+	default:
+		return
+	}
+
+	addresses := new(expvar.Int)
+	addresses.Set(int64(len(e.Addresses)))
+	r.stats.Set("addresses", addresses)
+}
@@ -0,0 +1,35 @@
+package resolver
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpvar(t *testing.T) {
+	// expvar maps can't be unpublished, every run uses its own
+	prefix := fmt.Sprintf("resolver_test_%d", time.Now().UnixNano())
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithExpvar(prefix))
+	r.StartResolver()
+
+	stats := expvar.Get(prefix).(*expvar.Map).Get("localhost").(*expvar.Map)
+	assert.Equal(t, "1", stats.Get("lookups").String())
+	assert.Nil(t, stats.Get("errors"))
+	assert.Equal(t, len(r.Addresses), int(stats.Get("addresses").(*expvar.Int).Value()))
+
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.getState(context.Background())
+	assert.Equal(t, "2", stats.Get("lookups").String())
+	assert.Equal(t, "1", stats.Get("changes").String())
+
+	// same target, shared counters
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithExpvar(prefix))
+	r.hostsOnly = true
+	r.resolve(context.Background())
+	assert.Equal(t, "3", stats.Get("lookups").String())
+	assert.Equal(t, "1", stats.Get("errors").String())
+}
@@ -0,0 +1,77 @@
+package resolver
+
+import (
+	"log"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// ReportConnectResult lets the dialers or custom balancers report the
+// result of a connection attempt to an address, when the feedback is
+// enabled the failing addresses are moved to the end of the list and
+// quarantined after consecutive failures, even between refreshes
+func (r *DomainResolver) ReportConnectResult(addr string, err error) {
+	r.access()
+	if r.quarantineAfter <= 0 {
+		return
+	}
+
+	r.m.Lock()
+	changed := false
+	if err == nil {
+		_, quarantined := r.quarantined[addr]
+		changed = r.failures[addr] > 0 || quarantined
+		delete(r.failures, addr)
+		delete(r.quarantined, addr)
+	} else {
+		r.failures[addr]++
+		changed = r.failures[addr] == 1
+		if r.failures[addr] >= r.quarantineAfter {
+			if _, ok := r.quarantined[addr]; !ok {
+				log.Println("[grpc-resolver]: quarantining address ", addr, " after ", r.failures[addr], " failures", r.labelsString())
+				changed = true
+			}
+			r.quarantined[addr] = r.now().Add(r.quarantineFor)
+		}
+	}
+	st := r.resolvedState
+	r.m.Unlock()
+
+	if changed && st != nil {
+		r.publish(*st)
+	}
+}
+
+// applyFeedback drops the quarantined addresses, unless no address would
+// be left, and moves the ones with failures to the end keeping the order,
+// it's expected to be called with the lock held
+func (r *DomainResolver) applyFeedback(addrs []resolver.Address, now time.Time) []resolver.Address {
+	if r.quarantineAfter <= 0 {
+		return addrs
+	}
+
+	healthy, failing := []resolver.Address{}, []resolver.Address{}
+	for _, a := range addrs {
+		if until, ok := r.quarantined[a.Addr]; ok {
+			if now.Before(until) {
+				continue
+			}
+			// give it another chance, one more failure quarantines it again
+			delete(r.quarantined, a.Addr)
+			r.failures[a.Addr] = r.quarantineAfter - 1
+		}
+
+		if r.failures[a.Addr] > 0 {
+			failing = append(failing, a)
+		} else {
+			healthy = append(healthy, a)
+		}
+	}
+
+	if len(healthy)+len(failing) == 0 {
+		return addrs
+	}
+
+	return append(healthy, failing...)
+}
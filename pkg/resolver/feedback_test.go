@@ -0,0 +1,65 @@
+package resolver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestApplyFeedback(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithConnectFeedback(2, time.Minute))
+	addrs := []resolver.Address{{Addr: "10.0.0.1:80"}, {Addr: "10.0.0.2:80"}, {Addr: "10.0.0.3:80"}}
+	now := time.Now()
+
+	r.failures["10.0.0.1:80"] = 1
+	r.quarantined["10.0.0.2:80"] = now.Add(time.Minute)
+	assert.Equal(t, []string{"10.0.0.3:80", "10.0.0.1:80"}, list.FromAddrToString(r.applyFeedback(addrs, now)))
+
+	// quarantine over
+	assert.Equal(t, []string{"10.0.0.3:80", "10.0.0.1:80", "10.0.0.2:80"}, list.FromAddrToString(r.applyFeedback(addrs, now.Add(2*time.Minute))))
+	assert.Equal(t, 1, r.failures["10.0.0.2:80"])
+
+	// every address quarantined
+	only := []resolver.Address{{Addr: "10.0.0.4:80"}}
+	r.quarantined["10.0.0.4:80"] = now.Add(time.Minute)
+	assert.Equal(t, only, r.applyFeedback(only, now))
+
+	// disabled
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, addrs, r.applyFeedback(addrs, now))
+}
+
+func TestReportConnectResult(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "127.0.0.1", "8080", false, &refreshRate, WithConnectFeedback(2, time.Minute))
+	r.publish(resolver.State{Addresses: []resolver.Address{{Addr: "10.0.0.1:80"}, {Addr: "10.0.0.2:80"}}})
+	assert.Equal(t, 1, len(cc.States()))
+
+	err := errors.New("connection refused")
+	r.ReportConnectResult("10.0.0.1:80", err)
+	st, _ := cc.LastState()
+	assert.Equal(t, []string{"10.0.0.2:80", "10.0.0.1:80"}, list.FromAddrToString(st.Addresses))
+
+	r.ReportConnectResult("10.0.0.1:80", err)
+	st, _ = cc.LastState()
+	assert.Equal(t, []string{"10.0.0.2:80"}, list.FromAddrToString(st.Addresses))
+	assert.Equal(t, 3, len(cc.States()))
+
+	// still failing, nothing to republish
+	r.ReportConnectResult("10.0.0.1:80", err)
+	assert.Equal(t, 3, len(cc.States()))
+
+	r.ReportConnectResult("10.0.0.1:80", nil)
+	st, _ = cc.LastState()
+	assert.Equal(t, []string{"10.0.0.1:80", "10.0.0.2:80"}, list.FromAddrToString(st.Addresses))
+
+	// disabled
+	r = NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	r.ReportConnectResult("10.0.0.1:80", err)
+	assert.Nil(t, r.failures)
+}
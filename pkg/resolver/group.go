@@ -0,0 +1,93 @@
+package resolver
+
+import (
+	"log"
+	"sync"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// Group holds back the publication of its members until every one of
+// them has at least one address, e.g. a service and its sidecar metrics
+// endpoint, avoiding a partial wiring during the startup, once complete
+// the members publish on their own. Every member has to be created with
+// WithGroup before any of them is started
+type Group struct {
+	m        sync.Mutex
+	members  map[*DomainResolver]bool // true once the member has an address
+	complete bool
+}
+
+// NewGroup creates an empty group
+func NewGroup() *Group {
+	return &Group{members: map[*DomainResolver]bool{}}
+}
+
+func (g *Group) add(r *DomainResolver) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.members[r] = false
+}
+
+func (g *Group) remove(r *DomainResolver) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	delete(g.members, r)
+}
+
+// Complete returns true once every member had at least one address
+func (g *Group) Complete() bool {
+	g.m.Lock()
+	defer g.m.Unlock()
+	return g.complete
+}
+
+// ready records the state of the member and returns true if it can be
+// published, the held states of the other members are published when
+// the group becomes complete
+func (g *Group) ready(r *DomainResolver, st resolver.State) bool {
+	g.m.Lock()
+	if g.complete {
+		g.m.Unlock()
+		return true
+	}
+
+	if len(st.Addresses) > 0 {
+		g.members[r] = true
+	}
+
+	for _, ok := range g.members {
+		if !ok {
+			g.m.Unlock()
+			return false
+		}
+	}
+
+	g.complete = true
+	others := []*DomainResolver{}
+	for m := range g.members {
+		if m != r {
+			others = append(others, m)
+		}
+	}
+	g.m.Unlock()
+
+	log.Println("[grpc-resolver]: group of ", r.address, " complete, publishing ", len(g.members), " targets")
+	for _, m := range others {
+		go m.publishHeld()
+	}
+
+	return true
+}
+
+// publishHeld publishes the state held back by the gate, if any
+func (r *DomainResolver) publishHeld() {
+	r.m.Lock()
+	held := r.heldState
+	r.heldState = nil
+	r.m.Unlock()
+
+	if held != nil {
+		r.publish(*held)
+	}
+}
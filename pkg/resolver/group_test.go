@@ -0,0 +1,32 @@
+package resolver
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	var published int32
+	g := NewGroup()
+	count := WithPublishers(PublisherFunc(func(s Snapshot) error {
+		atomic.AddInt32(&published, 1)
+		return nil
+	}))
+	service := NewResolver("127.0.0.1", "8080", false, &refreshRate, nil, WithGroup(g), count)
+	metrics := NewResolver("127.0.0.1", "9090", false, &refreshRate, nil, WithGroup(g), count)
+	defer service.Close()
+	defer metrics.Close()
+
+	// the service waits for the metrics endpoint
+	service.StartResolver()
+	assert.False(t, g.Complete())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&published))
+
+	// and both are published once it has an address
+	metrics.StartResolver()
+	assert.True(t, g.Complete())
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&published) == 2 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,48 @@
+package resolver
+
+import (
+	"context"
+	"time"
+)
+
+// lookUpHedged looks up the name against the first hedged server and
+// against the next one every hedge delay, or right away if the previous
+// lookup failed, the first non empty result is returned and the pending
+// lookups are cancelled
+func (r *DomainResolver) lookUpHedged(ctx context.Context, name string) []string {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan []string, len(r.hedged))
+	next, pending := 0, 0
+	launch := func() {
+		res := r.hedged[next]
+		next++
+		pending++
+		go func() { results <- lookUpByIP(ctx, res, name) }()
+	}
+
+	timer := time.NewTimer(r.hedgeDelay)
+	defer timer.Stop()
+	launch()
+	for pending > 0 {
+		select {
+		case ips := <-results:
+			pending--
+			if len(ips) > 0 {
+				return ips
+			}
+			if next < len(r.hedged) {
+				launch()
+				timer.Reset(r.hedgeDelay)
+			}
+		case <-timer.C:
+			if next < len(r.hedged) {
+				launch()
+				timer.Reset(r.hedgeDelay)
+			}
+		}
+	}
+
+	return []string{}
+}
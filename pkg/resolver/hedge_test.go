@@ -0,0 +1,55 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHedgedLookUp(t *testing.T) {
+	// the silent server never answers
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer silent.Close()
+
+	fast, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer fast.Close()
+	go serveECS(t, fast, make(chan []byte))
+
+	r := NewResolver("hedge.test.", "8080", false, &refreshRate, nil, WithHedgedServers(20*time.Millisecond, silent.LocalAddr().String(), fast.LocalAddr().String()))
+	assert.Equal(t, 2, len(r.hedged))
+
+	start := time.Now()
+	assert.Equal(t, []string{"10.0.0.1"}, r.lookUp(context.Background(), "hedge.test."))
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestHedgedLookUpFailure(t *testing.T) {
+	nx, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer nx.Close()
+	go serveNXDomain(nx)
+
+	fast, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer fast.Close()
+	go serveECS(t, fast, make(chan []byte))
+
+	// the failed lookup doesn't wait for the delay
+	r := NewResolver("hedge.test.", "8080", false, &refreshRate, nil, WithHedgedServers(time.Hour, nx.LocalAddr().String(), fast.LocalAddr().String()))
+	assert.Equal(t, []string{"10.0.0.1"}, r.lookUp(context.Background(), "hedge.test."))
+
+	// every lookup failed
+	r = NewResolver("hedge.test.", "8080", false, &refreshRate, nil, WithHedgedServers(time.Hour, nx.LocalAddr().String()))
+	assert.Empty(t, r.lookUp(context.Background(), "hedge.test."))
+}
+
+func TestWithHedgedServers(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithHedgedServers(time.Millisecond, "10.0.0.53", "10.0.0.54:5353"))
+	assert.Equal(t, []string{"10.0.0.53:53", "10.0.0.54:5353"}, r.hedgeServers)
+	assert.Equal(t, time.Millisecond, r.hedgeDelay)
+}
@@ -0,0 +1,86 @@
+package resolver
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"net"
+	"os"
+	"strings"
+)
+
+// lookUp looks up the ips of the given name, the hosts override
+// is consulted first and DNS is only queried if there is no match
+// and the override is not exclusive
+func (r *DomainResolver) lookUp(ctx context.Context, name string) []string {
+	if r.simulation != nil {
+		return r.simulation.records()
+	}
+
+	hosts := r.hosts
+	if r.hostsFile != "" {
+		parsed, err := parseHostsFile(r.hostsFile)
+		if err != nil {
+			log.Println("[grpc-resolver]: error reading hosts file ", err)
+		}
+		hosts = parsed
+	}
+
+	if ips := hostsLookUp(hosts, name); len(ips) > 0 || r.hostsOnly {
+		recordHostsHits(ctx, ips)
+		return ips
+	}
+
+	if len(r.views) > 0 {
+		return r.lookUpViews(ctx, name)
+	}
+
+	if len(r.hedged) > 0 {
+		return r.lookUpHedged(ctx, name)
+	}
+
+	return lookUpByIP(ctx, r.resolverFor(ctx), name)
+}
+
+// hostsLookUp returns the records of the given name in the hosts map
+func hostsLookUp(hosts map[string][]string, name string) []string {
+	ips := []net.IP{}
+	for _, h := range hosts[strings.ToLower(strings.TrimSuffix(name, "."))] {
+		if ip := net.ParseIP(h); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+
+	return pushRecords(ips)
+}
+
+// parseHostsFile parses a file in the /etc/hosts format
+// returning the ips indexed by the lower case hostname
+func parseHostsFile(path string) (map[string][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hosts := map[string][]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+			continue
+		}
+
+		for _, name := range fields[1:] {
+			name = strings.ToLower(strings.TrimSuffix(name, "."))
+			hosts[name] = append(hosts[name], fields[0])
+		}
+	}
+
+	return hosts, scanner.Err()
+}
@@ -0,0 +1,40 @@
+package resolver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHostsFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "hosts")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+
+	f.WriteString("# comment\n10.0.0.1 my-service My-Service.local. # pinned\n::1 my-service\ninvalid line\n")
+	f.Close()
+
+	hosts, err := parseHostsFile(f.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "::1"}, hosts["my-service"])
+	assert.Equal(t, []string{"10.0.0.1"}, hosts["my-service.local"])
+
+	_, err = parseHostsFile("/no/such/file")
+	assert.NotNil(t, err)
+}
+
+func TestLookUpHosts(t *testing.T) {
+	hosts := map[string][]string{"my-service": {"10.0.0.1", "::1", "invalid"}}
+	r := NewResolver("my-service", "8080", false, &refreshRate, nil, WithHosts(hosts, true))
+	assert.Equal(t, []string{"10.0.0.1", "::1"}, r.lookUp(context.Background(), "My-Service."))
+	assert.Equal(t, []string{}, r.lookUp(context.Background(), "localhost"))
+
+	r = NewResolver("my-service", "8080", false, &refreshRate, nil, WithHosts(hosts, false))
+	assert.True(t, len(r.lookUp(context.Background(), "localhost")) > 0)
+
+	r = NewResolver("my-service", "8080", false, &refreshRate, nil, WithHostsFile("/no/such/file", true))
+	assert.Equal(t, []string{}, r.lookUp(context.Background(), "my-service"))
+}
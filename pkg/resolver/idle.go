@@ -0,0 +1,96 @@
+package resolver
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var idle = struct {
+	sync.Mutex
+	timeout time.Duration
+	done    chan struct{} // closed to stop the janitor, nil if not running
+}{}
+
+// SetIdleTimeout closes the watched resolvers not accessed for longer
+// than the timeout, 0 disables it. Reading the status, the snapshot, the
+// names or the readiness, subscribing and reporting connection results
+// are accesses, so is having a Watch subscriber that read the last list.
+// The resolvers of gRPC are never closed this way since their ClientConn
+// closes them, nor the ones with a listener since the reads of the
+// Addresses field can't be tracked, nor the ones feeding a publisher, a
+// webhook, an event log or WatchEvents subscribers, it reclaims the
+// watchers of the standalone resolvers an application forgot to close
+func SetIdleTimeout(timeout time.Duration) {
+	idle.Lock()
+	defer idle.Unlock()
+	if idle.done != nil {
+		close(idle.done)
+		idle.done = nil
+	}
+
+	idle.timeout = timeout
+	if timeout > 0 {
+		idle.done = make(chan struct{})
+		go closeIdle(timeout, idle.done)
+	}
+}
+
+// access records the resolver was used now
+func (r *DomainResolver) access() {
+	atomic.StoreInt64(&r.lastAccess, time.Now().UnixNano())
+}
+
+// closeIdle checks the watched resolvers every half timeout until done
+func closeIdle(timeout time.Duration, done chan struct{}) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			registry.Lock()
+			resolvers := make([]*DomainResolver, 0, len(registry.watchers))
+			for r := range registry.watchers {
+				resolvers = append(resolvers, r)
+			}
+			registry.Unlock()
+
+			for _, r := range resolvers {
+				if r.isIdle(now, timeout) {
+					log.Println("[grpc-resolver]: closing idle resolver ", r.address, ", not accessed for ", timeout, r.labelsString())
+					r.Close()
+				}
+			}
+		}
+	}
+}
+
+// isIdle returns true if the resolver wasn't accessed within the timeout,
+// the resolvers with a listener are never idle since their owner reads the
+// Addresses field after the signals and those reads can't be tracked, nor
+// the ones with a consumer of their changes, see hasConsumers
+func (r *DomainResolver) isIdle(now time.Time, timeout time.Duration) bool {
+	if r.updateState || r.listener != nil || r.hasConsumers() {
+		return false
+	}
+
+	watching, _ := r.watching.Load().([]chan []string)
+	for _, ch := range watching {
+		if len(ch) == 0 {
+			r.access() // the subscriber read the last list
+		}
+	}
+
+	last := time.Unix(0, atomic.LoadInt64(&r.lastAccess))
+	return now.Sub(last) > timeout
+}
+
+// hasConsumers returns true if the changes of the resolver go somewhere
+// besides the accessors, publishers, ClientConnPublisher included, a
+// webhook, an event log or WatchEvents subscribers
+func (r *DomainResolver) hasConsumers() bool {
+	return r.webhook != nil || r.eventLog != nil || atomic.LoadInt32(&r.consumers) > 0
+}
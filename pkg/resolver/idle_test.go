@@ -0,0 +1,76 @@
+package resolver
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetIdleTimeout(t *testing.T) {
+	rate := time.Duration(3600)
+	idleOne := NewResolver("localhost", "8080", true, &rate, nil)
+	idleOne.StartResolver()
+	defer idleOne.Close()
+
+	// a subscriber reading the lists keeps the resolver alive
+	watched := NewResolver("localhost", "8080", true, &rate, nil)
+	watched.StartResolver()
+	defer watched.Close()
+	ch := watched.Watch()
+	<-ch
+
+	SetIdleTimeout(50 * time.Millisecond)
+	defer SetIdleTimeout(0)
+
+	assert.Eventually(t, idleOne.isShutdown, time.Second, 5*time.Millisecond)
+	assert.False(t, watched.isShutdown())
+
+	// gRPC resolvers are closed by their ClientConn
+	assert.False(t, (&DomainResolver{updateState: true}).isIdle(time.Now(), time.Millisecond))
+}
+
+func TestIsIdleListener(t *testing.T) {
+	rate := time.Duration(3600)
+	r := NewResolver("localhost", "8080", true, &rate, make(chan bool, 1))
+	assert.False(t, r.isIdle(time.Now().Add(time.Hour), time.Millisecond))
+
+	// the subscribers are checked without the lock
+	r = NewResolver("localhost", "8080", true, &rate, nil)
+	ch := r.Watch()
+	atomic.StoreInt64(&r.lastAccess, 0)
+	r.m.Lock()
+	assert.False(t, r.isIdle(time.Now(), time.Minute))
+	r.m.Unlock()
+
+	r.Unwatch(ch)
+	atomic.StoreInt64(&r.lastAccess, 0)
+	assert.True(t, r.isIdle(time.Now(), time.Minute))
+}
+
+func TestIsIdleConsumers(t *testing.T) {
+	rate := time.Duration(3600)
+	later := time.Now().Add(time.Hour)
+	for name, opt := range map[string]Option{
+		"publisher":  WithPublishers(PublisherFunc(func(Snapshot) error { return nil })),
+		"webhook":    WithWebhook("http://127.0.0.1:0", 0),
+		"event log":  WithEventLog(&bytes.Buffer{}),
+		"no options": WithLabels(nil),
+	} {
+		r := NewResolver("localhost", "8080", true, &rate, nil, opt)
+		assert.Equal(t, name == "no options", r.isIdle(later, time.Minute), name)
+	}
+
+	r := NewResolver("localhost", "8080", true, &rate, nil)
+	r.AddPublisher(NewClientConnPublisher(resolvertest.NewClientConn()))
+	assert.False(t, r.isIdle(later, time.Minute))
+
+	r = NewResolver("localhost", "8080", true, &rate, nil)
+	ch := r.WatchEvents()
+	assert.False(t, r.isIdle(later, time.Minute))
+	r.UnwatchEvents(ch)
+	assert.True(t, r.isIdle(later, time.Minute))
+}
@@ -0,0 +1,86 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor returns an interceptor annotating the Unavailable
+// errors with the discovery context of the resolver (address count, version
+// and last refresh), so the failures can be correlated with stale discovery,
+// a resolver created with WithLazyStart is started by the first call
+func (r *DomainResolver) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		r.startLazily()
+		return r.annotate(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor returns an interceptor annotating the Unavailable
+// errors returned when the stream is created, see UnaryClientInterceptor
+func (r *DomainResolver) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		r.startLazily()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		return cs, r.annotate(err)
+	}
+}
+
+// annotate appends the discovery context to the Unavailable errors
+// keeping the code, the rest of the errors are returned as they are
+func (r *DomainResolver) annotate(err error) error {
+	st, ok := status.FromError(err)
+	if err == nil || !ok || st.Code() != codes.Unavailable {
+		return err
+	}
+
+	s := r.Status()
+	refresh := "never"
+	if !s.LastRefresh.IsZero() {
+		refresh = time.Since(s.LastRefresh).Round(time.Millisecond).String() + " ago"
+	}
+
+	discovery := fmt.Sprintf("discovery: target %s, %d addresses, version %d, last refresh %s", s.Address, len(s.Addresses), s.Version, refresh)
+	if n, ok := r.unavailable.take(time.Now()); ok {
+		log.Println("[grpc-resolver]: ", n, " unavailable errors, ", discovery)
+	}
+
+	// the details and the rest of the status are kept
+	p := st.Proto()
+	p.Message = fmt.Sprintf("%s (%s)", st.Message(), discovery)
+	return status.ErrorProto(p)
+}
+
+// unavailableLogInterval is the minimum time between two logs of the
+// unavailable errors annotated by the interceptors
+const unavailableLogInterval = time.Minute
+
+// logLimiter counts the errors between the logs limited to one per
+// unavailableLogInterval
+type logLimiter struct {
+	sync.Mutex
+	last  time.Time
+	count int
+}
+
+// take counts an error, returns the errors counted since the last log
+// and true if it's time to log them
+func (l *logLimiter) take(now time.Time) (int, bool) {
+	l.Lock()
+	defer l.Unlock()
+	l.count++
+	if !l.last.IsZero() && now.Sub(l.last) < unavailableLogInterval {
+		return 0, false
+	}
+
+	n := l.count
+	l.last, l.count = now, 0
+	return n, true
+}
@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAnnotate(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	err := r.annotate(status.Error(codes.Unavailable, "no healthy upstream"))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Contains(t, err.Error(), "no healthy upstream (discovery: target localhost, 0 addresses, version 0, last refresh never)")
+
+	r.StartResolver()
+	assert.False(t, r.Status().LastRefresh.IsZero())
+	err = r.annotate(status.Error(codes.Unavailable, "no healthy upstream"))
+	assert.Contains(t, err.Error(), "ago)")
+
+	other := status.Error(codes.NotFound, "not found")
+	assert.Equal(t, other, r.annotate(other))
+	// the details are kept
+	detailed, err := status.New(codes.Unavailable, "down").WithDetails(&duration.Duration{Seconds: 1})
+	assert.Nil(t, err)
+	annotated := status.Convert(r.annotate(detailed.Err()))
+	assert.Contains(t, annotated.Message(), "down (discovery: target localhost")
+	assert.Equal(t, 1, len(annotated.Details()))
+
+	plain := errors.New("plain")
+	assert.Equal(t, plain, r.annotate(plain))
+	assert.Nil(t, r.annotate(nil))
+}
+
+func TestLogLimiter(t *testing.T) {
+	l := logLimiter{}
+	now := time.Now()
+	n, ok := l.take(now)
+	assert.True(t, ok)
+	assert.Equal(t, 1, n)
+
+	_, ok = l.take(now.Add(time.Second))
+	assert.False(t, ok)
+	_, ok = l.take(now.Add(2 * time.Second))
+	assert.False(t, ok)
+
+	// the errors not logged are counted in the next log
+	n, ok = l.take(now.Add(unavailableLogInterval))
+	assert.True(t, ok)
+	assert.Equal(t, 3, n)
+}
+
+func TestInterceptors(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	err := r.UnaryClientInterceptor()(context.Background(), "/svc/Method", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return unavailable
+		})
+	assert.Contains(t, err.Error(), "discovery: target localhost")
+
+	_, err = r.StreamClientInterceptor()(context.Background(), &grpc.StreamDesc{}, nil, "/svc/Method",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, unavailable
+		})
+	assert.Contains(t, err.Error(), "discovery: target localhost")
+}
@@ -0,0 +1,37 @@
+package resolver
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// MinRefreshInterval is the shortest refresh interval allowed
+	// by WithRefreshInterval, shorter ones are raised to it
+	MinRefreshInterval = 100 * time.Millisecond
+
+	// highQueryRate is the number of DNS queries per second
+	// above which a warning is logged
+	highQueryRate = 20
+)
+
+// refreshInterval returns the interval of the watcher, the one set by
+// WithRefreshInterval if any or the refresh rate in seconds otherwise
+func (r *DomainResolver) refreshInterval(refreshRate *time.Duration) time.Duration {
+	if r.customInterval <= 0 {
+		return time.Second * (*refreshRate)
+	}
+
+	interval := r.customInterval
+	if interval < MinRefreshInterval {
+		log.Println("[grpc-resolver]: refresh interval ", interval, " too short, using ", MinRefreshInterval)
+		interval = MinRefreshInterval
+	}
+
+	// A and AAAA queries for every name tried
+	if qps := float64(2*len(r.queryNames())) / interval.Seconds(); qps > highQueryRate {
+		log.Printf("[grpc-resolver]: refreshing %s every %v sends up to %.0f DNS queries per second", r.address, interval, qps)
+	}
+
+	return interval
+}
@@ -0,0 +1,38 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshInterval(t *testing.T) {
+	rate := time.Duration(2)
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, 2*time.Second, r.refreshInterval(&rate))
+
+	r = NewResolver("localhost", "8080", false, nil, nil, WithRefreshInterval(250*time.Millisecond))
+	assert.Equal(t, 250*time.Millisecond, r.refreshInterval(nil))
+
+	r = NewResolver("localhost", "8080", false, nil, nil, WithRefreshInterval(time.Millisecond))
+	assert.Equal(t, MinRefreshInterval, r.refreshInterval(nil))
+}
+
+func TestSubSecondWatcher(t *testing.T) {
+	listener := make(chan bool, 10)
+	r := NewResolver("localhost", "8080", true, nil, listener, WithRefreshInterval(MinRefreshInterval))
+	assert.Equal(t, MinRefreshInterval, r.interval)
+	r.StartResolver()
+	defer r.Close()
+
+	r.m.Lock()
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.m.Unlock()
+
+	select {
+	case <-listener:
+	case <-time.After(time.Second):
+		t.Fatal("the watcher didn't refresh")
+	}
+}
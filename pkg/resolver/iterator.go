@@ -0,0 +1,49 @@
+package resolver
+
+import "sync"
+
+// Iterator hands out the addresses of the resolver in round robin, the
+// new address lists are picked up on the next call, it's safe to be used
+// concurrently
+type Iterator struct {
+	r       *DomainResolver
+	updates <-chan []string
+
+	m     sync.Mutex
+	addrs []string
+	next  int
+}
+
+// Iterator returns a round robin iterator over the current addresses,
+// it should be closed once not needed anymore
+func (r *DomainResolver) Iterator() *Iterator {
+	return &Iterator{r: r, updates: r.Watch()}
+}
+
+// Next returns the next address, empty if the resolver has none
+func (it *Iterator) Next() string {
+	it.m.Lock()
+	defer it.m.Unlock()
+
+	select {
+	case addrs, ok := <-it.updates:
+		if ok {
+			it.addrs = addrs
+		}
+	default:
+	}
+
+	if len(it.addrs) == 0 {
+		return ""
+	}
+
+	addr := it.addrs[it.next%len(it.addrs)]
+	it.next++
+	return addr
+}
+
+// Close stops picking up the new address lists, the last one keeps
+// being handed out
+func (it *Iterator) Close() {
+	it.r.Unwatch(it.updates)
+}
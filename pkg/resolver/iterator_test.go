@@ -0,0 +1,38 @@
+package resolver
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterator(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	r.Addresses = []string{"10.0.0.1:8080", "10.0.0.2:8080"}
+	it := r.Iterator()
+	defer it.Close()
+
+	assert.Equal(t, "10.0.0.1:8080", it.Next())
+	assert.Equal(t, "10.0.0.2:8080", it.Next())
+	assert.Equal(t, "10.0.0.1:8080", it.Next())
+
+	// the new list is picked up on the next call
+	r.m.Lock()
+	r.Addresses = []string{"10.0.0.3:8080"}
+	r.sendLatest()
+	r.m.Unlock()
+	assert.Equal(t, "10.0.0.3:8080", it.Next())
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "10.0.0.3:8080", it.Next())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, "", (&Iterator{updates: make(chan []string)}).Next())
+}
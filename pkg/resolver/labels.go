@@ -0,0 +1,47 @@
+package resolver
+
+import (
+	"expvar"
+	"sort"
+	"strings"
+)
+
+// labelsString returns the labels as a sorted key=value list to be added
+// to the logs, empty if there are none
+func (r *DomainResolver) labelsString() string {
+	if len(r.labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(r.labels))
+	for k, v := range r.labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+
+	return " {" + strings.Join(pairs, " ") + "}"
+}
+
+// copyLabels returns a copy of the labels, nil if there are none
+func (r *DomainResolver) copyLabels() map[string]string {
+	if len(r.labels) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]string, len(r.labels))
+	for k, v := range r.labels {
+		labels[k] = v
+	}
+
+	return labels
+}
+
+// publishLabels adds the labels to the expvar counters of the target
+func (r *DomainResolver) publishLabels() {
+	if r.stats == nil || len(r.labels) == 0 {
+		return
+	}
+
+	labels := r.copyLabels()
+	r.stats.Set("labels", expvar.Func(func() interface{} { return labels }))
+}
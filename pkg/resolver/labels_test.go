@@ -0,0 +1,41 @@
+package resolver
+
+import (
+	"bufio"
+	"bytes"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabels(t *testing.T) {
+	labels := map[string]string{"team": "payments", "tier": "1"}
+	buf := &bytes.Buffer{}
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithLabels(labels), WithEventLog(buf), WithExpvar("labels_test"))
+	labels["team"] = "changed"
+	r.StartResolver()
+
+	want := map[string]string{"team": "payments", "tier": "1"}
+	assert.Equal(t, want, r.Status().Labels)
+	assert.Equal(t, " {team=payments tier=1}", r.labelsString())
+
+	scanner := bufio.NewScanner(buf)
+	assert.True(t, scanner.Scan())
+	e, err := UnmarshalEvent(scanner.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, want, e.Labels)
+
+	stats := expvar.Get("labels_test").(*expvar.Map).Get("localhost").(*expvar.Map)
+	assert.Equal(t, `{"team":"payments","tier":"1"}`, stats.Get("labels").String())
+}
+
+func TestNoLabels(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Nil(t, r.Status().Labels)
+	assert.Equal(t, "", r.labelsString())
+
+	data, err := MarshalEvent(Event{Type: EventChange})
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "labels")
+}
@@ -0,0 +1,86 @@
+package resolver
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// startLazily starts the resolver the first time it's called if it was
+// created with WithLazyStart, the resolvers started eagerly or already
+// closed are ignored
+func (r *DomainResolver) startLazily() {
+	if r.lazyStart && !r.isShutdown() {
+		r.startOnce.Do(r.StartResolver)
+	}
+}
+
+// UnaryClientInterceptor returns an interceptor starting the resolvers
+// built with WithLazyStart for the ClientConn before its first call, the
+// ClientConn target has to be in the scheme://authority/endpoint format
+func (b *DomainResolverBuilder) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	b.m.Lock()
+	b.intercepted = true
+	b.m.Unlock()
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		b.startPending(cc.Target())
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns an interceptor starting the resolvers
+// built with WithLazyStart, see UnaryClientInterceptor
+func (b *DomainResolverBuilder) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	b.m.Lock()
+	b.intercepted = true
+	b.m.Unlock()
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		b.startPending(cc.Target())
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// addPending keeps the lazily started resolver of the target, returns
+// whether the interceptors of the builder were requested to start it
+func (b *DomainResolverBuilder) addPending(target string, r *DomainResolver) bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+	if b.pending == nil {
+		b.pending = map[string][]*DomainResolver{}
+	}
+	b.pending[target] = append(b.pending[target], r)
+	return b.intercepted
+}
+
+// removePending forgets the lazily started resolver of the target, called
+// when it's closed before its first call
+func (b *DomainResolverBuilder) removePending(target string, r *DomainResolver) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	pending := b.pending[target]
+	for i, p := range pending {
+		if p == r {
+			pending = append(pending[:i], pending[i+1:]...)
+			break
+		}
+	}
+
+	if len(pending) == 0 {
+		delete(b.pending, target)
+		return
+	}
+	b.pending[target] = pending
+}
+
+// startPending starts the lazily started resolvers of the target, the
+// closed ones are skipped by startLazily
+func (b *DomainResolverBuilder) startPending(target string) {
+	b.m.Lock()
+	pending := b.pending[target]
+	delete(b.pending, target)
+	b.m.Unlock()
+
+	for _, r := range pending {
+		r.startLazily()
+	}
+}
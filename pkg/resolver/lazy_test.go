@@ -0,0 +1,90 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestLazyStart(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	rb := NewDomainResolverBuilder("test-schema", "localhost", "8080", false, nil, WithLazyStart())
+	rr, err := rb.Build(resolver.Target{Scheme: "test-schema", Endpoint: "localhost:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	defer rr.Close()
+
+	// nothing is resolved until the first call
+	_, ok := cc.LastState()
+	assert.False(t, ok)
+
+	conn, err := grpc.Dial("test-schema:///localhost:8080", grpc.WithInsecure(), grpc.WithResolvers(NewDomainResolverBuilder("test-schema", "localhost", "8080", false, nil)))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	invoked := false
+	err = rb.UnaryClientInterceptor()(context.Background(), "/svc/Method", nil, nil, conn, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		invoked = true
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, invoked)
+	st, ok := cc.LastState()
+	assert.True(t, ok)
+	assert.True(t, len(st.Addresses) > 0)
+	assert.Equal(t, 0, len(rb.pending))
+}
+
+func TestLazyStartResolveNow(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "localhost", "8080", false, nil, WithLazyStart())
+	defer r.Close()
+
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	assert.Eventually(t, func() bool {
+		_, ok := cc.LastState()
+		return ok
+	}, time.Second, 5*time.Millisecond)
+
+	// started once
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, len(cc.States()))
+}
+
+func TestLazyStartClosed(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	rb := NewDomainResolverBuilder("test-schema", "localhost", "8080", false, nil, WithLazyStart())
+	target := resolver.Target{Scheme: "test-schema", Endpoint: "localhost:8080"}
+	closed, err := rb.Build(target, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	open, err := rb.Build(target, resolvertest.NewClientConn(), resolver.BuildOptions{})
+	assert.Nil(t, err)
+	defer open.Close()
+
+	// the closed resolver is no longer pending
+	closed.Close()
+	closed.Close()
+	assert.Equal(t, []*DomainResolver{open.(*DomainResolver)}, rb.pending["test-schema:///localhost:8080"])
+	open.Close()
+	assert.Equal(t, 0, len(rb.pending))
+
+	// nor started by a late call
+	closed.ResolveNow(resolver.ResolveNowOptions{})
+	rb.addPending("test-schema:///localhost:8080", closed.(*DomainResolver))
+	rb.startPending("test-schema:///localhost:8080")
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, len(cc.States()))
+}
+
+func TestLazyStartIntercepted(t *testing.T) {
+	rb := NewDomainResolverBuilder("test-schema", "localhost", "8080", false, nil, WithLazyStart())
+	assert.False(t, rb.addPending("test-schema:///localhost:8080", &DomainResolver{}))
+
+	rb.UnaryClientInterceptor()
+	assert.True(t, rb.addPending("test-schema:///localhost:8080", &DomainResolver{}))
+}
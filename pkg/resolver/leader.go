@@ -0,0 +1,68 @@
+package resolver
+
+import (
+	"log"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// DefaultLeaderInterval is how often the leadership is checked if no
+// interval is given to WithLeaderGate
+const DefaultLeaderInterval = time.Second
+
+// isLeader returns true if the publication is not gated or the process
+// is the leader
+func (r *DomainResolver) isLeader() bool {
+	return r.leaderCheck == nil || r.leaderCheck()
+}
+
+// gate returns true if the publication has to be held back because the
+// process is not the leader or the group is not complete, the state is
+// kept to be published as soon as it can be
+func (r *DomainResolver) gate(st resolver.State) bool {
+	if r.leaderCheck == nil && r.group == nil {
+		return false
+	}
+
+	// the state is held before asking the group, so it's found if the
+	// group becomes complete meanwhile
+	r.m.Lock()
+	r.heldState = &st
+	r.m.Unlock()
+
+	held := r.leaderCheck != nil && !r.leaderCheck()
+	if !held && r.group != nil {
+		held = !r.group.ready(r, st)
+	}
+
+	if !held {
+		r.m.Lock()
+		r.heldState = nil
+		r.m.Unlock()
+	}
+
+	return held
+}
+
+// watchLeadership publishes the held state once the process becomes the
+// leader, the webhook only sends the changes made after that
+func (r *DomainResolver) watchLeadership() {
+	ticker := time.NewTicker(r.leaderInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if r.isShutdown() {
+			return
+		}
+
+		r.m.Lock()
+		held := r.heldState
+		r.m.Unlock()
+		if held == nil || !r.leaderCheck() {
+			continue
+		}
+
+		log.Println("[grpc-resolver]: leadership acquired, publishing the addresses of ", r.address)
+		r.publish(*held)
+	}
+}
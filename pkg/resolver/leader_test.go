@@ -0,0 +1,38 @@
+package resolver
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderGate(t *testing.T) {
+	var leader, published int32
+	r := NewResolver("127.0.0.1", "8080", false, &refreshRate, nil,
+		WithLeaderGate(func() bool { return atomic.LoadInt32(&leader) == 1 }, 10*time.Millisecond),
+		WithPublishers(PublisherFunc(func(s Snapshot) error {
+			atomic.AddInt32(&published, 1)
+			return nil
+		})))
+	defer r.Close()
+
+	// the standby holds the state back
+	r.StartResolver()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&published))
+	assert.False(t, r.isLeader())
+
+	// and publishes it once it becomes the leader
+	atomic.StoreInt32(&leader, 1)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&published) == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&published))
+}
+
+func TestWithLeaderGate(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithLeaderGate(func() bool { return true }, 0))
+	assert.Equal(t, DefaultLeaderInterval, r.leaderInterval)
+	assert.True(t, r.isLeader())
+}
@@ -0,0 +1,126 @@
+package resolver
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// EventWarning is emitted when a soft limit is exceeded, see SetLimits
+const EventWarning = "warning"
+
+// Limits are soft limits of the resolvers of the process, exceeding one
+// logs a warning and emits a warning event on the resolver that crossed
+// it, nothing fails, useful to spot the applications leaking resolvers
+// through repeated dialing, zero means no limit
+type Limits struct {
+	Resolvers int     // resolvers with a running watcher
+	Addresses int     // addresses of the resolvers with a running watcher
+	QPS       float64 // lookups per second of every resolver over the last minute
+}
+
+// Usage is the current usage of the resolvers of the process
+type Usage struct {
+	Resolvers int
+	Addresses int
+	QPS       float64
+}
+
+// usageWindow is the window the lookups per second are computed over
+const usageWindow = time.Minute
+
+var limits = struct {
+	sync.Mutex
+	Limits
+	lookups  []time.Time     // lookups in the usage window
+	exceeded map[string]bool // limits exceeded, warned once until back under them
+}{exceeded: map[string]bool{}}
+
+// SetLimits sets the soft limits of the resolvers of the process
+func SetLimits(l Limits) {
+	limits.Lock()
+	defer limits.Unlock()
+	limits.Limits = l
+	limits.exceeded = map[string]bool{}
+}
+
+// CurrentUsage returns the usage the soft limits are compared to
+func CurrentUsage() Usage {
+	registry.Lock()
+	u := Usage{Resolvers: len(registry.watchers)}
+	for _, n := range registry.addresses {
+		u.Addresses += n
+	}
+	registry.Unlock()
+
+	limits.Lock()
+	defer limits.Unlock()
+	u.QPS = qps(time.Now())
+	return u
+}
+
+// qps prunes the lookups out of the window and returns the lookups per
+// second, expected to be called with the limits lock held
+func qps(now time.Time) float64 {
+	i := 0
+	for i < len(limits.lookups) && now.Sub(limits.lookups[i]) > usageWindow {
+		i++
+	}
+	limits.lookups = limits.lookups[i:]
+
+	return float64(len(limits.lookups)) / usageWindow.Seconds()
+}
+
+// recordUsage records the lookups and the address count of the event and
+// emits the warnings of the limits it made exceed, the lookups out of the
+// window are pruned here too, CurrentUsage may never be called
+func (r *DomainResolver) recordUsage(e Event) {
+	switch e.Type {
+	case EventResolution:
+		limits.Lock()
+		qps(e.Time)
+		limits.lookups = append(limits.lookups, e.Time)
+		limits.Unlock()
+	case EventChange:
+		registry.Lock()
+		if _, ok := registry.addresses[r]; ok {
+			registry.addresses[r] = len(e.Addresses)
+		}
+		registry.Unlock()
+	default:
+		return
+	}
+
+	r.checkLimits()
+}
+
+// checkLimits emits a warning for every limit exceeded since the last check
+func (r *DomainResolver) checkLimits() {
+	limits.Lock()
+	set := limits.Limits
+	limits.Unlock()
+	if set == (Limits{}) {
+		return
+	}
+
+	u := CurrentUsage()
+	r.warnIf("resolvers", set.Resolvers > 0 && u.Resolvers > set.Resolvers, fmt.Sprintf("%d resolvers exceed the limit of %d", u.Resolvers, set.Resolvers))
+	r.warnIf("addresses", set.Addresses > 0 && u.Addresses > set.Addresses, fmt.Sprintf("%d addresses exceed the limit of %d", u.Addresses, set.Addresses))
+	r.warnIf("qps", set.QPS > 0 && u.QPS > set.QPS, fmt.Sprintf("%.2f lookups per second exceed the limit of %.2f", u.QPS, set.QPS))
+}
+
+// warnIf emits the warning the first time the limit is exceeded
+func (r *DomainResolver) warnIf(limit string, exceeded bool, msg string) {
+	limits.Lock()
+	warn := exceeded && !limits.exceeded[limit]
+	limits.exceeded[limit] = exceeded
+	limits.Unlock()
+
+	if !warn {
+		return
+	}
+
+	log.Printf("[grpc-resolver]: soft limit exceeded, %s, last resolver %s%s", msg, r.address, r.labelsString())
+	r.emit(Event{Type: EventWarning, Time: time.Now(), Error: msg})
+}
@@ -0,0 +1,72 @@
+package resolver
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	// the watchers left running by other tests count too
+	running := CurrentUsage().Resolvers
+	SetLimits(Limits{Resolvers: running + 1})
+	defer SetLimits(Limits{})
+
+	rate := time.Duration(3600)
+	first := NewResolver("localhost", "8080", true, &rate, nil)
+	first.StartResolver()
+	defer first.Close()
+
+	// the second watcher exceeds the limit
+	log := &bytes.Buffer{}
+	second := NewResolver("localhost", "8080", true, &rate, nil, WithEventLog(log))
+	second.StartResolver()
+	defer second.Close()
+
+	u := CurrentUsage()
+	assert.True(t, u.Resolvers >= running+2)
+	assert.True(t, u.Addresses >= 2)
+	assert.True(t, u.QPS > 0)
+
+	warnings := []Event{}
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		e, err := UnmarshalEvent([]byte(line))
+		assert.Nil(t, err)
+		if e.Type == EventWarning {
+			warnings = append(warnings, e)
+		}
+	}
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0].Error, fmt.Sprintf("resolvers exceed the limit of %d", running+1))
+
+	// the warning is only emitted once
+	limits.Lock()
+	assert.True(t, limits.exceeded["resolvers"])
+	limits.Unlock()
+}
+
+func TestRecordUsagePrunes(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	old := time.Now().Add(-2 * usageWindow)
+
+	limits.Lock()
+	saved := limits.lookups
+	limits.lookups = []time.Time{old, old, old}
+	limits.Unlock()
+	defer func() {
+		limits.Lock()
+		limits.lookups = saved
+		limits.Unlock()
+	}()
+
+	// no limit is set and CurrentUsage isn't called, the old lookups go anyway
+	r.recordUsage(Event{Type: EventResolution, Time: time.Now()})
+	limits.Lock()
+	assert.Len(t, limits.lookups, 1)
+	limits.Unlock()
+}
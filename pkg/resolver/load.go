@@ -0,0 +1,29 @@
+package resolver
+
+import (
+	"google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/resolver"
+)
+
+// LoadReporter returns the weight of an address based on its load or
+// utilization (e.g. taken from Consul metadata), the higher the weight
+// the more traffic the address should receive, 0 means unknown
+type LoadReporter func(addr string) uint32
+
+// attachLoad attaches the weight given by the load reporter to the
+// addresses using the weightedroundrobin attribute, it's only read by the
+// balancers looking for it (e.g. a custom weighted balancer), the same
+// weight keeps the SubConn of the address, see reuseAddresses
+func (r *DomainResolver) attachLoad(addrs []resolver.Address) []resolver.Address {
+	if r.loadReporter == nil {
+		return addrs
+	}
+
+	for i, a := range addrs {
+		if w := r.loadReporter(a.Addr); w > 0 {
+			addrs[i] = weightedroundrobin.SetAddrInfo(a, weightedroundrobin.AddrInfo{Weight: w})
+		}
+	}
+
+	return addrs
+}
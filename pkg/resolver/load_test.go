@@ -0,0 +1,32 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestAttachLoad(t *testing.T) {
+	addrs := []resolver.Address{{Addr: "127.0.0.1:8080"}, {Addr: "127.0.0.2:8080"}}
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, addrs, r.attachLoad(addrs))
+
+	weights := map[string]uint32{"127.0.0.1:8080": 10}
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithLoadReporter(func(addr string) uint32 { return weights[addr] }))
+	addrs = r.attachLoad(addrs)
+	assert.Equal(t, uint32(10), weightedroundrobin.GetAddrInfo(addrs[0]).Weight)
+	assert.Nil(t, addrs[1].Attributes)
+}
+
+func TestLoadKeepsSubConns(t *testing.T) {
+	weights := map[string]uint32{"10.0.0.1:8080": 1, "10.0.0.2:8080": 2, "10.0.0.3:8080": 3}
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "churn.test", "8080", false, nil, WithHosts(map[string][]string{"churn.test": nil}, true),
+		WithLoadReporter(func(addr string) uint32 { return weights[addr] }))
+	defer r.Close()
+
+	assertSubConnsKept(t, r, cc, []string{"10.0.0.1"}, []string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"})
+}
@@ -0,0 +1,80 @@
+package resolver
+
+import (
+	"google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/resolver"
+)
+
+// Locality is where an address runs, with the fields of the xDS locality
+type Locality struct {
+	Region  string `json:"region,omitempty"`
+	Zone    string `json:"zone,omitempty"`
+	SubZone string `json:"sub_zone,omitempty"`
+}
+
+// LocalityReporter returns the locality of an address, e.g. taken from
+// Consul metadata or a subnet per zone convention, false if unknown
+type LocalityReporter func(addr string) (Locality, bool)
+
+// localityInfo is the locality attached to an address and the
+// aggregated weight of the locality in the published state
+type localityInfo struct {
+	locality Locality
+	weight   uint32
+}
+
+type localityKey struct{}
+
+// LocalityFrom returns the locality attached to the address and the
+// weight of the locality, the sum of the weights of its addresses, false
+// if the address has no locality
+func LocalityFrom(addr resolver.Address) (Locality, uint32, bool) {
+	info, ok := addr.Attributes.Value(localityKey{}).(localityInfo)
+	return info.locality, info.weight, ok
+}
+
+// LocalityWeights returns the weight of every locality of the addresses,
+// the weights of the localities balancers expect in xDS
+func LocalityWeights(addrs []resolver.Address) map[Locality]uint32 {
+	weights := map[Locality]uint32{}
+	for _, a := range addrs {
+		if l, w, ok := LocalityFrom(a); ok {
+			weights[l] = w
+		}
+	}
+
+	return weights
+}
+
+// attachLocality attaches the locality given by the reporter to the
+// addresses with the weight of the locality, every address weighs its
+// weightedroundrobin weight or 1 if it has none
+func (r *DomainResolver) attachLocality(addrs []resolver.Address) []resolver.Address {
+	if r.localityReporter == nil {
+		return addrs
+	}
+
+	localities := make([]Locality, len(addrs))
+	known := make([]bool, len(addrs))
+	weights := map[Locality]uint32{}
+	for i, a := range addrs {
+		localities[i], known[i] = r.localityReporter(a.Addr)
+		if !known[i] {
+			continue
+		}
+
+		w := uint32(1)
+		if info := weightedroundrobin.GetAddrInfo(a); info.Weight > 0 {
+			w = info.Weight
+		}
+		weights[localities[i]] += w
+	}
+
+	for i, a := range addrs {
+		if known[i] {
+			addrs[i].Attributes = a.Attributes.WithValues(localityKey{}, localityInfo{localities[i], weights[localities[i]]})
+		}
+	}
+
+	return addrs
+}
@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestAttachLocality(t *testing.T) {
+	addrs := []resolver.Address{{Addr: "10.0.1.1:8080"}, {Addr: "10.0.1.2:8080"}, {Addr: "10.0.2.1:8080"}, {Addr: "10.0.3.1:8080"}}
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, addrs, r.attachLocality(addrs))
+
+	zones := map[string]Locality{
+		"10.0.1.1:8080": {Region: "eu", Zone: "eu-1"},
+		"10.0.1.2:8080": {Region: "eu", Zone: "eu-1"},
+		"10.0.2.1:8080": {Region: "eu", Zone: "eu-2"},
+	}
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithLocality(func(addr string) (Locality, bool) {
+		l, ok := zones[addr]
+		return l, ok
+	}))
+	addrs[2] = weightedroundrobin.SetAddrInfo(addrs[2], weightedroundrobin.AddrInfo{Weight: 5})
+	addrs = r.attachLocality(addrs)
+
+	l, w, ok := LocalityFrom(addrs[0])
+	assert.True(t, ok)
+	assert.Equal(t, Locality{Region: "eu", Zone: "eu-1"}, l)
+	assert.Equal(t, uint32(2), w)
+	assert.Equal(t, uint32(5), weightedroundrobin.GetAddrInfo(addrs[2]).Weight)
+	_, _, ok = LocalityFrom(addrs[3])
+	assert.False(t, ok)
+
+	assert.Equal(t, map[Locality]uint32{
+		{Region: "eu", Zone: "eu-1"}: 2,
+		{Region: "eu", Zone: "eu-2"}: 5,
+	}, LocalityWeights(addrs))
+}
+
+func TestLocalityStaticAndBootstrap(t *testing.T) {
+	eu := func(addr string) (Locality, bool) { return Locality{Region: "eu"}, true }
+
+	// IP target
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "127.0.0.1", "8080", false, nil, WithLocality(eu))
+	r.StartResolver()
+	st, _ := cc.LastState()
+	l, w, ok := LocalityFrom(st.Addresses[0])
+	assert.True(t, ok)
+	assert.Equal(t, Locality{Region: "eu"}, l)
+	assert.Equal(t, uint32(1), w)
+
+	// bootstrap addresses
+	cc = resolvertest.NewClientConn()
+	r = NewGRPCResolver(cc, "unknown.invalid", "8080", false, nil, WithLocality(eu), WithBootstrapAddresses([]string{"10.0.0.1:8080", "10.0.0.2:8080"}))
+	r.StartResolver()
+	defer r.Close()
+	st = cc.States()[0]
+	assert.Equal(t, map[Locality]uint32{{Region: "eu"}: 2}, LocalityWeights(st.Addresses))
+}
+
+func TestLocalityKeepsSubConns(t *testing.T) {
+	zones := map[string]string{"10.0.0.1:8080": "a", "10.0.0.2:8080": "b", "10.0.0.3:8080": "c"}
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "churn.test", "8080", false, nil, WithHosts(map[string][]string{"churn.test": nil}, true),
+		WithLocality(func(addr string) (Locality, bool) { return Locality{Zone: zones[addr]}, true }))
+	defer r.Close()
+
+	// the weight of the locality of 10.0.0.1 doesn't change, it keeps its SubConn
+	assertSubConnsKept(t, r, cc, []string{"10.0.0.1"}, []string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"})
+}
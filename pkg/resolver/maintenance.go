@@ -0,0 +1,191 @@
+package resolver
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"google.golang.org/grpc/resolver"
+)
+
+// MaintenanceMode is what a maintenance window holds back
+type MaintenanceMode int
+
+const (
+	// MaintenanceKeepRemoved keeps publishing the removed addresses
+	// during the window, the additions are still published
+	MaintenanceKeepRemoved MaintenanceMode = iota
+	// MaintenanceFreeze publishes nothing during the window, the
+	// changes are published on the first refresh after it
+	MaintenanceFreeze
+)
+
+// maxMaintenance is the longest window supported, the start
+// is looked for minute by minute before the current time
+const maxMaintenance = 7 * 24 * time.Hour
+
+// maintenanceWindow starts at every minute matching the schedule and
+// lasts the duration
+type maintenanceWindow struct {
+	schedule *cronSchedule
+	duration time.Duration
+	mode     MaintenanceMode
+}
+
+// newMaintenanceWindow returns the window, false if the schedule is
+// invalid, the duration is capped to maxMaintenance
+func newMaintenanceWindow(schedule string, duration time.Duration, mode MaintenanceMode) (maintenanceWindow, bool) {
+	cron, err := parseCron(schedule)
+	if err != nil {
+		log.Println("[grpc-resolver]: ignoring maintenance window ", err)
+		return maintenanceWindow{}, false
+	}
+	if duration > maxMaintenance {
+		duration = maxMaintenance
+	}
+
+	return maintenanceWindow{schedule: cron, duration: duration, mode: mode}, true
+}
+
+// active returns whether a start of the window happened in (t-duration, t]
+func (w maintenanceWindow) active(t time.Time) bool {
+	start := t.Truncate(time.Minute)
+	for s := start; t.Sub(s) < w.duration; s = s.Add(-time.Minute) {
+		if w.schedule.matches(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maintenance returns whether the resolver is in a maintenance window
+// and if any of the active windows freezes the publication
+func (r *DomainResolver) maintenance() (active, frozen bool) {
+	now := r.now()
+	for _, w := range r.maintenanceWindows {
+		if w.active(now) {
+			active = true
+			frozen = frozen || w.mode == MaintenanceFreeze
+		}
+	}
+
+	return active, frozen
+}
+
+// keepRemoved adds back the addresses removed from the current list as
+// they were resolved, weights, locality and transport included, expected
+// to be called with the lock held
+func (r *DomainResolver) keepRemoved(addrs []resolver.Address, addrstr []string) ([]resolver.Address, []string) {
+	_, removed := list.DiffListStr(r.Addresses, addrstr)
+	if len(removed) == 0 {
+		return addrs, addrstr
+	}
+
+	for _, a := range removed {
+		addrs = append(addrs, r.resolvedAddress(a))
+		addrstr = append(addrstr, a)
+	}
+
+	return addrs, addrstr
+}
+
+// cronSchedule is a standard 5 fields cron expression: minute, hour,
+// day of month, month and day of week
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]bool
+	anyDom, anyDow                bool
+}
+
+// parseCron parses the expression, the fields accept *, values,
+// ranges, lists and steps, e.g. "0 2 * * 1-5" or "*/15 0-6 * * *"
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in %q", expr)
+	}
+
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := make([]map[int]bool, 5)
+	for i, f := range fields {
+		set, err := parseCronField(f, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q in %q: %v", f, expr, err)
+		}
+		sets[i] = set
+	}
+
+	// 7 is sunday too
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &cronSchedule{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		anyDom: fields[2] == "*", anyDow: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			step, part = s, part[:i]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
+
+// matches returns whether the minute of t matches the schedule, as in
+// cron when both days are restricted either of them matches
+func (c *cronSchedule) matches(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+
+	dom, dow := c.dom[t.Day()], c.dow[int(t.Weekday())]
+	switch {
+	case c.anyDom && c.anyDow:
+		return true
+	case c.anyDom:
+		return dow
+	case c.anyDow:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,85 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestParseCron(t *testing.T) {
+	c, err := parseCron("*/15 2-3 * * 1,7")
+	assert.Nil(t, err)
+	monday := time.Date(2020, 1, 6, 2, 30, 0, 0, time.UTC)
+	assert.True(t, c.matches(monday))
+	assert.True(t, c.matches(monday.AddDate(0, 0, 6))) // sunday
+	assert.False(t, c.matches(monday.AddDate(0, 0, 1)))
+	assert.False(t, c.matches(monday.Add(time.Minute)))
+	assert.False(t, c.matches(monday.Add(2*time.Hour)))
+
+	c, err = parseCron("0 0 1 * 1")
+	assert.Nil(t, err)
+	assert.True(t, c.matches(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC))) // 1st, saturday
+	assert.True(t, c.matches(time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC))) // monday
+	assert.False(t, c.matches(time.Date(2020, 2, 4, 0, 0, 0, 0, time.UTC)))
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := parseCron(expr)
+		assert.NotNil(t, err, expr)
+	}
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	start := time.Date(2020, 1, 1, 1, 50, 0, 0, time.UTC)
+	answers := []SimulatedAnswer{
+		{Time: start, IPs: []string{"10.0.0.1", "10.0.0.2"}},
+		{Time: start.Add(20 * time.Minute), IPs: []string{"10.0.0.1", "10.0.0.3"}}, // 2:10, in the window
+		{Time: start.Add(30 * time.Minute)},                                        // 2:20, lookup failure
+		{Time: start.Add(time.Hour), IPs: []string{"10.0.0.1", "10.0.0.3"}},        // 2:50, after the window
+	}
+
+	published := Simulate("my-service", "8080", answers, WithMaintenanceWindow("0 2 * * *", 30*time.Minute, MaintenanceKeepRemoved), WithAddressTTL(time.Minute))
+	assert.Equal(t, []SimulatedPublication{
+		{Time: start, Version: 1, Addresses: []string{"10.0.0.1:8080", "10.0.0.2:8080"}},
+		{Time: start.Add(20 * time.Minute), Version: 2, Addresses: []string{"10.0.0.1:8080", "10.0.0.3:8080", "10.0.0.2:8080"}},
+		{Time: start.Add(time.Hour), Version: 3, Addresses: []string{"10.0.0.1:8080", "10.0.0.3:8080"}},
+	}, published)
+
+	published = Simulate("my-service", "8080", answers, WithMaintenanceWindow("0 2 * * *", 30*time.Minute, MaintenanceFreeze))
+	assert.Equal(t, []SimulatedPublication{
+		{Time: start, Version: 1, Addresses: []string{"10.0.0.1:8080", "10.0.0.2:8080"}},
+		{Time: start.Add(time.Hour), Version: 2, Addresses: []string{"10.0.0.1:8080", "10.0.0.3:8080"}},
+	}, published)
+
+	r := NewResolver("my-service", "8080", false, nil, nil, WithMaintenanceWindow("invalid", time.Hour, MaintenanceFreeze), WithMaintenanceWindow("* * * * *", 30*24*time.Hour, MaintenanceFreeze))
+	assert.Equal(t, 1, len(r.maintenanceWindows))
+	assert.Equal(t, maxMaintenance, r.maintenanceWindows[0].duration)
+}
+
+func TestKeepRemovedAttributes(t *testing.T) {
+	start := time.Date(2020, 1, 1, 1, 50, 0, 0, time.UTC)
+	answers := []SimulatedAnswer{
+		{Time: start, IPs: []string{"10.0.0.1", "10.0.0.2"}},
+		{Time: start.Add(20 * time.Minute), IPs: []string{"10.0.0.1", "10.0.0.3"}}, // 2:10, in the window
+	}
+
+	var last resolver.State
+	capture := PublisherFunc(func(s Snapshot) error {
+		last = s.State
+		return nil
+	})
+	zone := func(addr string) (Locality, bool) { return Locality{Zone: "eu-1"}, true }
+	Simulate("my-service", "8080", answers, WithMaintenanceWindow("0 2 * * *", 30*time.Minute, MaintenanceKeepRemoved),
+		WithLoadReporter(func(string) uint32 { return 5 }), WithLocality(zone), WithPublishers(capture))
+
+	// the kept address is published as it was resolved
+	assert.Equal(t, 3, len(last.Addresses))
+	kept := last.Addresses[2]
+	assert.Equal(t, "10.0.0.2:8080", kept.Addr)
+	assert.Equal(t, uint32(5), weightedroundrobin.GetAddrInfo(kept).Weight)
+	l, _, ok := LocalityFrom(kept)
+	assert.True(t, ok)
+	assert.Equal(t, "eu-1", l.Zone)
+}
@@ -0,0 +1,38 @@
+package resolver
+
+import "time"
+
+// Metrics receives the measurements of a resolver, the methods mirror the
+// OpenTelemetry instruments (a histogram, a counter and a gauge) so an
+// adapter on top of a metric.Meter takes a few lines, the info is meant
+// to become the attributes of the measurements
+type Metrics interface {
+	// LookupDuration records the duration of a resolution attempt
+	LookupDuration(info Info, d time.Duration, failed bool)
+	// AddChanges adds the address list changes
+	AddChanges(info Info, n int64)
+	// SetAddresses sets the current number of addresses
+	SetAddresses(info Info, n int64)
+}
+
+// recordMetrics sends the measurements of the event to the metrics
+func (r *DomainResolver) recordMetrics(e Event) {
+	if r.metrics == nil {
+		return
+	}
+
+	info := Info{Target: e.Target, Labels: e.Labels}
+	switch e.Type {
+	case EventResolution:
+		r.metrics.LookupDuration(info, time.Duration(e.DurationMs*float64(time.Millisecond)), e.Error != "")
+		if e.Error != "" {
+			return
+		}
+	case EventChange:
+		r.metrics.AddChanges(info, 1)
+	default:
+		return
+	}
+
+	r.metrics.SetAddresses(info, int64(len(e.Addresses)))
+}
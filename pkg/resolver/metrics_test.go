@@ -0,0 +1,61 @@
+package resolver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMetrics struct {
+	m         sync.Mutex
+	lookups   int
+	failed    int
+	changes   int64
+	addresses int64
+	info      Info
+}
+
+func (t *testMetrics) LookupDuration(info Info, d time.Duration, failed bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.lookups++
+	if failed {
+		t.failed++
+	}
+	t.info = info
+}
+
+func (t *testMetrics) AddChanges(info Info, n int64) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.changes += n
+}
+
+func (t *testMetrics) SetAddresses(info Info, n int64) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.addresses = n
+}
+
+func TestWithMetrics(t *testing.T) {
+	m := &testMetrics{}
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithMetrics(m), WithLabels(map[string]string{"team": "payments"}))
+	r.StartResolver()
+	defer r.Close()
+
+	r.m.Lock()
+	r.Addresses = append(r.Addresses, "127.0.0.9:8080")
+	e := r.recordChange([]string{"127.0.0.9:8080"}, nil)
+	r.m.Unlock()
+	r.announceChange(e)
+
+	m.m.Lock()
+	defer m.m.Unlock()
+	assert.Equal(t, 1, m.lookups)
+	assert.Equal(t, 0, m.failed)
+	assert.Equal(t, int64(1), m.changes)
+	assert.Equal(t, int64(len(r.Addresses)), m.addresses)
+	assert.Equal(t, Info{Target: "localhost", Labels: map[string]string{"team": "payments"}}, m.info)
+}
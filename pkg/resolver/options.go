@@ -0,0 +1,665 @@
+package resolver
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Option configures optional behaviour of the DomainResolver,
+// options are applied in order right after the default values are set
+type Option func(*DomainResolver)
+
+// WithReverseLookup enables PTR lookups for every resolved IP, the
+// hostnames are attached to the gRPC addresses as attributes and
+// can be read by standalone users through the Hostnames method
+func WithReverseLookup() Option {
+	return func(r *DomainResolver) {
+		r.reverseLookup = true
+	}
+}
+
+// WithCNAMETracking looks up the canonical name of the domain on every
+// refresh and logs when it changes (e.g. blue/green DNS switch), if
+// forcePublish is true the state is published on a canonical name
+// change even if the resolved IPs are the same
+func WithCNAMETracking(forcePublish bool) Option {
+	return func(r *DomainResolver) {
+		r.trackCNAME = true
+		r.forcePublishOnCNAME = forcePublish
+	}
+}
+
+// WithFQDN treats the domain as fully qualified appending a trailing
+// dot if missing, so the host search domains are never applied
+func WithFQDN() Option {
+	return func(r *DomainResolver) {
+		r.fqdn = true
+		r.searchDomains = nil
+	}
+}
+
+// WithSearchDomains expands short names against the given search list
+// instead of the host search domains, each candidate is looked up in
+// order and the first one returning records wins, the name as given is
+// used as the last candidate
+func WithSearchDomains(domains ...string) Option {
+	return func(r *DomainResolver) {
+		r.fqdn = false
+		r.searchDomains = domains
+	}
+}
+
+// WithHosts consults the given hosts-style map (name to IPs) before
+// DNS, if only is true DNS is never queried for the domain
+func WithHosts(hosts map[string][]string, only bool) Option {
+	return func(r *DomainResolver) {
+		r.hosts = map[string][]string{}
+		for name, ips := range hosts {
+			name = strings.ToLower(strings.TrimSuffix(name, "."))
+			r.hosts[name] = append(r.hosts[name], ips...)
+		}
+		r.hostsOnly = only
+	}
+}
+
+// WithHostsFile is like WithHosts but reads a file in the /etc/hosts
+// format on every lookup, so the pinned endpoints can be changed
+// without restarting the process
+func WithHostsFile(path string, only bool) Option {
+	return func(r *DomainResolver) {
+		r.hostsFile = path
+		r.hostsOnly = only
+	}
+}
+
+// WithPassthrough publishes the domain and port untouched and disables
+// the IP lookup, meant for environments where the egress goes through
+// a proxy that does its own name resolution
+func WithPassthrough() Option {
+	return func(r *DomainResolver) {
+		r.passthrough = true
+	}
+}
+
+// WithChurnThreshold logs a warning and emits a warning event every time
+// the number of address list changes in the last hour exceeds the given
+// threshold
+func WithChurnThreshold(changesPerHour int) Option {
+	return func(r *DomainResolver) {
+		r.churnThreshold = changesPerHour
+	}
+}
+
+// WithDNSServer sends the DNS queries to the given server instead of the
+// host configured ones, the port 53 is used if the address has no port
+func WithDNSServer(server string) Option {
+	return func(r *DomainResolver) {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+
+		r.dnsServer = server
+	}
+}
+
+// WithForceTCP sends the DNS queries over TCP, useful when the answers
+// routinely exceed the UDP size, note the Go resolver already retries
+// over TCP on truncated answers but pays an extra round trip for it
+func WithForceTCP() Option {
+	return func(r *DomainResolver) {
+		r.forceTCP = true
+	}
+}
+
+// WithGoResolver uses the pure Go resolver for this resolver only, by
+// default the process wide choice applies, which goes through cgo when
+// nsswitch needs it (LDAP, mDNS...), forcing cgo per resolver is not
+// supported by the net package, only process wide with GODEBUG=netdns=cgo
+func WithGoResolver() Option {
+	return func(r *DomainResolver) {
+		r.preferGo = true
+	}
+}
+
+// WithAuthoritative sends the DNS queries straight to the name servers
+// of the target zone bypassing the recursive caches, so a record change
+// is seen as soon as it's published instead of after the cached TTL, the
+// name servers are discovered once per zone and cached for 5 minutes and
+// the retries go to the next one, ignored if WithDNSServer is set
+func WithAuthoritative() Option {
+	return func(r *DomainResolver) {
+		r.authoritative = true
+	}
+}
+
+// WithResolvConfWatch checks the resolver configuration file every
+// interval (5 seconds if not positive), DefaultResolvConf if path is
+// empty, and refreshes the addresses when the name servers or the search
+// domains change, e.g. on VPN connect or DHCP renewal, so they don't wait
+// for the refresh rate. Platforms without resolv.conf can point it to an
+// equivalent file
+func WithResolvConfWatch(path string, interval time.Duration) Option {
+	return func(r *DomainResolver) {
+		if path == "" {
+			path = DefaultResolvConf
+		}
+		if interval <= 0 {
+			interval = resolvConfRecheck
+		}
+		r.resolvConfPath = path
+		r.resolvConfInterval = interval
+	}
+}
+
+// WithEndpointsDir takes the addresses from the file named after the
+// target in the directory instead of DNS, e.g. a ConfigMap volume with
+// a key per target, the file is checked every interval,
+// DefaultEndpointsInterval if not positive, and the addresses refreshed
+// on every change, so the discovery can be driven by GitOps managed
+// config. The file has IPs or IP:port separated by commas or new lines,
+// the port of the resolver is used if missing, # starts a comment line
+func WithEndpointsDir(dir string, interval time.Duration) Option {
+	return func(r *DomainResolver) {
+		if interval <= 0 {
+			interval = DefaultEndpointsInterval
+		}
+		r.endpointsDir = dir
+		r.endpointsInterval = interval
+	}
+}
+
+// WithHedgedServers sends the queries to the first server and, if no
+// answer arrived after the delay or the lookup failed, to the next one,
+// the first answer with records wins, reducing the tail latency when a
+// server is slow or drops UDP. The port 53 is used if a server has no
+// port, it's ignored with WithViews and only applies to the IP lookups
+func WithHedgedServers(delay time.Duration, servers ...string) Option {
+	return func(r *DomainResolver) {
+		r.hedgeDelay = delay
+		r.hedgeServers = nil
+		for _, s := range servers {
+			if _, _, err := net.SplitHostPort(s); err != nil {
+				s = net.JoinHostPort(s, "53")
+			}
+			r.hedgeServers = append(r.hedgeServers, s)
+		}
+	}
+}
+
+// WithViews queries the domain against every given DNS server, e.g. the
+// internal and the external views of a split horizon setup, the results
+// are combined according to the mode, the port 53 is used if a server has no port
+func WithViews(mode ViewMode, servers ...string) Option {
+	return func(r *DomainResolver) {
+		r.viewMode = mode
+		r.viewServers = nil
+		for _, s := range servers {
+			if _, _, err := net.SplitHostPort(s); err != nil {
+				s = net.JoinHostPort(s, "53")
+			}
+			r.viewServers = append(r.viewServers, s)
+		}
+	}
+}
+
+// WithStableOrder preserves the order of the addresses that remain after
+// a refresh and appends the new ones at the end, instead of publishing the
+// order returned by DNS, minimizing reconnections with pick_first
+func WithStableOrder() Option {
+	return func(r *DomainResolver) {
+		r.stableOrder = true
+	}
+}
+
+// WithCatchUpRefresh checks the wall clock every second and refreshes
+// right away when it moved more than the refresh rate since the last
+// check, so the clients don't run stale after a sleep or a freeze
+func WithCatchUpRefresh() Option {
+	return func(r *DomainResolver) {
+		r.catchUpRefresh = true
+	}
+}
+
+// WithPublishers feeds the published address lists to the given publishers
+func WithPublishers(publishers ...Publisher) Option {
+	return func(r *DomainResolver) {
+		r.publishers = append(r.publishers, publishers...)
+		atomic.AddInt32(&r.consumers, int32(len(publishers)))
+	}
+}
+
+// WithBootstrapAddresses publishes the given host:port addresses right
+// away on start instead of waiting for the first resolution, which is
+// done in background and replaces them once it returns records
+func WithBootstrapAddresses(addrs []string) Option {
+	return func(r *DomainResolver) {
+		r.bootstrap = append([]string{}, addrs...)
+	}
+}
+
+// WithConnectFeedback enables ReportConnectResult, the addresses failing
+// to connect are published last and quarantined for the given duration
+// after the given number of consecutive failures
+func WithConnectFeedback(failures int, quarantine time.Duration) Option {
+	return func(r *DomainResolver) {
+		r.quarantineAfter = failures
+		r.quarantineFor = quarantine
+		r.failures = map[string]int{}
+		r.quarantined = map[string]time.Time{}
+	}
+}
+
+// WithExpvar publishes the counters of the target (lookups, errors,
+// changes and current number of addresses) through expvar, under the
+// given prefix map keyed by the target, e.g. /debug/vars
+func WithExpvar(prefix string) Option {
+	return func(r *DomainResolver) {
+		r.stats = newExpvarStats(prefix, r.address)
+	}
+}
+
+// WithSpreadRefresh delays the first refresh of the watcher by an offset
+// within the refresh interval derived from the target, so the processes
+// watching hundreds of targets at the same interval spread the DNS
+// queries and the CPU load evenly across the window instead of firing
+// all the refreshes at once
+func WithSpreadRefresh() Option {
+	return func(r *DomainResolver) {
+		r.spreadRefresh = true
+	}
+}
+
+// WithEventReplay keeps the last n change events to replay them to the
+// channels returned by WatchEvents, so late subscribers, e.g. lazily
+// initialized routers, get the recent history besides the current state
+func WithEventReplay(n int) Option {
+	return func(r *DomainResolver) {
+		r.replaySize = n
+	}
+}
+
+// WithLabels attaches user defined labels to the resolver, e.g. the
+// team, tier or environment of the target, they are added to the events,
+// the webhook payloads, the expvar counters, the status and the logs
+func WithLabels(labels map[string]string) Option {
+	return func(r *DomainResolver) {
+		r.labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			r.labels[k] = v
+		}
+	}
+}
+
+// WithPortRules rewrites the port of the resolved addresses with the first
+// matching rule, for mesh and NAT environments where the advertised port
+// isn't the dialable one, the rules with an invalid CIDR are ignored
+func WithPortRules(rules ...PortRule) Option {
+	return func(r *DomainResolver) {
+		r.portRules = parsePortRules(rules)
+	}
+}
+
+// WithFamilyPorts publishes the IPv4 and the IPv6 addresses with their
+// own port instead of the port of the resolver, for dual-stack services
+// with a listener per family, an empty port keeps the one of the resolver,
+// the port rules take precedence
+func WithFamilyPorts(ipv4Port, ipv6Port string) Option {
+	return func(r *DomainResolver) {
+		r.ipv4Port = ipv4Port
+		r.ipv6Port = ipv6Port
+	}
+}
+
+// WithRefreshInterval sets the refresh interval of the watcher overriding
+// the refresh rate in seconds, allowing sub-second intervals down to
+// MinRefreshInterval, a warning is logged if the resulting DNS query rate
+// is high, below a second the conns to the DNS servers are kept open
+// between the refreshes instead of dialing one for every query, the
+// refresh rate can be nil when this option is used
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(r *DomainResolver) {
+		r.customInterval = interval
+	}
+}
+
+// WithPorts publishes every resolved IP, or the IP of an IP target, with
+// each of the given ports instead of the single port of the resolver, for
+// services exposing several gRPC ports, with SRV the ports come from the
+// records instead
+func WithPorts(ports ...string) Option {
+	return func(r *DomainResolver) {
+		r.ports = append([]string{}, ports...)
+	}
+}
+
+// WithServerName publishes the addresses with the given server name, gRPC
+// uses it instead of the target host for the TLS handshake of every
+// address, e.g. for virtual hosted gateways behind a shared IP, note the
+// :authority header is set per ClientConn in this gRPC version, with
+// grpc.WithAuthority
+func WithServerName(name string) Option {
+	return func(r *DomainResolver) {
+		r.serverName = name
+	}
+}
+
+// WithDryRun makes the resolver perform lookups, diffs and logging as
+// usual but never call UpdateState on the gRPC ClientConn, meant to
+// shadow test a new discovery source before switching over
+func WithDryRun() Option {
+	return func(r *DomainResolver) {
+		r.dryRun = true
+	}
+}
+
+// WithAddressTTL bounds how stale the state can become, when a refresh
+// fails the addresses not seen for longer than ttl are dropped instead
+// of being kept until the next successful lookup
+func WithAddressTTL(ttl time.Duration) Option {
+	return func(r *DomainResolver) {
+		r.addressTTL = ttl
+	}
+}
+
+// WithPanicPolicy sets what the watcher does after recovering from a
+// panic, by default it is restarted with backoff, onPanic is an
+// optional callback that receives the recovered panic as an error
+func WithPanicPolicy(policy PanicPolicy, onPanic func(error)) Option {
+	return func(r *DomainResolver) {
+		r.panicPolicy = policy
+		r.onPanic = onPanic
+	}
+}
+
+// WithContext stops the watcher when the given context is done, so
+// resolvers dropped without calling Close don't leak their goroutine,
+// its values are passed to the lookups and the hooks, see InfoFromContext
+func WithContext(ctx context.Context) Option {
+	return func(r *DomainResolver) {
+		r.ctx = ctx
+	}
+}
+
+// WithRewriter rewrites the name with the rules of the rewriter before
+// the lookups, e.g. service-a to service-a.prod.internal.example.com, the
+// rules can be changed at runtime through the rewriter
+func WithRewriter(w *Rewriter) Option {
+	return func(r *DomainResolver) {
+		r.rewriter = w
+	}
+}
+
+// WithTransportHint attaches the transport returned by the hint to every
+// published address, see TransportFrom, to be combined with
+// HintedCredentials when some backends require TLS and others don't,
+// e.g. WithTransportHint(PlaintextPorts("80"))
+func WithTransportHint(hint TransportHint) Option {
+	return func(r *DomainResolver) {
+		r.transportHint = hint
+	}
+}
+
+// WithLoadReporter attaches the weight returned by the reporter to every
+// published address with weightedroundrobin.SetAddrInfo, for the balancers
+// reading that attribute, the weights are refreshed on every state update
+// and an address whose weight changed gets a new SubConn
+func WithLoadReporter(reporter LoadReporter) Option {
+	return func(r *DomainResolver) {
+		r.loadReporter = reporter
+	}
+}
+
+// WithLocality attaches the locality returned by the reporter to every
+// published address, with the weight of the locality aggregated from the
+// weights of its addresses, see LocalityFrom and LocalityWeights, so the
+// locality weighted balancing works without a full xDS deployment
+func WithLocality(reporter LocalityReporter) Option {
+	return func(r *DomainResolver) {
+		r.localityReporter = reporter
+	}
+}
+
+// WithHostnameFallback publishes the original hostname when there are no
+// resolved IPs to publish, e.g. the first lookup failed or every address
+// expired, so gRPC can still attempt the hostname letting the OS resolver
+// try, only applicable for domains
+func WithHostnameFallback() Option {
+	return func(r *DomainResolver) {
+		r.hostnameFallback = true
+	}
+}
+
+// WithEventLog writes every resolution attempt and address list change
+// to w as JSON lines, ready to be ingested by ELK/Loki like systems
+func WithEventLog(w io.Writer) Option {
+	return func(r *DomainResolver) {
+		r.eventLog = w
+	}
+}
+
+// WithComparator replaces the default change detection, so it can be
+// tuned to avoid unnecessary gRPC subchannel churn, e.g. using
+// list.CompareHostsStr to ignore port changes
+func WithComparator(compare Comparator) Option {
+	return func(r *DomainResolver) {
+		r.compare = compare
+	}
+}
+
+// WithMaxAddresses caps the number of published addresses, taking the
+// first max addresses after sorting or, if random is true, a random
+// sample that is kept stable across refreshes, useful when DNS returns
+// hundreds of records and creating that many subchannels is wasteful
+func WithMaxAddresses(max int, random bool) Option {
+	return func(r *DomainResolver) {
+		r.maxAddresses = max
+		r.randomSample = random
+	}
+}
+
+// WithRandSource sets the source used for the random decisions, e.g. the
+// random sample of WithMaxAddresses, a seeded source makes them
+// reproducible in tests and simulations. The default is the shared
+// math/rand source, safe for concurrent use but not cryptographically
+// secure, and not seeded before Go 1.20 unless rand.Seed is called
+func WithRandSource(src rand.Source) Option {
+	return func(r *DomainResolver) {
+		r.random = &random{rnd: rand.New(src)}
+	}
+}
+
+// WithRecursionDesired sets the RD bit of the queries, set by default, the
+// servers answer the queries without it from their data or cache only, which
+// together with WithAuthoritative or WithDNSServer pointing at an
+// authoritative server avoids it recursing for names out of its zones, the
+// Go resolver is used
+func WithRecursionDesired(rd bool) Option {
+	return func(r *DomainResolver) {
+		r.noRecursion = !rd
+	}
+}
+
+// WithClientSubnet sends the subnet (CIDR notation) as EDNS Client Subnet
+// with the queries so geo-aware DNS returns endpoints near the client, the
+// scope of the answers is reported by Status, the Go resolver is used and
+// invalid subnets are ignored
+func WithClientSubnet(subnet string) Option {
+	return func(r *DomainResolver) {
+		r.clientSubnet = newClientSubnet(subnet)
+	}
+}
+
+// WithScoring attaches to every published address a score between 0 and
+// 1 combining its DNS presence, health, connection failures and age, see
+// ScoreFrom, and sets it as weight for the weighted balancers. The
+// DefaultScorer is used if scorer is nil, health can be nil too. The
+// connection failures require WithConnectFeedback
+func WithScoring(scorer Scorer, health HealthSource) Option {
+	return func(r *DomainResolver) {
+		if scorer == nil {
+			scorer = DefaultScorer
+		}
+		r.scorer = scorer
+		r.healthSource = health
+	}
+}
+
+// WithSRV resolves the domain through its SRV records (_service._proto.domain,
+// or the domain itself if service and proto are empty), the ports come from
+// the records and only the lowest priority tier is published, the optional
+// health callback allows failing over to the next tier per RFC 2782
+func WithSRV(service, proto string, health TierHealth) Option {
+	return func(r *DomainResolver) {
+		r.srv = true
+		r.srvService = service
+		r.srvProto = proto
+		r.tierHealth = health
+	}
+}
+
+// WithWebhook POSTs every address list change as a JSON event (target,
+// version, added and removed addresses) to the given URL, along with the
+// health and warning events, retrying failed requests up to retries times
+// with exponential backoff
+func WithWebhook(url string, retries int) Option {
+	return func(r *DomainResolver) {
+		r.webhook = newWebhook(url, retries)
+	}
+}
+
+// WithDeltaWebhook works like WithWebhook but only sends the added and
+// removed addresses since the base version of the event, the full list
+// is sent every fullEvery events and whenever an event couldn't be
+// delivered, reduces the payloads of very large or churny services
+func WithDeltaWebhook(url string, retries, fullEvery int) Option {
+	return func(r *DomainResolver) {
+		r.webhook = newWebhook(url, retries)
+		r.webhook.fullEvery = fullEvery
+	}
+}
+
+// WithLeaderGate publishes the addresses to gRPC, the publishers and the
+// webhook only while isLeader returns true, for active/standby processes
+// embedding the same resolver, e.g. backed by an etcd or Consul lease.
+// The lookups go on meanwhile and the last state is published as soon as
+// a check every interval, DefaultLeaderInterval if not positive, finds
+// the process is the leader
+func WithLeaderGate(isLeader func() bool, interval time.Duration) Option {
+	return func(r *DomainResolver) {
+		if interval <= 0 {
+			interval = DefaultLeaderInterval
+		}
+
+		r.leaderCheck = isLeader
+		r.leaderInterval = interval
+	}
+}
+
+// WithWarmUp invokes the hook for every address when it first appears,
+// at most concurrency at a time, DefaultWarmUpConcurrency if not
+// positive, the hooks start before the address is published but don't
+// delay the publication
+func WithWarmUp(hook WarmUpHook, concurrency int) Option {
+	return func(r *DomainResolver) {
+		r.warmUp = newWarmUp(hook, concurrency)
+	}
+}
+
+// WithGroup adds the resolver to the group, nothing is published until
+// every member of the group has at least one address, see Group
+func WithGroup(g *Group) Option {
+	return func(r *DomainResolver) {
+		r.group = g
+		g.add(r)
+	}
+}
+
+// WithLazyStart makes the builder return the resolver without starting
+// it, the first lookup is done on the first ResolveNow or the first call
+// going through the interceptors of the builder or the resolver, so
+// creating many ClientConns up front doesn't trigger a burst of lookups.
+//
+// WARNING: gRPC doesn't call ResolveNow before the resolver publishes its
+// first state, so a ClientConn without the interceptors never starts the
+// resolver and every RPC waits for addresses until its deadline, always
+// install the UnaryClientInterceptor and StreamClientInterceptor of the
+// builder (or of the resolver) with this option
+func WithLazyStart() Option {
+	return func(r *DomainResolver) {
+		r.lazyStart = true
+	}
+}
+
+// WithMetrics sends the lookup durations, the address list changes and the
+// current number of addresses to the metrics, e.g. an adapter recording
+// them with OpenTelemetry instruments, see Metrics
+func WithMetrics(m Metrics) Option {
+	return func(r *DomainResolver) {
+		r.metrics = m
+	}
+}
+
+// WithQueryLog logs a summary of every DNS query (qname, qtype, server,
+// rcode, answer count and rtt) to w, or the standard logger if w is nil,
+// to diagnose the discovery without packet captures. The Go resolver is
+// used and the log can be toggled at runtime with SetQueryLog
+func WithQueryLog(w io.Writer, enabled bool) Option {
+	return func(r *DomainResolver) {
+		r.queryLog = newQueryLog(w, enabled)
+	}
+}
+
+// WithMaintenanceWindow holds back the changes during the windows starting
+// at every minute matching the cron schedule, e.g. "0 2 * * *" for 2AM
+// every night, and lasting the duration (up to a week), so the known
+// noisy periods don't churn the connections. MaintenanceKeepRemoved keeps
+// the removed addresses published while the additions go through and
+// MaintenanceFreeze holds every change, invalid schedules are ignored
+func WithMaintenanceWindow(schedule string, duration time.Duration, mode MaintenanceMode) Option {
+	return func(r *DomainResolver) {
+		w, ok := newMaintenanceWindow(schedule, duration, mode)
+		if !ok {
+			return
+		}
+
+		r.maintenanceWindows = append(r.maintenanceWindows, w)
+	}
+}
+
+// WithErrorBudget emits a single health event every time the resolver goes
+// from healthy to degraded or stale and back, e.g. degraded when less than
+// 90% of the refreshes succeeded over 10 minutes or stale when no refresh
+// succeeded for longer than MaxStaleness, easy to wire into alerting, the
+// staleness is also checked on a timer so a resolver whose refreshes hang
+// still goes stale
+func WithErrorBudget(b ErrorBudget) Option {
+	return func(r *DomainResolver) {
+		r.budget = &budget{ErrorBudget: b}
+	}
+}
+
+// WithDialer dials the DNS servers with dial, e.g. a net.Dialer bound to the
+// interface or the VRF of the discovery network or setting SO_MARK, for the
+// multi-homed hosts where discovery and data use different networks, the
+// Go resolver is used
+func WithDialer(dial DialFunc) Option {
+	return func(r *DomainResolver) {
+		r.dialer = dial
+	}
+}
+
+// WithEDNS0BufferSize advertises size as the EDNS0 UDP buffer size of the
+// queries instead of the 1232 bytes of the Go resolver, so large answers
+// fit in a single UDP datagram on networks without fragmentation issues,
+// the answers not fitting the Go resolver buffer are retried over TCP,
+// the Go resolver is used
+func WithEDNS0BufferSize(size uint16) Option {
+	return func(r *DomainResolver) {
+		r.ednsBufferSize = size
+	}
+}
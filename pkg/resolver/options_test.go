@@ -0,0 +1,120 @@
+package resolver
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithReverseLookup(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.False(t, r.reverseLookup)
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithReverseLookup())
+	assert.True(t, r.reverseLookup)
+}
+
+func TestWithCNAMETracking(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithCNAMETracking(true))
+	assert.True(t, r.trackCNAME)
+	assert.True(t, r.forcePublishOnCNAME)
+}
+
+func TestWithSearchDomains(t *testing.T) {
+	r := NewResolver("svc", "8080", false, &refreshRate, nil, WithFQDN())
+	assert.Equal(t, []string{"svc."}, r.queryNames())
+
+	r = NewResolver("svc", "8080", false, &refreshRate, nil, WithFQDN(), WithSearchDomains("ns.svc.cluster.local", ".cluster.local."))
+	assert.False(t, r.fqdn)
+	assert.Equal(t, []string{"svc.ns.svc.cluster.local.", "svc.cluster.local.", "svc"}, r.queryNames())
+
+	r = NewResolver("svc.", "8080", false, &refreshRate, nil, WithSearchDomains("cluster.local"))
+	assert.Equal(t, []string{"svc."}, r.queryNames())
+
+	r = NewResolver("svc", "8080", false, &refreshRate, nil)
+	assert.Equal(t, []string{"svc"}, r.queryNames())
+}
+
+func TestWithHosts(t *testing.T) {
+	r := NewResolver("my-service", "8080", false, &refreshRate, nil, WithHosts(map[string][]string{"My-Service.": {"10.0.0.1"}}, true))
+	assert.Equal(t, map[string][]string{"my-service": {"10.0.0.1"}}, r.hosts)
+	assert.True(t, r.hostsOnly)
+
+	r = NewResolver("my-service", "8080", false, &refreshRate, nil, WithHostsFile("/etc/hosts", false))
+	assert.Equal(t, "/etc/hosts", r.hostsFile)
+	assert.False(t, r.hostsOnly)
+}
+
+func TestWithPassthrough(t *testing.T) {
+	r := NewResolver("my-service", "8080", true, &refreshRate, nil, WithPassthrough())
+	assert.True(t, r.passthrough)
+	assert.False(t, r.needLookup)
+	assert.Nil(t, r.ticker)
+	assert.Equal(t, []string{"my-service:8080"}, r.Addresses)
+}
+
+func TestWithChurnThreshold(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithChurnThreshold(10))
+	assert.Equal(t, 10, r.churnThreshold)
+}
+
+func TestWithDNSServer(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, net.DefaultResolver, r.netResolver)
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithDNSServer("10.1.2.3"))
+	assert.Equal(t, "10.1.2.3:53", r.dnsServer)
+	assert.NotEqual(t, net.DefaultResolver, r.netResolver)
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithDNSServer("10.1.2.3:8600"))
+	assert.Equal(t, "10.1.2.3:8600", r.dnsServer)
+}
+
+func TestWithDryRun(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithDryRun())
+	assert.True(t, r.dryRun)
+}
+
+func TestWithAddressTTL(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithAddressTTL(time.Minute))
+	assert.Equal(t, time.Minute, r.addressTTL)
+}
+
+func TestWithPanicPolicy(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, RestartOnPanic, r.panicPolicy)
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithPanicPolicy(StopOnPanic, func(error) {}))
+	assert.Equal(t, StopOnPanic, r.panicPolicy)
+	assert.NotNil(t, r.onPanic)
+}
+
+func TestWithContext(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Nil(t, r.ctx.Done())
+
+	// the context of the resolver derives from the given one
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithContext(ctx))
+	assert.Equal(t, ctx.Done(), r.ctx.Done())
+}
+
+func TestWithLoadReporter(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithLoadReporter(func(string) uint32 { return 1 }))
+	assert.NotNil(t, r.loadReporter)
+}
+
+func TestWithHostnameFallback(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithHostnameFallback())
+	assert.True(t, r.hostnameFallback)
+}
+
+func TestWithEventLog(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithEventLog(ioutil.Discard))
+	assert.Equal(t, ioutil.Discard, r.eventLog)
+}
@@ -0,0 +1,70 @@
+package resolver
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// PanicPolicy defines what the watcher does after recovering from a
+// panic in a lookup, hook or listener
+type PanicPolicy int
+
+const (
+	// RestartOnPanic restarts the watcher after an exponential backoff
+	RestartOnPanic PanicPolicy = iota
+	// StopOnPanic stops the watcher, the last state is kept
+	StopOnPanic
+)
+
+const (
+	minPanicBackoff = time.Second
+	maxPanicBackoff = time.Minute
+)
+
+// runWatcher runs the watcher loop recovering from panics,
+// a nil error is returned when the watcher is closed
+func (r *DomainResolver) runWatcher() (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("watcher panic: %v", rec)
+		}
+	}()
+
+	r.watchLoop()
+	return nil
+}
+
+// handlePanic reports the panic and applies the panic policy,
+// returns false if the watcher must not be restarted
+func (r *DomainResolver) handlePanic(err error, backoff time.Duration) (restart bool) {
+	log.Println("[grpc-resolver]: recovered from ", err)
+	if r.onPanic != nil {
+		r.onPanic(err)
+	}
+
+	if r.panicPolicy == StopOnPanic {
+		r.ticker.Stop()
+		return false
+	}
+
+	select {
+	case <-r.isDone:
+		r.ticker.Stop()
+		return false
+	case <-r.ctx.Done():
+		r.ticker.Stop()
+		return false
+	case <-time.After(backoff):
+		return true
+	}
+}
+
+// nextPanicBackoff doubles the backoff up to the maximum
+func nextPanicBackoff(backoff time.Duration) time.Duration {
+	if backoff *= 2; backoff > maxPanicBackoff {
+		return maxPanicBackoff
+	}
+
+	return backoff
+}
@@ -0,0 +1,39 @@
+package resolver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextPanicBackoff(t *testing.T) {
+	assert.Equal(t, 2*time.Second, nextPanicBackoff(minPanicBackoff))
+	assert.Equal(t, maxPanicBackoff, nextPanicBackoff(maxPanicBackoff))
+}
+
+func TestWatcherPanicStop(t *testing.T) {
+	refresh := time.Duration(1)
+	errs := make(chan error, 1)
+	listener := make(chan bool)
+	r := NewResolver("localhost", "8080", true, &refresh, listener, WithPanicPolicy(StopOnPanic, func(err error) { errs <- err }))
+	r.StartResolver()
+
+	// a closed listener makes the watcher panic on the next change
+	close(listener)
+	r.m.Lock()
+	r.Addresses = []string{}
+	r.m.Unlock()
+
+	assert.NotNil(t, <-errs)
+	r.Close()
+}
+
+func TestHandlePanicRestart(t *testing.T) {
+	r := NewResolver("localhost", "8080", true, &refreshRate, nil)
+	assert.True(t, r.handlePanic(errors.New("boom"), time.Millisecond))
+
+	r.Close()
+	assert.False(t, r.handlePanic(errors.New("boom"), time.Minute))
+}
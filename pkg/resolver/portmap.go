@@ -0,0 +1,86 @@
+package resolver
+
+import (
+	"log"
+	"net"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// PortRule rewrites the port of the resolved addresses, e.g. to dial a
+// local sidecar instead of the port advertised by DNS, empty CIDR and
+// From match any address and port
+type PortRule struct {
+	CIDR string // addresses the rule applies to, e.g. 10.0.0.0/8
+	From string // port the rule applies to
+	To   string // port the addresses are published with
+}
+
+type portRule struct {
+	network *net.IPNet
+	from    string
+	to      string
+}
+
+// parsePortRules parses the CIDRs of the rules, the invalid rules are dropped
+func parsePortRules(rules []PortRule) []portRule {
+	parsed := make([]portRule, 0, len(rules))
+	for _, rule := range rules {
+		pr := portRule{from: rule.From, to: rule.To}
+		if rule.CIDR != "" {
+			_, network, err := net.ParseCIDR(rule.CIDR)
+			if err != nil {
+				log.Println("[grpc-resolver]: invalid port rule cidr ", err)
+				continue
+			}
+			pr.network = network
+		}
+		parsed = append(parsed, pr)
+	}
+
+	return parsed
+}
+
+// mapPorts rewrites the port of the addresses with the first matching
+// rule, the port of the resolver is replaced by the one of the family of
+// the addresses no rule matched
+func (r *DomainResolver) mapPorts(addrs []resolver.Address) []resolver.Address {
+	if len(r.portRules) == 0 && r.ipv4Port == "" && r.ipv6Port == "" {
+		return addrs
+	}
+
+	mapped := make([]resolver.Address, 0, len(addrs))
+	for _, a := range addrs {
+		host, port, err := net.SplitHostPort(a.Addr)
+		if err == nil {
+			ip := net.ParseIP(host)
+			matched := false
+			for _, rule := range r.portRules {
+				if (rule.from == "" || rule.from == port) && (rule.network == nil || (ip != nil && rule.network.Contains(ip))) {
+					a.Addr = net.JoinHostPort(host, rule.to)
+					matched = true
+					break
+				}
+			}
+
+			if fp := r.familyPort(ip); !matched && fp != "" && port == r.port {
+				a.Addr = net.JoinHostPort(host, fp)
+			}
+		}
+		mapped = append(mapped, a)
+	}
+
+	return mapped
+}
+
+// familyPort returns the port set for the family of the IP, empty if none
+func (r *DomainResolver) familyPort(ip net.IP) string {
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return r.ipv4Port
+	default:
+		return r.ipv6Port
+	}
+}
@@ -0,0 +1,37 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestMapPorts(t *testing.T) {
+	r := NewResolver("localhost", "443", false, &refreshRate, nil, WithPortRules(
+		PortRule{CIDR: "invalid", To: "1"},
+		PortRule{CIDR: "10.1.0.0/16", To: "8443"},
+		PortRule{From: "443", To: "15001"},
+	))
+	assert.Equal(t, 2, len(r.portRules))
+
+	addrs := []resolver.Address{{Addr: "10.1.2.3:443"}, {Addr: "10.2.2.3:443"}, {Addr: "[::1]:443"}, {Addr: "10.2.2.3:80"}, {Addr: "invalid"}}
+	assert.Equal(t, []string{"10.1.2.3:8443", "10.2.2.3:15001", "[::1]:15001", "10.2.2.3:80", "invalid"}, list.FromAddrToString(r.mapPorts(addrs)))
+
+	r.StartResolver()
+	assert.Contains(t, r.Addresses, "127.0.0.1:15001")
+
+	r = NewResolver("localhost", "443", false, &refreshRate, nil)
+	assert.Equal(t, addrs, r.mapPorts(addrs))
+}
+
+func TestFamilyPorts(t *testing.T) {
+	r := NewResolver("localhost", "443", false, &refreshRate, nil, WithFamilyPorts("8443", "9443"), WithPortRules(PortRule{CIDR: "10.1.0.0/16", To: "15001"}))
+	addrs := []resolver.Address{{Addr: "10.2.2.3:443"}, {Addr: "[::1]:443"}, {Addr: "10.1.2.3:443"}, {Addr: "10.2.2.3:80"}}
+	assert.Equal(t, []string{"10.2.2.3:8443", "[::1]:9443", "10.1.2.3:15001", "10.2.2.3:80"}, list.FromAddrToString(r.mapPorts(addrs)))
+
+	// an empty port keeps the one of the resolver
+	r = NewResolver("localhost", "443", false, &refreshRate, nil, WithFamilyPorts("", "9443"))
+	assert.Equal(t, []string{"10.2.2.3:443", "[::1]:9443"}, list.FromAddrToString(r.mapPorts(addrs[:2])))
+}
@@ -0,0 +1,109 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// address sources
+const (
+	SourceA         = "A"
+	SourceAAAA      = "AAAA"
+	SourceSRV       = "SRV"
+	SourceHosts     = "hosts"     // hosts override, see WithHosts and WithHostsFile
+	SourceBootstrap = "bootstrap" // see WithBootstrapAddresses
+	SourceStatic    = "static"    // IP or passthrough target
+	SourceFile      = "file"      // see WithEndpointsDir
+	SourceImport    = "import"    // see ImportSnapshot
+)
+
+// Provenance tells where a published address comes from and when it was seen
+type Provenance struct {
+	Source    string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// hostsHits records the IPs returned by the hosts override during a
+// resolution, it travels in the context of the lookups
+type hostsHits struct {
+	sync.Mutex
+	ips map[string]bool
+}
+
+type hostsHitsKey struct{}
+
+func withHostsHits(ctx context.Context) (context.Context, *hostsHits) {
+	h := &hostsHits{ips: map[string]bool{}}
+	return context.WithValue(ctx, hostsHitsKey{}, h), h
+}
+
+// recordHostsHits records the IPs as returned by the hosts override
+func recordHostsHits(ctx context.Context, ips []string) {
+	h, ok := ctx.Value(hostsHitsKey{}).(*hostsHits)
+	if !ok {
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	for _, ip := range ips {
+		h.ips[ip] = true
+	}
+}
+
+// sourceOf returns the source of the host:port address resolved by DNS
+func (r *DomainResolver) sourceOf(addr string, hits *hostsHits) string {
+	host := splitHost(addr)
+	hits.Lock()
+	fromHosts := hits.ips[host]
+	hits.Unlock()
+
+	switch {
+	case fromHosts:
+		return SourceHosts
+	case r.importedAddresses() != nil:
+		return SourceImport
+	case r.endpointsDir != "":
+		return SourceFile
+	case r.srv:
+		return SourceSRV
+	case net.ParseIP(host).To4() != nil:
+		return SourceA
+	default:
+		return SourceAAAA
+	}
+}
+
+// recordProvenance records the source of the addresses seen at the given
+// time, the addresses not seen are forgotten, it's expected to be called
+// with the lock held
+func (r *DomainResolver) recordProvenance(sources map[string]string, now time.Time) {
+	provenance := make(map[string]Provenance, len(sources))
+	for addr, source := range sources {
+		p, ok := r.provenance[addr]
+		if !ok {
+			p.FirstSeen = now
+		}
+		p.Source = source
+		p.LastSeen = now
+		provenance[addr] = p
+	}
+
+	r.provenance = provenance
+}
+
+// currentProvenance returns the provenance of the current addresses,
+// it's expected to be called with the lock held
+func (r *DomainResolver) currentProvenance() map[string]Provenance {
+	current := make(map[string]Provenance, len(r.Addresses))
+	for _, a := range r.Addresses {
+		if p, ok := r.provenance[a]; ok {
+			current[a] = p
+		}
+	}
+
+	return current
+}
@@ -0,0 +1,57 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenance(t *testing.T) {
+	r := NewResolver("my-service", "8080", false, &refreshRate, nil, WithHosts(map[string][]string{"my-service": {"10.0.0.1", "::1"}}, true))
+	r.StartResolver()
+
+	st := r.Status()
+	assert.Equal(t, 2, len(st.Provenance))
+	assert.Equal(t, SourceHosts, st.Provenance["10.0.0.1:8080"].Source)
+	assert.Equal(t, SourceHosts, st.Provenance["[::1]:8080"].Source)
+	first := st.Provenance["10.0.0.1:8080"].FirstSeen
+	assert.False(t, first.IsZero())
+
+	time.Sleep(time.Millisecond)
+	r.resolve(context.Background())
+	p := r.Status().Provenance["10.0.0.1:8080"]
+	assert.Equal(t, first, p.FirstSeen)
+	assert.True(t, p.LastSeen.After(first))
+}
+
+func TestProvenanceDNS(t *testing.T) {
+	r := NewResolver("localhost", "443", false, &refreshRate, nil, WithPortRules(PortRule{To: "15001"}))
+	r.StartResolver()
+	assert.Equal(t, SourceA, r.Status().Provenance["127.0.0.1:15001"].Source)
+
+	r = NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	r.StartResolver()
+	assert.Equal(t, SourceStatic, r.Status().Provenance["127.0.0.1:8080"].Source)
+
+	r = NewResolver("unknown.invalid", "8080", false, &refreshRate, nil, WithBootstrapAddresses([]string{"10.0.0.1:8080"}))
+	r.startBootstrapped()
+	assert.Equal(t, SourceBootstrap, r.Status().Provenance["10.0.0.1:8080"].Source)
+}
+
+func TestSourceOf(t *testing.T) {
+	_, hits := withHostsHits(context.Background())
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, SourceA, r.sourceOf("10.0.0.1:80", hits))
+	assert.Equal(t, SourceAAAA, r.sourceOf("[::1]:80", hits))
+
+	hits.ips["10.0.0.1"] = true
+	assert.Equal(t, SourceHosts, r.sourceOf("10.0.0.1:80", hits))
+
+	r.srv = true
+	assert.Equal(t, SourceSRV, r.sourceOf("10.0.0.2:80", hits))
+
+	// no recorder in the context
+	recordHostsHits(context.Background(), []string{"10.0.0.3"})
+}
@@ -0,0 +1,91 @@
+package resolver
+
+import (
+	"log"
+	"sync/atomic"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// Snapshot is the address list of a target at a given version
+type Snapshot struct {
+	Target    string
+	Version   uint64
+	Addresses []string
+	State     resolver.State // the state as sent to gRPC, with the address attributes
+}
+
+// Source produces the address snapshots of a target and feeds them to
+// its publishers every time the address list is published
+type Source interface {
+	Snapshot() Snapshot
+	AddPublisher(p Publisher)
+}
+
+// Publisher outputs the snapshots produced by a Source, e.g. to a
+// gRPC ClientConn or a file, a Source can feed several publishers
+type Publisher interface {
+	Publish(s Snapshot) error
+}
+
+// PublisherFunc allows using a function as a Publisher
+type PublisherFunc func(s Snapshot) error
+
+// Publish calls f(s)
+func (f PublisherFunc) Publish(s Snapshot) error {
+	return f(s)
+}
+
+// ClientConnPublisher sends the snapshots to a gRPC ClientConn, it allows
+// feeding several ClientConns from the same resolver
+type ClientConnPublisher struct {
+	cc resolver.ClientConn
+}
+
+// NewClientConnPublisher creates a publisher for the given ClientConn
+func NewClientConnPublisher(cc resolver.ClientConn) *ClientConnPublisher {
+	return &ClientConnPublisher{cc: cc}
+}
+
+// Publish updates the state of the ClientConn
+func (p *ClientConnPublisher) Publish(s Snapshot) error {
+	p.cc.UpdateState(s.State)
+	return nil
+}
+
+// Snapshot returns the current address list and its version
+func (r *DomainResolver) Snapshot() Snapshot {
+	r.access()
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.snapshot(resolver.State{})
+}
+
+// AddPublisher adds a publisher fed on every publication of the address list
+func (r *DomainResolver) AddPublisher(p Publisher) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.publishers = append(r.publishers, p)
+	atomic.AddInt32(&r.consumers, 1)
+}
+
+// snapshot returns the current snapshot with the given state,
+// it's expected to be called with the lock held
+func (r *DomainResolver) snapshot(st resolver.State) Snapshot {
+	return Snapshot{Target: r.address, Version: r.version, Addresses: append([]string{}, r.Addresses...), State: st}
+}
+
+// fanOut feeds the state to the publishers, errors are only logged
+// so a failing publisher doesn't prevent the others to be fed
+func (r *DomainResolver) fanOut(st resolver.State) {
+	r.m.Lock()
+	publishers := append([]Publisher{}, r.publishers...)
+	s := r.snapshot(st)
+	r.m.Unlock()
+
+	for _, p := range publishers {
+		if err := p.Publish(s); err != nil {
+			log.Println("[grpc-resolver]: error publishing snapshot ", err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package resolver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestPublishers(t *testing.T) {
+	snapshots := []Snapshot{}
+	p := PublisherFunc(func(s Snapshot) error {
+		snapshots = append(snapshots, s)
+		return nil
+	})
+	failing := PublisherFunc(func(s Snapshot) error { return errors.New("failed") })
+
+	cc := resolvertest.NewClientConn()
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithPublishers(failing, p))
+	r.AddPublisher(NewClientConnPublisher(cc))
+	r.StartResolver()
+
+	assert.Equal(t, 1, len(snapshots))
+	assert.Equal(t, "localhost", snapshots[0].Target)
+	assert.Equal(t, r.Addresses, snapshots[0].Addresses)
+	st, ok := cc.LastState()
+	assert.True(t, ok)
+	assert.Equal(t, r.Addresses, list.FromAddrToString(st.Addresses))
+
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.Refresh()
+	assert.Equal(t, 2, len(snapshots))
+	assert.Equal(t, uint64(1), snapshots[1].Version)
+	assert.Equal(t, 2, len(cc.States()))
+}
+
+func TestSnapshot(t *testing.T) {
+	r := NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	s := r.Snapshot()
+	assert.Equal(t, Snapshot{Target: "127.0.0.1", Addresses: []string{"127.0.0.1:8080"}}, s)
+
+	var src Source = r
+	src.AddPublisher(NewClientConnPublisher(resolvertest.NewClientConn()))
+	assert.Equal(t, 1, len(r.publishers))
+	assert.Equal(t, resolver.State{}, s.State)
+}
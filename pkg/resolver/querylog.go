@@ -0,0 +1,138 @@
+package resolver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// queryLogTimeout is how long a query waits for its answer in the log,
+// well over the 5s the Go resolver waits by default, the queries never
+// answered are forgotten after it
+const queryLogTimeout = 30 * time.Second
+
+// queryLog logs a summary of every DNS query going through the conns of
+// the Go resolver, it can be toggled at runtime with SetQueryLog
+type queryLog struct {
+	w       io.Writer // destination of the summaries, the standard logger if nil
+	enabled int32
+	m       sync.Mutex           // guards sent and serializes the writes to w
+	sent    map[string]time.Time // send time of the pending queries by server and ID
+}
+
+func newQueryLog(w io.Writer, enabled bool) *queryLog {
+	q := &queryLog{w: w, sent: map[string]time.Time{}}
+	q.set(enabled)
+	return q
+}
+
+func (q *queryLog) set(enabled bool) {
+	v := int32(0)
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&q.enabled, v)
+}
+
+func (q *queryLog) isEnabled() bool {
+	return q != nil && atomic.LoadInt32(&q.enabled) == 1
+}
+
+func queryKey(msg []byte, server string) (string, bool) {
+	if len(msg) < 2 {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s/%d", server, binary.BigEndian.Uint16(msg)), true
+}
+
+// query records the send time of the query to compute the rtt, the
+// queries pending for longer than queryLogTimeout are evicted
+func (q *queryLog) query(msg []byte, server string) {
+	if !q.isEnabled() {
+		return
+	}
+
+	key, ok := queryKey(msg, server)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	q.m.Lock()
+	defer q.m.Unlock()
+	for k, sent := range q.sent {
+		if now.Sub(sent) > queryLogTimeout {
+			delete(q.sent, k)
+		}
+	}
+	q.sent[key] = now
+}
+
+// answer logs the qname, qtype, server, rcode, answer count and rtt
+func (q *queryLog) answer(msg []byte, server string) {
+	key, ok := queryKey(msg, server)
+	if !ok {
+		return
+	}
+
+	q.m.Lock()
+	sent, found := q.sent[key]
+	delete(q.sent, key)
+	q.m.Unlock()
+	if !q.isEnabled() {
+		return
+	}
+
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil {
+		return
+	}
+
+	name, qtype := "", ""
+	if question, err := p.Question(); err == nil {
+		name, qtype = question.Name.String(), question.Type.String()
+	}
+
+	count := 0
+	if p.SkipAllQuestions() == nil {
+		for p.SkipAnswer() == nil {
+			count++
+		}
+	}
+
+	rtt := "unknown"
+	if found {
+		rtt = time.Since(sent).String()
+	}
+
+	summary := fmt.Sprintf("query %s %s server %s rcode %s answers %d rtt %s", name, qtype, server, h.RCode, count, rtt)
+	if q.w == nil {
+		log.Println("[grpc-resolver]: " + summary)
+		return
+	}
+
+	q.m.Lock()
+	defer q.m.Unlock()
+	fmt.Fprintln(q.w, summary)
+}
+
+// SetQueryLog enables or disables the query log at runtime, it has no
+// effect if the resolver was not created with WithQueryLog
+func (r *DomainResolver) SetQueryLog(enabled bool) {
+	if r.queryLog != nil {
+		r.queryLog.set(enabled)
+	}
+}
+
+// QueryLogEnabled returns whether the queries are being logged
+func (r *DomainResolver) QueryLogEnabled() bool {
+	return r.queryLog.isEnabled()
+}
@@ -0,0 +1,91 @@
+package resolver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestQueryLog(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+	go serveNXDomain(pc)
+
+	buf := &bytes.Buffer{}
+	r := NewResolver("missing.test.", "8080", false, &refreshRate, nil, WithQueryLog(buf, false))
+	r.netResolver = r.netResolverFor(pc.LocalAddr().String())
+	assert.False(t, r.QueryLogEnabled())
+	r.StartResolver()
+	assert.Empty(t, buf.String())
+
+	r.SetQueryLog(true)
+	assert.True(t, r.QueryLogEnabled())
+	r.Refresh()
+	assert.Contains(t, buf.String(), "query missing.test. TypeA server "+pc.LocalAddr().String()+" rcode RCodeNameError answers 0 rtt ")
+	assert.NotContains(t, buf.String(), "rtt unknown")
+
+	r.SetQueryLog(false)
+	buf.Reset()
+	r.Refresh()
+	assert.Empty(t, buf.String())
+
+	plain := NewResolver("missing.test.", "8080", false, &refreshRate, nil)
+	plain.SetQueryLog(true)
+	assert.False(t, plain.QueryLogEnabled())
+}
+
+func TestQueryLogEviction(t *testing.T) {
+	q := newQueryLog(ioutil.Discard, true)
+	q.sent["10.0.0.1:53/1"] = time.Now().Add(-2 * queryLogTimeout)
+	q.sent["10.0.0.1:53/2"] = time.Now()
+
+	// the unanswered query is forgotten on the next one
+	q.query([]byte{0, 3}, "10.0.0.1:53")
+	assert.Equal(t, 2, len(q.sent))
+	_, ok := q.sent["10.0.0.1:53/1"]
+	assert.False(t, ok)
+}
+
+func TestQueryLogConcurrentWrites(t *testing.T) {
+	w := &unsafeWriter{}
+	q := newQueryLog(w, true)
+	answer := dnsmessage.Message{Header: dnsmessage.Header{ID: 1, Response: true}}
+	msg, err := answer.Pack()
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				q.answer(msg, "10.0.0.1:53")
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(800), w.writes)
+}
+
+// unsafeWriter counts the writes and fails if two of them overlap
+type unsafeWriter struct {
+	writing int32
+	writes  int32
+}
+
+func (w *unsafeWriter) Write(b []byte) (int, error) {
+	if !atomic.CompareAndSwapInt32(&w.writing, 0, 1) {
+		panic("concurrent write")
+	}
+	w.writes++
+	atomic.StoreInt32(&w.writing, 0)
+	return len(b), nil
+}
@@ -0,0 +1,26 @@
+package resolver
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// random wraps the optional random source of the resolver, rand.Rand is
+// not safe for concurrent use so the calls are serialized
+type random struct {
+	m   sync.Mutex
+	rnd *rand.Rand
+}
+
+// shuffle shuffles the n elements with the configured source or the
+// shared math/rand one if none
+func (r *random) shuffle(n int, swap func(i, j int)) {
+	if r == nil || r.rnd == nil {
+		rand.Shuffle(n, swap)
+		return
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.rnd.Shuffle(n, swap)
+}
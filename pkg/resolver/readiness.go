@@ -0,0 +1,42 @@
+package resolver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Ready returns true once the resolver has addresses from a successful
+// resolution, IP and passthrough targets are always ready
+func (r *DomainResolver) Ready() bool {
+	r.access()
+	if !r.needLookup {
+		return true
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	return !r.lastRefresh.IsZero()
+}
+
+// ReadinessHandler returns a handler answering 200 once every resolver is
+// ready and 503 listing the targets not ready otherwise, to be used as
+// a Kubernetes readinessProbe so the pod doesn't receive traffic before
+// its downstream discovery is populated
+func ReadinessHandler(resolvers ...*DomainResolver) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		pending := []string{}
+		for _, r := range resolvers {
+			if !r.Ready() {
+				pending = append(pending, r.address)
+			}
+		}
+
+		if len(pending) > 0 {
+			http.Error(w, fmt.Sprintf("waiting for the resolution of %s", strings.Join(pending, ", ")), http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprintln(w, "ok")
+	})
+}
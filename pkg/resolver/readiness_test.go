@@ -0,0 +1,35 @@
+package resolver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	ip := NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	domain := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	unknown := NewResolver("unknown.invalid", "8080", false, &refreshRate, nil)
+	assert.True(t, ip.Ready())
+	assert.False(t, domain.Ready())
+
+	h := ReadinessHandler(ip, domain, unknown)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "localhost, unknown.invalid")
+
+	domain.StartResolver()
+	unknown.StartResolver()
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "localhost")
+
+	w = httptest.NewRecorder()
+	ReadinessHandler(ip, domain).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok\n", w.Body.String())
+}
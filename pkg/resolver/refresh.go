@@ -0,0 +1,76 @@
+package resolver
+
+import (
+	"context"
+	"net"
+)
+
+// RefreshOption customizes a single refresh, see RefreshWith
+type RefreshOption func(*refreshConfig)
+
+type refreshConfig struct {
+	forceTCP    bool
+	bypassCache bool
+	recordType  string
+}
+
+type refreshConfigKey struct{}
+
+// RefreshForceTCP sends the queries of the refresh over TCP
+func RefreshForceTCP() RefreshOption {
+	return func(c *refreshConfig) {
+		c.forceTCP = true
+	}
+}
+
+// RefreshBypassCache sends the queries of the refresh to the name servers
+// of the zone, bypassing the positive and negative caches of the recursive
+// resolvers, ignored if a DNS server is set with WithDNSServer
+func RefreshBypassCache() RefreshOption {
+	return func(c *refreshConfig) {
+		c.bypassCache = true
+	}
+}
+
+// RefreshRecordType only looks up the given record type, "A" or "AAAA",
+// both are looked up for any other value
+func RefreshRecordType(recordType string) RefreshOption {
+	return func(c *refreshConfig) {
+		c.recordType = recordType
+	}
+}
+
+// network returns the network to look up the IPs for
+func (c refreshConfig) network() string {
+	switch c.recordType {
+	case "A":
+		return "ip4"
+	case "AAAA":
+		return "ip6"
+	default:
+		return "ip"
+	}
+}
+
+func withRefreshConfig(ctx context.Context, c refreshConfig) context.Context {
+	return context.WithValue(ctx, refreshConfigKey{}, c)
+}
+
+func refreshConfigFrom(ctx context.Context) refreshConfig {
+	c, _ := ctx.Value(refreshConfigKey{}).(refreshConfig)
+	return c
+}
+
+// resolverFor returns the resolver for the lookups of the refresh, the
+// configured one unless the refresh options require a different one
+func (r *DomainResolver) resolverFor(ctx context.Context) *net.Resolver {
+	c := refreshConfigFrom(ctx)
+	if !c.forceTCP && !c.bypassCache {
+		return r.netResolver
+	}
+
+	dc := r.dnsConfig(r.dnsServer)
+	dc.forceTCP = dc.forceTCP || c.forceTCP
+	dc.authoritative = dc.authoritative || c.bypassCache
+	return dnsResolver(dc)
+}
@@ -0,0 +1,47 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshConfig(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, refreshConfig{}, refreshConfigFrom(ctx))
+	assert.Equal(t, "ip", refreshConfigFrom(ctx).network())
+
+	for recordType, network := range map[string]string{"A": "ip4", "AAAA": "ip6", "MX": "ip"} {
+		c := refreshConfig{}
+		RefreshRecordType(recordType)(&c)
+		assert.Equal(t, network, c.network())
+	}
+
+	c := refreshConfig{}
+	RefreshForceTCP()(&c)
+	RefreshBypassCache()(&c)
+	assert.Equal(t, refreshConfig{forceTCP: true, bypassCache: true}, refreshConfigFrom(withRefreshConfig(ctx, c)))
+}
+
+func TestResolverFor(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	ctx := context.Background()
+	assert.Equal(t, net.DefaultResolver, r.resolverFor(ctx))
+
+	res := r.resolverFor(withRefreshConfig(ctx, refreshConfig{forceTCP: true}))
+	assert.NotEqual(t, net.DefaultResolver, res)
+	assert.True(t, res.PreferGo)
+}
+
+func TestRefreshWith(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	r.RefreshWith(RefreshRecordType("A"))
+	assert.Equal(t, []string{"127.0.0.1:8080"}, r.Addresses)
+
+	// nothing to refresh for IPs
+	r = NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	r.RefreshWith(RefreshForceTCP())
+	assert.Equal(t, []string{"127.0.0.1:8080"}, r.Addresses)
+}
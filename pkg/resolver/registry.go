@@ -0,0 +1,87 @@
+package resolver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// registry keeps track of the resolvers with a running watcher
+var registry = struct {
+	sync.Mutex
+	watchers  map[*DomainResolver]struct{}
+	addresses map[*DomainResolver]int // number of addresses of every watcher
+}{watchers: map[*DomainResolver]struct{}{}, addresses: map[*DomainResolver]int{}}
+
+func register(r *DomainResolver) {
+	r.access()
+	r.m.Lock()
+	n := len(r.Addresses)
+	r.m.Unlock()
+
+	registry.Lock()
+	registry.watchers[r] = struct{}{}
+	registry.addresses[r] = n
+	registry.Unlock()
+
+	r.checkLimits()
+}
+
+func unregister(r *DomainResolver) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.watchers, r)
+	delete(registry.addresses, r)
+}
+
+// ActiveWatchers returns the number of resolvers with a running watcher
+func ActiveWatchers() int {
+	registry.Lock()
+	defer registry.Unlock()
+	return len(registry.watchers)
+}
+
+// LeakCheck waits up to timeout for every watcher to terminate, an error
+// listing the addresses still being watched is returned otherwise, meant
+// to be used at the end of integration tests
+func LeakCheck(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		registry.Lock()
+		addresses := []string{}
+		for r := range registry.watchers {
+			addresses = append(addresses, r.address)
+		}
+		registry.Unlock()
+
+		if len(addresses) == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d watchers still running: %v", len(addresses), addresses)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// RefreshAll refreshes right away every resolver with a running watcher
+func RefreshAll() {
+	registry.Lock()
+	resolvers := make([]*DomainResolver, 0, len(registry.watchers))
+	for r := range registry.watchers {
+		resolvers = append(resolvers, r)
+	}
+	registry.Unlock()
+
+	wg := sync.WaitGroup{}
+	for _, r := range resolvers {
+		wg.Add(1)
+		go func(r *DomainResolver) {
+			defer wg.Done()
+			r.Refresh()
+		}(r)
+	}
+	wg.Wait()
+}
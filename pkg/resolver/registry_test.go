@@ -0,0 +1,49 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func isRegistered(r *DomainResolver) bool {
+	registry.Lock()
+	defer registry.Unlock()
+	_, ok := registry.watchers[r]
+	return ok
+}
+
+func waitUnregistered(r *DomainResolver) bool {
+	for i := 0; i < 100; i++ {
+		if !isRegistered(r) {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewResolver("localhost", "8080", true, &refreshRate, nil)
+	r.StartResolver()
+	assert.True(t, isRegistered(r))
+	assert.True(t, ActiveWatchers() > 0)
+	assert.NotNil(t, LeakCheck(time.Millisecond))
+
+	r.Close()
+	r.Close()
+	assert.True(t, waitUnregistered(r))
+}
+
+func TestWatcherStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewResolver("localhost", "8080", true, &refreshRate, nil, WithContext(ctx))
+	r.StartResolver()
+	assert.True(t, isRegistered(r))
+
+	cancel()
+	assert.True(t, waitUnregistered(r))
+}
@@ -0,0 +1,92 @@
+package resolver
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// eventBuffer is the capacity of the channels returned by WatchEvents
+// on top of the replayed events
+const eventBuffer = 16
+
+// WatchEvents returns a channel receiving the change, health and
+// warning events, a late
+// subscriber first receives the last change events kept by
+// WithEventReplay followed by a snapshot event with the current address
+// list, so it never misses the initial state. The events are dropped if
+// the channel is full and it's closed by Close or UnwatchEvents
+func (r *DomainResolver) WatchEvents() <-chan Event {
+	r.access()
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.em.Lock()
+	defer r.em.Unlock()
+
+	ch := make(chan Event, len(r.replay)+1+eventBuffer)
+	if r.closed {
+		close(ch)
+		return ch
+	}
+
+	for _, e := range r.replay {
+		ch <- e
+	}
+
+	if len(r.Addresses) > 0 {
+		ch <- Event{Type: EventSnapshot, Target: r.address, Time: time.Now(), Version: r.version, Addresses: append([]string{}, r.Addresses...), Labels: r.copyLabels()}
+	}
+
+	r.eventSubscribers = append(r.eventSubscribers, ch)
+	atomic.AddInt32(&r.consumers, 1)
+	return ch
+}
+
+// UnwatchEvents stops sending events to a channel returned by
+// WatchEvents and closes it
+func (r *DomainResolver) UnwatchEvents(ch <-chan Event) {
+	r.em.Lock()
+	defer r.em.Unlock()
+	for i, sub := range r.eventSubscribers {
+		if sub == ch {
+			r.eventSubscribers = append(r.eventSubscribers[:i], r.eventSubscribers[i+1:]...)
+			atomic.AddInt32(&r.consumers, -1)
+			close(sub)
+			return
+		}
+	}
+}
+
+// deliverEvent keeps the change event for the replay and sends the
+// notified events to the event subscribers, it's expected to be called
+// with the em lock held
+func (r *DomainResolver) deliverEvent(e Event) {
+	if !notified(e) {
+		return
+	}
+
+	if e.Type == EventChange && r.replaySize > 0 {
+		r.replay = append(r.replay, e)
+		if len(r.replay) > r.replaySize {
+			r.replay = r.replay[len(r.replay)-r.replaySize:]
+		}
+	}
+
+	for _, ch := range r.eventSubscribers {
+		select {
+		case ch <- e:
+		default:
+			log.Println("[grpc-resolver]: event subscriber full, dropping ", e.Type, " event version ", e.Version)
+		}
+	}
+}
+
+// closeEventSubscribers closes the channels returned by WatchEvents
+func (r *DomainResolver) closeEventSubscribers() {
+	r.em.Lock()
+	defer r.em.Unlock()
+	for _, ch := range r.eventSubscribers {
+		close(ch)
+	}
+	r.eventSubscribers = nil
+}
@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchEventsReplay(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithEventReplay(2))
+	r.StartResolver()
+	current := append([]string{}, r.Addresses...)
+	for _, addr := range []string{"127.0.0.2:8080", "127.0.0.3:8080", "127.0.0.4:8080"} {
+		r.Addresses = []string{addr}
+		r.getState(context.Background())
+	}
+
+	// the last 2 changes are replayed before the snapshot
+	ch := r.WatchEvents()
+	e := <-ch
+	assert.Equal(t, EventChange, e.Type)
+	assert.Equal(t, []string{"127.0.0.3:8080"}, e.Removed)
+	e = <-ch
+	assert.Equal(t, EventChange, e.Type)
+	assert.Equal(t, []string{"127.0.0.4:8080"}, e.Removed)
+	e = <-ch
+	assert.Equal(t, EventSnapshot, e.Type)
+	assert.Equal(t, "localhost", e.Target)
+	assert.Equal(t, r.Status().Version, e.Version)
+	assert.ElementsMatch(t, current, e.Addresses)
+
+	// live changes follow
+	r.Addresses = []string{"127.0.0.5:8080"}
+	r.getState(context.Background())
+	e = <-ch
+	assert.Equal(t, EventChange, e.Type)
+	assert.Equal(t, []string{"127.0.0.5:8080"}, e.Removed)
+
+	r.Close()
+	_, ok := <-ch
+	assert.False(t, ok)
+	_, ok = <-r.WatchEvents()
+	assert.False(t, ok)
+}
+
+func TestWatchEventsNoReplay(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	ch := r.WatchEvents()
+	assert.Equal(t, 0, len(ch))
+
+	r.StartResolver()
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.getState(context.Background())
+	assert.Equal(t, 1, len(ch))
+	assert.Empty(t, r.replay)
+
+	// only the snapshot for new subscribers
+	ch2 := r.WatchEvents()
+	assert.Equal(t, 1, len(ch2))
+	assert.Equal(t, EventSnapshot, (<-ch2).Type)
+
+	// the buffered change is still delivered before the close
+	r.UnwatchEvents(ch)
+	assert.Equal(t, EventChange, (<-ch).Type)
+	_, ok := <-ch
+	assert.False(t, ok)
+	r.Close()
+}
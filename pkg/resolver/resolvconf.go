@@ -0,0 +1,78 @@
+package resolver
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultResolvConf is the resolver configuration file on unix systems
+const DefaultResolvConf = "/etc/resolv.conf"
+
+// resolvConfRecheck is how often the Go resolver reloads resolv.conf at
+// most, a second refresh is done after it so the new servers are used
+var resolvConfRecheck = 5 * time.Second
+
+// resolvConf is the part of resolv.conf affecting the lookups
+type resolvConf struct {
+	nameservers []string
+	search      []string
+}
+
+// readResolvConf parses the nameserver and search lines of the file
+func readResolvConf(path string) (resolvConf, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return resolvConf{}, err
+	}
+	defer f.Close()
+
+	conf := resolvConf{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "nameserver":
+			conf.nameservers = append(conf.nameservers, fields[1])
+		case "search", "domain":
+			conf.search = fields[1:]
+		}
+	}
+
+	return conf, scanner.Err()
+}
+
+// watchResolvConf polls the resolver configuration file and refreshes
+// the addresses when the name servers or the search domains change,
+// e.g. on VPN connect or DHCP renewal, until the resolver is closed
+func (r *DomainResolver) watchResolvConf() {
+	last, err := readResolvConf(r.resolvConfPath)
+	if err != nil {
+		log.Println("[grpc-resolver]: error reading resolver configuration ", err)
+	}
+
+	ticker := time.NewTicker(r.resolvConfInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if r.isShutdown() {
+			return
+		}
+
+		conf, err := readResolvConf(r.resolvConfPath)
+		if err != nil || reflect.DeepEqual(conf, last) {
+			continue
+		}
+
+		log.Println("[grpc-resolver]: resolver configuration changed, name servers ", conf.nameservers, " search ", conf.search)
+		last = conf
+		r.Refresh()
+		time.AfterFunc(resolvConfRecheck, r.Refresh)
+	}
+}
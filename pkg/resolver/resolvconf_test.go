@@ -0,0 +1,57 @@
+package resolver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadResolvConf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resolvconf")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "resolv.conf")
+	content := "# comment\nnameserver 10.0.0.53\nnameserver 10.0.0.54\nsearch ns.svc.cluster.local svc.cluster.local\noptions ndots:5\n"
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	conf, err := readResolvConf(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.53", "10.0.0.54"}, conf.nameservers)
+	assert.Equal(t, []string{"ns.svc.cluster.local", "svc.cluster.local"}, conf.search)
+
+	_, err = readResolvConf(filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+}
+
+func TestWatchResolvConf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resolvconf")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "resolv.conf")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("nameserver 10.0.0.53\n"), 0644))
+
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithResolvConfWatch(path, 10*time.Millisecond))
+	r.StartResolver()
+	defer r.Close()
+	first := r.Status().LastRefresh
+
+	// unrelated changes don't trigger a refresh
+	assert.Nil(t, ioutil.WriteFile(path, []byte("nameserver 10.0.0.53\noptions ndots:5\n"), 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, first, r.Status().LastRefresh)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("nameserver 10.0.0.54\n"), 0644))
+	assert.Eventually(t, func() bool { return r.Status().LastRefresh.After(first) }, time.Second, 5*time.Millisecond)
+}
+
+func TestWithResolvConfWatch(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithResolvConfWatch("", 0))
+	assert.Equal(t, DefaultResolvConf, r.resolvConfPath)
+	assert.Equal(t, resolvConfRecheck, r.resolvConfInterval)
+}
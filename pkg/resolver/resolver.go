@@ -1,16 +1,28 @@
 package resolver
 
 import (
+	"context"
+	"expvar"
+	"io"
+	"io/ioutil"
 	"log"
 	"net"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cperez08/dm-resolver/pkg/list"
+	"golang.org/x/net/dns/dnsmessage"
+	"google.golang.org/grpc/attributes"
 	"google.golang.org/grpc/resolver"
 )
 
+// hostnamesKey is the attribute key used to attach the PTR
+// hostnames to the gRPC addresses
+type hostnamesKey struct{}
+
 // DomainResolver is a custom resolver library that helps to resolve a
 // domain returning a list of IPs associated with it, also with posibilty to watch for DNS
 // changes, the library can be used either by the resolver builder
@@ -20,30 +32,213 @@ type DomainResolver struct {
 	cc          resolver.ClientConn
 	target      resolver.Target
 	ticker      *time.Ticker
-	Addresses   []string
+	Addresses   []string // current addresses as host:port, IP targets included
 	isDone      chan bool
 	needWatcher bool // indicates if the library needs to watch for domain changes
 	address     string
 	port        string
-	updateState bool      // false when the library is used outside gRPC context
-	listener    chan bool // lister that can be used to watch changes in the Address list
-	needLookup  bool      // indicates if need to look up for new ips in the watcher, no valid for address type IP
+	updateState bool          // true when created by NewGRPCResolver, false outside gRPC context
+	listener    chan bool     // lister that can be used to watch changes in the Address list
+	lm          sync.Mutex    // held while signaling the listener
+	closing     chan struct{} // closed by Close
+	needLookup  bool          // indicates if need to look up for new ips in the watcher, no valid for address type IP
+
+	reverseLookup bool                // indicates if PTR lookups are done for the resolved IPs
+	hostnames     map[string][]string // PTR hostnames by address, only when reverseLookup is enabled
+
+	trackCNAME          bool     // indicates if the canonical name is looked up on every refresh
+	forcePublishOnCNAME bool     // publish the state on canonical name changes even without IP changes
+	cname               string   // last canonical name seen for the domain
+	cnameChain          []string // names from the domain to cname, see lookupCNAMEChain
+
+	fqdn          bool      // the domain is always looked up as a fully qualified name
+	searchDomains []string  // user supplied search list used to qualify short names
+	rewriter      *Rewriter // optional rewrite rules applied to the name before the lookups
+
+	hosts     map[string][]string // hosts override consulted before DNS
+	hostsFile string              // hosts file read on every lookup, takes precedence over hosts
+	hostsOnly bool                // DNS is never queried when the hosts override is set
+
+	passthrough bool // the domain is published untouched, no IP lookup is done
+
+	churn          Churn       // address churn counters
+	changes        []time.Time // time of the address list changes in the last hour
+	churnThreshold int         // changes per hour after which a warning is logged
+
+	netResolver        *net.Resolver       // resolver used for the lookups, net.DefaultResolver by default
+	dnsServer          string              // DNS server set by WithDNSServer, empty for the host configured ones
+	forceTCP           bool                // the DNS queries are sent over TCP
+	authoritative      bool                // the DNS queries are sent to the zone name servers
+	preferGo           bool                // the pure Go resolver is used even if the process would use cgo
+	clientSubnet       *clientSubnet       // EDNS client subnet added to the queries, nil if not set
+	queryLog           *queryLog           // logs a summary of the DNS queries, nil if not set
+	maintenanceWindows []maintenanceWindow // windows holding back the removals or every change
+	budget             *budget             // error budget deciding the health events, nil if not set
+	imported           []string            // addresses replacing the lookups, see ImportSnapshot
+	dialer             DialFunc            // dials the DNS servers, a zero net.Dialer if nil
+	ednsBufferSize     uint16              // EDNS0 UDP buffer size advertised in the queries, the Go default if 0
+	noRecursion        bool                // the RD bit of the queries is cleared, see WithRecursionDesired
+	dnsAnswers         []DNSAnswer         // answers of the last resolution, only recorded by the Go resolver dialer
+	viewServers        []string            // DNS servers set by WithViews, one per view
+	views              []*net.Resolver
+	viewMode           ViewMode
+	hedgeServers       []string // DNS servers set by WithHedgedServers, in order of preference
+	hedged             []*net.Resolver
+	hedgeDelay         time.Duration // delay before querying the next hedged server
+
+	resolvConfPath     string        // resolver configuration file watched for changes, empty if not watched
+	resolvConfInterval time.Duration // how often the resolver configuration file is checked
+
+	endpointsDir      string        // directory of the files with the addresses of the targets, empty for DNS
+	endpointsInterval time.Duration // how often the endpoints file is checked
+
+	stableOrder bool     // the order of the remaining addresses is preserved across refreshes
+	published   []string // order of the last published addresses
+
+	lastState     *resolver.State             // last state sent to the ClientConn, identical states are not sent again
+	lastAddresses map[string]resolver.Address // last published address by Addr, see reuseAddresses
+	resolvedAddrs map[string]resolver.Address // prepared address of every entry of Addresses, see setResolved
+
+	invalid int  // number of invalid addresses dropped before the publication
+	dryRun  bool // lookups and diffs are done but the state is never sent to gRPC
+
+	leaderCheck    func() bool     // optional leadership check, nothing is published unless it returns true
+	leaderInterval time.Duration   // how often the leadership is checked to publish the held state
+	heldState      *resolver.State // last state held back while not the leader or the group is not complete
+	group          *Group          // optional group published together
+
+	addressTTL time.Duration        // time after which an address not seen anymore is dropped
+	lastSeen   map[string]time.Time // last time every address was returned by a lookup
+
+	panicPolicy PanicPolicy // what the watcher does after recovering from a panic
+	onPanic     func(error) // optional callback invoked with the recovered panic
+
+	closeOnce sync.Once       // makes Close safe to be called more than once
+	ctx       context.Context // the watcher is stopped when the context is done
+
+	loadReporter     LoadReporter     // optional source of per address weights
+	localityReporter LocalityReporter // optional source of per address localities
+	transportHint    TransportHint    // optional source of the per address transport
+	hostnameFallback bool             // the hostname is published after the IPs as a last resort
+	warmUp           *warmUp          // optional warm-up of the added addresses
+	simulation       *simulation      // scripted answers and clock, only set by Simulate
+
+	metrics Metrics // optional sink of the measurements, e.g. OpenTelemetry
+
+	em       sync.Mutex // serializes the writes to the event sinks
+	eventLog io.Writer  // optional sink for the events as JSON lines
+
+	subscribers []chan []string // channels returned by Watch
+	watching    atomic.Value    // copy of subscribers read by isIdle without the lock
+
+	eventSubscribers []chan Event // channels returned by WatchEvents, guarded by em
+	consumers        int32        // publishers and event subscribers, read by isIdle without the lock
+	replay           []Event      // last change events replayed to the new event subscribers, guarded by em
+	replaySize       int          // number of change events kept for the replay
+	closed           bool         // true once Close was called
+
+	compare Comparator // decides if a newly resolved list is a change
+
+	maxAddresses int     // maximum number of published addresses, 0 means no limit
+	randomSample bool    // a random sample is published instead of the first addresses
+	random       *random // optional source of the random sample, the shared math/rand one if nil
+
+	srv        bool       // the domain is resolved through its SRV records
+	srvService string     // SRV service name, e.g. grpc
+	srvProto   string     // SRV protocol, e.g. tcp
+	tierHealth TierHealth // optional health of the SRV priority tiers
+
+	version uint64   // address list version, increased on every change
+	webhook *webhook // optional notifier of the change events
+
+	publishers []Publisher // fed on every publication of the address list
+
+	bootstrap []string // addresses published before the first resolution
+
+	stats  *expvar.Map       // expvar counters of the target, nil if not enabled
+	labels map[string]string // user defined labels added to the events, counters, status and logs
+
+	lastRefresh time.Time // last resolution returning records
+
+	portRules []portRule // port rewrite rules applied to the resolved addresses
+	ipv4Port  string     // port of the IPv4 addresses, the port of the resolver if empty
+	ipv6Port  string     // port of the IPv6 addresses, the port of the resolver if empty
+
+	customInterval time.Duration // refresh interval set by WithRefreshInterval, overrides the refresh rate
+	dnsConns       *dnsConnPool  // DNS conns kept open for the sub-second refresh intervals, nil otherwise
+
+	ports []string // ports every resolved IP is published with, overrides port
+
+	serverName string // server name published with the addresses, empty for the target host
+
+	shutdown int32 // set to 1 by Close, nothing is delivered afterwards
+
+	lastAccess int64 // unix nanoseconds of the last access, see SetIdleTimeout
+
+	lazyStart bool      // the resolver is started by the first ResolveNow or call, see WithLazyStart
+	startOnce sync.Once // starts the lazy resolver once
+	unpend    func()    // removes the lazy resolver from the pending ones of its builder, nil if none
+
+	provenance map[string]Provenance // source and first/last seen times by address
+
+	quarantineAfter int                  // consecutive connection failures quarantining an address, 0 disables the feedback
+	quarantineFor   time.Duration        // how long a failing address is quarantined
+	failures        map[string]int       // consecutive connection failures by address
+	quarantined     map[string]time.Time // end of the quarantine by address
+	resolvedState   *resolver.State      // last published state before applying the feedback
+
+	scorer       Scorer       // scores the published addresses, nil disables the scoring
+	healthSource HealthSource // optional health check results used by the scorer
+
+	interval       time.Duration // refresh interval of the watcher, zero without watcher
+	lastTick       time.Time     // wall clock time of the last tick, only used by the watcher
+	catchUpRefresh bool          // a refresh is triggered right away after a stall
+	spreadRefresh  bool          // the first tick is delayed by the phase offset of the target
+
+	unavailable logLimiter // limits the logs of the unavailable errors annotated by the interceptors
 }
 
 // NewResolver creates a new resolver instance, if needWatcher is true
 // a time in seconds is expected in the refreshRate parameter
-// the ticker field is exported in case want to be updated or stoped
-func NewResolver(address, port string, needWatcher bool, refreshRate *time.Duration, listener chan bool) *DomainResolver {
-	d := &DomainResolver{address: address, port: port, updateState: false}
-	if net.ParseIP(address) != nil {
-		d.Addresses = append(d.Addresses, address)
+// the ticker field is exported in case want to be updated or stoped,
+// optional behaviour can be enabled through the opts parameter
+func NewResolver(address, port string, needWatcher bool, refreshRate *time.Duration, listener chan bool, opts ...Option) *DomainResolver {
+	d := &DomainResolver{address: address, port: port, updateState: false, ctx: context.Background(), compare: list.CompareListStr, closing: make(chan struct{})}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.ctx = context.WithValue(d.ctx, resolverKey{}, d)
+	if d.warmUp != nil {
+		d.warmUp.ctx = d.ctx
+	}
+	if d.customInterval > 0 && d.customInterval < persistentInterval {
+		d.dnsConns = newDNSConnPool()
+	}
+	d.netResolver = d.newNetResolver()
+	d.publishLabels()
+	for _, s := range d.viewServers {
+		d.views = append(d.views, d.netResolverFor(s))
+	}
+	for _, s := range d.hedgeServers {
+		d.hedged = append(d.hedged, d.netResolverFor(s))
+	}
+
+	if d.passthrough {
+		d.Addresses = append(d.Addresses, net.JoinHostPort(address, port))
+		d.needLookup = false
+		d.listener = listener
+		d.recordProvenance(map[string]string{d.Addresses[0]: SourceStatic}, time.Now())
+	} else if ip := net.ParseIP(address); ip != nil {
+		d.Addresses = d.joinPorts([]string{ip.String()})
 		d.needLookup = false
+		d.recordProvenance(staticSources(d.Addresses), time.Now())
 	} else {
 		d.needLookup = true
 		d.listener = listener
 		if needWatcher {
 			d.needWatcher = true
-			d.ticker = time.NewTicker(time.Second * (*refreshRate))
+			d.interval = d.refreshInterval(refreshRate)
+			d.ticker = time.NewTicker(d.interval)
 			d.isDone = make(chan bool)
 		}
 	}
@@ -51,126 +246,590 @@ func NewResolver(address, port string, needWatcher bool, refreshRate *time.Durat
 	return d
 }
 
+// NewStandaloneResolver creates a resolver used outside gRPC, the changes
+// are notified through the listener, Watch and the publishers, it's the
+// same as NewResolver. It returns a *DomainResolver like NewGRPCResolver,
+// the method set is the same, only the ClientConn updates differ
+func NewStandaloneResolver(address, port string, needWatcher bool, refreshRate *time.Duration, listener chan bool, opts ...Option) *DomainResolver {
+	return NewResolver(address, port, needWatcher, refreshRate, listener, opts...)
+}
+
+// NewGRPCResolver creates a resolver updating the state of the given
+// ClientConn on every change besides the standalone notifications, the
+// resolver is not started so StartResolver has to be called, the
+// returned *DomainResolver has the same methods as a standalone one
+func NewGRPCResolver(cc resolver.ClientConn, address, port string, needWatcher bool, refreshRate *time.Duration, opts ...Option) *DomainResolver {
+	r := NewResolver(address, port, needWatcher, refreshRate, nil, opts...)
+	r.cc = cc
+	r.updateState = true
+	return r
+}
+
 // StartResolver resolves by first time the given domain
 func (r *DomainResolver) StartResolver() {
+	if r.leaderCheck != nil {
+		go r.watchLeadership()
+	}
+
 	if !r.needLookup {
-		addrs := []resolver.Address{{Addr: r.Addresses[0]}}
-		if r.updateState {
-			r.cc.UpdateState(resolver.State{Addresses: addrs})
-		}
+		addrs := r.prepare(r.staticAddresses())
+		r.m.Lock()
+		r.Addresses = list.FromAddrToString(addrs)
+		r.recordProvenance(staticSources(r.Addresses), time.Now())
+		r.m.Unlock()
+		r.publish(resolver.State{Addresses: addrs})
+		return
+	}
+
+	if r.resolvConfPath != "" {
+		go r.watchResolvConf()
+	}
+
+	if r.budget != nil && r.budget.MaxStaleness > 0 && r.simulation == nil {
+		r.budget.checkStale(r.now()) // starts counting the staleness before the first refresh
+		go r.watchStaleness()
+	}
+
+	if r.endpointsDir != "" {
+		last, _ := ioutil.ReadFile(r.endpointsFile()) // read before the first resolution so no change is missed
+		go r.watchEndpoints(last)
+	}
+
+	if len(r.bootstrap) > 0 {
+		r.startBootstrapped()
 		return
 	}
 
-	addrs := r.resolve()
+	r.refreshCNAME(r.ctx)
+	addrs := r.resolve(r.ctx)
 	for _, a := range addrs {
 		r.Addresses = append(r.Addresses, a.Addr)
 	}
+	r.m.Lock()
+	addrs = r.applyStableOrder(addrs)
+	r.m.Unlock()
 
 	if r.needWatcher {
+		register(r)
 		go r.watch()
 	}
 
 	sort.Strings(r.Addresses)
-	if r.updateState {
-		r.cc.UpdateState(resolver.State{Addresses: addrs}) // update the state in the start, only gRPC
+	r.m.Lock()
+	r.setResolved(addrs)
+	r.touch(r.Addresses)
+	r.sendLatest()
+	if r.warmUp != nil {
+		r.warmUp.update(r.Addresses, nil)
+	}
+	r.m.Unlock()
+	r.publish(resolver.State{Addresses: addrs}) // update the state in the start, only gRPC
+}
+
+// staticAddresses returns the addresses of an IP or passthrough target
+// before going through the pipeline, the IP is published with every port
+// of the resolver
+func (r *DomainResolver) staticAddresses() []resolver.Address {
+	hostports := []string{net.JoinHostPort(r.address, r.port)}
+	if ip := net.ParseIP(r.address); ip != nil && !r.passthrough {
+		hostports = r.joinPorts([]string{ip.String()})
+	}
+
+	addrs := make([]resolver.Address, 0, len(hostports))
+	for _, hp := range hostports {
+		addrs = append(addrs, resolver.Address{Addr: hp})
+	}
+
+	return addrs
+}
+
+// staticSources returns the static source of every address
+func staticSources(addrs []string) map[string]string {
+	sources := make(map[string]string, len(addrs))
+	for _, a := range addrs {
+		sources[a] = SourceStatic
 	}
+
+	return sources
 }
 
 // ResolveNow is empty since we are going to rely on our own ticker
 // to standardise the refresh rate
 func (r *DomainResolver) ResolveNow(o resolver.ResolveNowOptions) {
+	go r.startLazily()
 	// st, apply := r.getState()
 	// if apply && r.updateState {
 	// 	r.cc.UpdateState(st)
 	// }
 }
 
-// Close stops watching for changes in the domain
+// Refresh resolves the domain right away out of the refresh
+// rate, the state is updated if the addresses changed
+func (r *DomainResolver) Refresh() {
+	r.RefreshWith()
+}
+
+// RefreshWith resolves the domain right away like Refresh, the options
+// apply to this refresh only, e.g. to force a clean re-resolution when
+// DNS is known to have just changed
+func (r *DomainResolver) RefreshWith(opts ...RefreshOption) {
+	if !r.needLookup {
+		return
+	}
+
+	ctx, cancel := r.refreshContext()
+	defer cancel()
+
+	cfg := refreshConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ctx = withRefreshConfig(ctx, cfg)
+
+	st, apply := r.getState(ctx)
+	if apply {
+		r.publish(st)
+	}
+}
+
+// Close stops watching for changes in the domain,
+// it is safe to be called more than once
 func (r *DomainResolver) Close() {
-	if r.isDone != nil && r.needWatcher {
-		r.isDone <- true
+	r.m.Lock()
+	atomic.StoreInt32(&r.shutdown, 1)
+	r.m.Unlock()
+	r.closeOnce.Do(func() {
+		close(r.closing)
+		if r.isDone != nil && r.needWatcher {
+			close(r.isDone)
+		}
+		if r.unpend != nil {
+			r.unpend()
+		}
+	})
+
+	// waits for a listener signal in flight, none is sent afterwards
+	r.lm.Lock()
+	r.lm.Unlock()
+
+	r.closeSubscribers()
+	if r.webhook != nil {
+		r.webhook.close()
+	}
+
+	if r.warmUp != nil {
+		r.warmUp.close()
+	}
+
+	if r.dnsConns != nil {
+		r.dnsConns.close()
 	}
 }
 
+// publish feeds the state to the publishers and sends it to the gRPC
+// ClientConn, only applicable for gRPC, in dry run mode the state is
+// logged instead of sent to the ClientConn
+func (r *DomainResolver) publish(st resolver.State) {
+	if r.isShutdown() || r.gate(st) {
+		return
+	}
+
+	if r.quarantineAfter > 0 || r.scorer != nil {
+		r.m.Lock()
+		resolved := st
+		r.resolvedState = &resolved
+		now := r.now()
+		st.Addresses = r.applyScores(r.applyFeedback(st.Addresses, now), now)
+		r.m.Unlock()
+	}
+
+	if r.hostnameFallback && r.needLookup && len(st.Addresses) == 0 {
+		// only alone, next to the IPs balancers other than pick_first would
+		// send it a share of the traffic
+		st.Addresses = []resolver.Address{{Addr: net.JoinHostPort(r.lookupName(), r.port)}}
+	}
+
+	if r.serverName != "" {
+		st.Addresses = withServerName(st.Addresses, r.serverName)
+	}
+	st.Addresses = r.reuseAddresses(st.Addresses)
+
+	r.fanOut(st)
+	if !r.updateState {
+		return
+	}
+
+	if r.isPublished(st) {
+		return
+	}
+
+	if r.dryRun {
+		log.Println("[grpc-resolver]: dry run, skipping state update ", list.FromAddrToString(st.Addresses))
+		return
+	}
+
+	r.cc.UpdateState(st)
+}
+
 // GetNewState get a new resolver state
-func (r *DomainResolver) getState() (_ resolver.State, isUpdated bool) {
-	addrs := r.resolve()
+func (r *DomainResolver) getState(ctx context.Context) (_ resolver.State, isUpdated bool) {
+	cnameChanged := r.refreshCNAME(ctx)
+	addrs := r.resolve(ctx)
+
+	// the change is announced and the listener signaled once the lock is
+	// released, so a consumer not reading it can't block Close and the
+	// event consumers can't block the resolver
+	var change *Event
+	signal := false
+	defer func() {
+		if change != nil {
+			r.announceChange(*change)
+		}
+		if signal {
+			r.signalListener()
+		}
+	}()
 
 	r.m.Lock()
 	defer r.m.Unlock()
 	addrstr := list.FromAddrToString(addrs)
 
 	// experimental, let's skip changes in case of 0 records,
-	// to avoid cleaning state in case of errors
+	// to avoid cleaning state in case of errors, unless the
+	// addresses outlived their TTL
 	if len(addrstr) == 0 {
+		if active, _ := r.maintenance(); active {
+			return resolver.State{}, false
+		}
+		st, e, isUpdated := r.expire()
+		if isUpdated {
+			change = &e
+			signal = r.notify()
+		}
+		return st, isUpdated
+	}
+
+	r.touch(addrstr)
+
+	if hasDiff := r.compare(r.Addresses, addrstr); !hasDiff && !(cnameChanged && r.forcePublishOnCNAME) {
 		return resolver.State{}, false
 	}
 
-	if hasDiff := list.CompareListStr(r.Addresses, addrstr); !hasDiff {
+	if active, frozen := r.maintenance(); frozen {
+		log.Println("[grpc-resolver]: maintenance window, holding the changes of ", r.address)
 		return resolver.State{}, false
+	} else if active {
+		if addrs, addrstr = r.keepRemoved(addrs, addrstr); !r.compare(r.Addresses, addrstr) && !(cnameChanged && r.forcePublishOnCNAME) {
+			return resolver.State{}, false
+		}
 	}
 
-	r.Addresses = addrstr
+	if cnameChanged && r.forcePublishOnCNAME {
+		r.lastState = nil // otherwise the identical state would be suppressed
+	}
 
-	if r.listener != nil {
-		// let know to the listener the Addresses were updated
-		r.listener <- true
+	added, removed := list.DiffListStr(r.Addresses, addrstr)
+	r.Addresses = addrstr
+	r.setResolved(addrs)
+	addrs = r.applyStableOrder(addrs)
+	e := r.recordChange(added, removed)
+	change = &e
+	if r.reverseLookup {
+		log.Println("[grpc-resolver]: addresses updated ", r.hostnames)
 	}
 
+	signal = r.notify()
 	return resolver.State{Addresses: addrs}, true
 }
 
+// notify lets know to the subscribers the Addresses were updated and
+// returns whether the listener has to be signaled with signalListener,
+// it's expected to be called with the lock held
+func (r *DomainResolver) notify() bool {
+	r.sendLatest()
+	return r.listener != nil && !r.closed && !r.isShutdown()
+}
+
+// signalListener lets know to the listener the Addresses were updated, it
+// waits for the listener to be read unless the resolver is closed, it's
+// expected to be called without the lock held
+func (r *DomainResolver) signalListener() {
+	r.lm.Lock()
+	defer r.lm.Unlock()
+	select {
+	case <-r.closing:
+		return
+	default:
+	}
+
+	select {
+	case r.listener <- true:
+		r.access()
+	case <-r.closing:
+	}
+}
+
+// prepare runs the addresses through the steps every published list
+// goes through whatever its source: port mapping, validation, cap, load
+// weights, localities and transport hints
+func (r *DomainResolver) prepare(addrs []resolver.Address) []resolver.Address {
+	return r.attachTransport(r.attachLocality(r.attachLoad(r.capAddresses(r.validate(r.mapPorts(addrs))))))
+}
+
 // resolve resolves the domain looking for
 // the Ipv4 and Ipv6 records
-func (r *DomainResolver) resolve() []resolver.Address {
+func (r *DomainResolver) resolve(ctx context.Context) []resolver.Address {
 	addrs := []resolver.Address{}
 	if r.needLookup {
-		ips := lookUpByIP(r.address)
-		for _, ip := range ips {
-			addr := ip + ":" + r.port
-			addrs = append(addrs, resolver.Address{Addr: addr})
+		start := time.Now()
+		hostnames := map[string][]string{}
+		ctx, hits := withHostsHits(ctx)
+		ctx, answers := withDNSAnswers(ctx)
+		hostSources := map[string]string{}
+		for _, hp := range r.hostPorts(ctx) {
+			hostSources[splitHost(hp)] = r.sourceOf(hp, hits)
+			addr := resolver.Address{Addr: hp}
+			if r.reverseLookup {
+				names := lookUpAddr(ctx, r.resolverFor(ctx), splitHost(hp))
+				addr.Attributes = attributes.New(hostnamesKey{}, names)
+				hostnames[addr.Addr] = names
+			}
+			addrs = append(addrs, addr)
+		}
+		addrs = r.prepare(addrs)
+		sources := make(map[string]string, len(addrs))
+		for _, a := range addrs {
+			sources[a.Addr] = hostSources[splitHost(a.Addr)]
+		}
+
+		e := Event{Type: EventResolution, Time: start, DurationMs: float64(time.Since(start)) / float64(time.Millisecond)}
+		e.Addresses = list.FromAddrToString(addrs)
+		e.DNS = answers.all()
+		if len(addrs) == 0 {
+			e.Error = strings.TrimSpace("no records found " + describeAnswers(ctx))
 		}
+		r.emit(e)
+
+		r.m.Lock()
+		if len(addrs) > 0 {
+			r.lastRefresh = start
+			r.recordProvenance(sources, start)
+		}
+		if r.reverseLookup {
+			r.hostnames = hostnames
+		}
+		r.dnsAnswers = e.DNS
+		r.m.Unlock()
 	}
 
 	return addrs
 }
 
+// queryNames returns the names to look up in order of preference
+// according to the FQDN and search domains configuration
+func (r *DomainResolver) queryNames() []string {
+	name := r.lookupName()
+	if r.fqdn {
+		return []string{strings.TrimSuffix(name, ".") + "."}
+	}
+
+	if len(r.searchDomains) == 0 || strings.HasSuffix(name, ".") {
+		return []string{name}
+	}
+
+	names := []string{}
+	for _, d := range r.searchDomains {
+		names = append(names, name+"."+strings.Trim(d, ".")+".")
+	}
+
+	return append(names, name)
+}
+
+// refreshCNAME looks up the canonical name of the domain when
+// the tracking is enabled, returns true if it changed since the last lookup
+func (r *DomainResolver) refreshCNAME(ctx context.Context) (changed bool) {
+	if !r.trackCNAME || !r.needLookup {
+		return false
+	}
+
+	name := r.lookupName()
+	cname, err := r.resolverFor(ctx).LookupCNAME(ctx, name)
+	if err != nil {
+		log.Println("[grpc-resolver]: error looking up for cname ", err)
+		return false
+	}
+	chain := r.lookupCNAMEChain(ctx, name, cname)
+	cname = chain[len(chain)-1] // the Go resolver may stop at the first hop
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.cname == cname && strings.Join(r.cnameChain, " ") == strings.Join(chain, " ") {
+		return false
+	}
+
+	if r.cname != "" {
+		log.Printf("[grpc-resolver]: canonical name chain changed from %s to %s", strings.Join(r.cnameChain, " -> "), strings.Join(chain, " -> "))
+	}
+
+	r.cname = cname
+	r.cnameChain = chain
+	return true
+}
+
+// lookupCNAMEChain returns the names from the domain to its canonical name,
+// the intermediate ones are read from the CNAME records of a query to
+// the DNS server of the resolver, or the first system server, only the
+// domain and the canonical name are returned if it fails
+func (r *DomainResolver) lookupCNAMEChain(ctx context.Context, name, cname string) []string {
+	name = strings.TrimSuffix(name, ".") + "."
+	if strings.EqualFold(name, cname) {
+		return []string{name}
+	}
+
+	fallback := []string{name, cname}
+	server := r.dnsServer
+	if server == "" {
+		servers, err := SystemServers()
+		if err != nil || len(servers) == 0 {
+			return fallback
+		}
+		server = servers[0]
+	}
+
+	ex, err := exchange(ctx, dialWith(r.dialer), server, name, dnsmessage.TypeA)
+	if err != nil {
+		return fallback
+	}
+
+	targets := map[string]string{}
+	for _, a := range ex.answers {
+		if c, ok := a.Body.(*dnsmessage.CNAMEResource); ok {
+			targets[strings.ToLower(a.Header.Name.String())] = c.CNAME.String()
+		}
+	}
+
+	chain := []string{name}
+	for len(chain) <= len(targets) { // bounded, the records may loop
+		next, ok := targets[strings.ToLower(chain[len(chain)-1])]
+		if !ok {
+			break
+		}
+		chain = append(chain, next)
+	}
+
+	return chain
+}
+
+// CanonicalName returns the last canonical name seen for the domain,
+// only populated when the resolver is created WithCNAMETracking
+func (r *DomainResolver) CanonicalName() string {
+	r.access()
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.cname
+}
+
+// Hostnames returns the PTR hostnames of the last resolved addresses,
+// only populated when the resolver is created WithReverseLookup
+func (r *DomainResolver) Hostnames() map[string][]string {
+	r.access()
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	hostnames := make(map[string][]string, len(r.hostnames))
+	for addr, names := range r.hostnames {
+		hostnames[addr] = names
+	}
+
+	return hostnames
+}
+
+// HostnamesFromAddress returns the PTR hostnames attached
+// to a gRPC address, nil if the address has none
+func HostnamesFromAddress(addr resolver.Address) []string {
+	if addr.Attributes == nil {
+		return nil
+	}
+
+	names, _ := addr.Attributes.Value(hostnamesKey{}).([]string)
+	return names
+}
+
 // watch watches every X secods for changes in the domain
-// in order to update the state if enabled
+// in order to update the state if enabled,
+// restarting it after panics according to the panic policy
 func (r *DomainResolver) watch() {
+	defer unregister(r)
+	backoff := minPanicBackoff
+	for {
+		err := r.runWatcher()
+		if err == nil || !r.handlePanic(err, backoff) {
+			return
+		}
+		backoff = nextPanicBackoff(backoff)
+	}
+}
+
+// watchLoop refreshes the state on every tick until the resolver is closed
+func (r *DomainResolver) watchLoop() {
+	if r.spreadRefresh && !r.waitPhase() {
+		r.ticker.Stop()
+		return
+	}
+
+	var check <-chan time.Time
+	if r.catchUpRefresh {
+		checker := time.NewTicker(catchUpRate)
+		defer checker.Stop()
+		check = checker.C
+	}
+	lastCheck := time.Now().Round(0)
+
 	for {
 		select {
 		case <-r.isDone:
 			r.ticker.Stop()
 			return
-		case <-r.ticker.C:
-			st, apply := r.getState()
-			if apply && r.updateState { // only applicable for gRPC
-				r.cc.UpdateState(st)
-			}
+		case <-r.ctx.Done():
+			r.ticker.Stop()
+			return
+		case now := <-r.ticker.C:
+			r.checkSkew(now)
+			r.refreshTick()
+		case now := <-check:
+			r.catchUp(now, lastCheck)
+			lastCheck = now.Round(0)
 		}
 	}
 }
 
 // lookUpByIP ...
-func lookUpByIP(host string) []string {
-	ips, err := net.LookupIP(host)
+func lookUpByIP(ctx context.Context, res *net.Resolver, host string) []string {
+	ips, err := res.LookupIP(ctx, refreshConfigFrom(ctx).network(), host)
 	if err != nil {
-		log.Println("[grpc-resolver]: error looking up for ips ", err)
+		log.Println("[grpc-resolver]: error looking up for ips ", err, describeAnswers(ctx))
 		return []string{}
 	}
 
 	return pushRecords(ips)
 }
 
+// lookUpAddr returns the PTR hostnames for the given ip,
+// an empty list is returned in case of errors
+func lookUpAddr(ctx context.Context, res *net.Resolver, ip string) []string {
+	names, err := res.LookupAddr(ctx, ip)
+	if err != nil {
+		log.Println("[grpc-resolver]: error looking up for hostnames ", err)
+		return []string{}
+	}
+
+	return names
+}
+
 func pushRecords(ips []net.IP) []string {
-	records := []string{}
+	records := make([]string, 0, len(ips))
 	for _, ip := range ips {
-		if ip.To4() != nil {
-			records = append(records, ip.String())
-		} else {
-			records = append(records, "["+ip.String()+"]")
-		}
+		records = append(records, ip.String())
 	}
 
 	return records
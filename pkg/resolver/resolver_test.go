@@ -1,10 +1,16 @@
 package resolver
 
 import (
+	"bytes"
+	"context"
+	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
 	"google.golang.org/grpc/resolver"
 )
 
@@ -20,6 +26,24 @@ func TestNewResolver(t *testing.T) {
 	assert.Equal(t, 1, len(r.Addresses))
 }
 
+func TestResolverModes(t *testing.T) {
+	listener := make(chan bool, 1)
+	r := NewStandaloneResolver("127.0.0.1", "8080", false, &refreshRate, listener)
+	assert.False(t, r.updateState)
+	assert.Nil(t, r.cc)
+	r.StartResolver()
+	assert.Equal(t, []string{"127.0.0.1:8080"}, r.Addresses)
+
+	cc := resolvertest.NewClientConn()
+	r = NewGRPCResolver(cc, "127.0.0.1", "8080", false, &refreshRate)
+	assert.True(t, r.updateState)
+	assert.Empty(t, cc.States())
+	r.StartResolver()
+	st, ok := cc.LastState()
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.1:8080", st.Addresses[0].Addr)
+}
+
 func TestStartResolver(t *testing.T) {
 	r := NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
 	r.StartResolver()
@@ -67,17 +91,17 @@ func TestCloseResolver(t *testing.T) {
 func TestGetState(t *testing.T) {
 	r := NewResolver("no-domain1234.com", "8080", false, &refreshRate, nil)
 	r.StartResolver()
-	state, isUpdated := r.getState()
+	state, isUpdated := r.getState(context.Background())
 	assert.Equal(t, 0, len(state.Addresses))
 	assert.False(t, isUpdated)
 
 	r.address = "localhost"
-	state, isUpdated = r.getState()
+	state, isUpdated = r.getState(context.Background())
 	assert.True(t, len(state.Addresses) > 0)
 	assert.True(t, isUpdated)
 
 	r.address = "127.0.0.1"
-	state, isUpdated = r.getState()
+	state, isUpdated = r.getState(context.Background())
 	assert.True(t, len(state.Addresses) > 0)
 	assert.True(t, isUpdated)
 
@@ -85,7 +109,7 @@ func TestGetState(t *testing.T) {
 	c := make(chan bool)
 	r = NewResolver("localhost", "8080", false, &refreshRate, c)
 	r.StartResolver()
-	state, isUpdated = r.getState()
+	state, isUpdated = r.getState(context.Background())
 	assert.False(t, len(state.Addresses) > 0)
 	assert.False(t, isUpdated)
 
@@ -94,7 +118,7 @@ func TestGetState(t *testing.T) {
 	r.Addresses = []string{"127.0.0.1"}
 
 	go func() {
-		r.getState()
+		r.getState(context.Background())
 	}()
 	<-c
 }
@@ -122,3 +146,135 @@ func TestWatchResolverFromBuilder(t *testing.T) {
 	<-parsed.ticker.C
 	assert.True(t, len(parsed.Addresses) > 0)
 }
+
+func TestReverseLookup(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithReverseLookup())
+	addrs := r.resolve(context.Background())
+	assert.True(t, len(addrs) > 0)
+	for _, a := range addrs {
+		assert.Equal(t, r.Hostnames()[a.Addr], HostnamesFromAddress(a))
+	}
+
+	assert.Nil(t, HostnamesFromAddress(resolver.Address{Addr: "127.0.0.1:8080"}))
+	assert.Equal(t, []string{}, lookUpAddr(context.Background(), net.DefaultResolver, "not-an-ip"))
+}
+
+func TestCNAMETracking(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithCNAMETracking(true))
+	r.StartResolver()
+	assert.NotEmpty(t, r.CanonicalName())
+	assert.False(t, r.refreshCNAME(context.Background()))
+
+	// a canonical name change forces the publication of the same addresses
+	r.cname = "old.localhost."
+	state, isUpdated := r.getState(context.Background())
+	assert.True(t, isUpdated)
+	assert.True(t, len(state.Addresses) > 0)
+
+	r = NewResolver("127.0.0.1", "8080", false, &refreshRate, nil, WithCNAMETracking(false))
+	assert.False(t, r.refreshCNAME(context.Background()))
+	assert.Equal(t, "", r.CanonicalName())
+}
+
+func TestResolveSearchDomains(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithSearchDomains("no-domain1234.com"))
+	assert.True(t, len(r.resolve(context.Background())) > 0)
+}
+
+func TestPassthroughFromBuilder(t *testing.T) {
+	rb := NewDomainResolverBuilder("my-schema", "my-service", "8080", true, &refreshRate, WithPassthrough())
+	rr, err := rb.Build(resolver.Target{Scheme: "my-schema", Endpoint: "my-service:8080"}, &TestResolver{}, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"my-service:8080"}, rr.(*DomainResolver).Addresses)
+	rr.Close()
+}
+
+func TestRefresh(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	rb := NewDomainResolverBuilder("my-schema", "localhost", "8080", false, nil)
+	rr, err := rb.Build(resolver.Target{Scheme: "my-schema", Endpoint: "localhost:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+
+	r := rr.(*DomainResolver)
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.Refresh()
+	// same state than the published at start
+	assert.Equal(t, 1, len(cc.States()))
+
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.lastState = &resolver.State{}
+	r.Refresh()
+	assert.Equal(t, 2, len(cc.States()))
+
+	// nothing to refresh for IPs
+	r = NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	r.Refresh()
+}
+
+// serveCNAMEChain answers every query of my-service.test with the chain
+// my-service.test -> edge.cdn.test -> <last>.cdn.test and an A record
+func serveCNAMEChain(pc net.PacketConn, last *atomic.Value) {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		query := dnsmessage.Message{}
+		if err := query.Unpack(buf[:n]); err != nil {
+			continue
+		}
+
+		q := query.Questions[0]
+		answer := dnsmessage.Message{Header: dnsmessage.Header{ID: query.ID, Response: true}, Questions: query.Questions}
+		chain := []string{q.Name.String(), "edge.cdn.test.", last.Load().(string) + ".cdn.test."}
+		hops := len(chain) - 1
+		if q.Type == dnsmessage.TypeCNAME {
+			hops = 1 // only the record of the name
+		}
+		for i := 0; i < hops; i++ {
+			answer.Answers = append(answer.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(chain[i]), Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(chain[i+1])},
+			})
+		}
+		if q.Type == dnsmessage.TypeA {
+			answer.Answers = append(answer.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(chain[len(chain)-1]), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}},
+			})
+		}
+
+		packed, _ := answer.Pack()
+		pc.WriteTo(packed, addr)
+	}
+}
+
+func TestCNAMEChain(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer pc.Close()
+	last := &atomic.Value{}
+	last.Store("eu")
+	go serveCNAMEChain(pc, last)
+
+	log := &bytes.Buffer{}
+	r := NewResolver("my-service.test", "8080", false, &refreshRate, nil, WithCNAMETracking(true), WithDNSServer(pc.LocalAddr().String()), WithEventLog(log))
+	r.StartResolver()
+	assert.Equal(t, "eu.cdn.test.", r.CanonicalName())
+	assert.Equal(t, []string{"my-service.test.", "edge.cdn.test.", "eu.cdn.test."}, r.Status().CNAMEChain)
+
+	// the change of a hop is a change of the chain, published with it
+	last.Store("us")
+	r.Refresh()
+	assert.Equal(t, []string{"my-service.test.", "edge.cdn.test.", "us.cdn.test."}, r.Status().CNAMEChain)
+
+	var change Event
+	for _, line := range bytes.Split(bytes.TrimSpace(log.Bytes()), []byte("\n")) {
+		if e, err := UnmarshalEvent(line); err == nil && e.Type == EventChange {
+			change = e
+		}
+	}
+	assert.Equal(t, []string{"my-service.test.", "edge.cdn.test.", "us.cdn.test."}, change.CNAMEChain)
+}
@@ -0,0 +1,92 @@
+// Package resolvertest provides utilities for testing code
+// that embeds the dm-resolver in gRPC clients
+package resolvertest
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// ClientConn is a fake resolver.ClientConn that records every
+// state update and error reported by the resolver, it's safe
+// to be used by the resolver watcher and the test concurrently
+type ClientConn struct {
+	m      sync.Mutex
+	states []resolver.State
+	errs   []error
+	notify chan struct{}
+}
+
+// NewClientConn creates a new fake ClientConn
+func NewClientConn() *ClientConn {
+	return &ClientConn{notify: make(chan struct{}, 1)}
+}
+
+// UpdateState records the given state
+func (c *ClientConn) UpdateState(s resolver.State) {
+	c.m.Lock()
+	c.states = append(c.states, s)
+	c.m.Unlock()
+	c.signal()
+}
+
+// ReportError records the given error
+func (c *ClientConn) ReportError(err error) {
+	c.m.Lock()
+	c.errs = append(c.errs, err)
+	c.m.Unlock()
+	c.signal()
+}
+
+// NewAddress records the addresses as a new state
+func (c *ClientConn) NewAddress(addresses []resolver.Address) {
+	c.UpdateState(resolver.State{Addresses: addresses})
+}
+
+// NewServiceConfig ...
+func (c *ClientConn) NewServiceConfig(serviceConfig string) {}
+
+// ParseServiceConfig ...
+func (c *ClientConn) ParseServiceConfig(serviceConfigJSON string) *serviceconfig.ParseResult {
+	return &serviceconfig.ParseResult{}
+}
+
+// States returns all the states recorded so far
+func (c *ClientConn) States() []resolver.State {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return append([]resolver.State{}, c.states...)
+}
+
+// LastState returns the last state recorded, false if there is none
+func (c *ClientConn) LastState() (resolver.State, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if len(c.states) == 0 {
+		return resolver.State{}, false
+	}
+
+	return c.states[len(c.states)-1], true
+}
+
+// Errors returns all the errors recorded so far
+func (c *ClientConn) Errors() []error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return append([]error{}, c.errs...)
+}
+
+// Updated returns a channel that receives a value after a state
+// update or an error is recorded, meant to wait for the watcher
+func (c *ClientConn) Updated() <-chan struct{} {
+	return c.notify
+}
+
+func (c *ClientConn) signal() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
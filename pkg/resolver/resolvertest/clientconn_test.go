@@ -0,0 +1,29 @@
+package resolvertest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestClientConn(t *testing.T) {
+	cc := NewClientConn()
+	_, ok := cc.LastState()
+	assert.False(t, ok)
+
+	cc.UpdateState(resolver.State{Addresses: []resolver.Address{{Addr: "127.0.0.1:8080"}}})
+	<-cc.Updated()
+	cc.NewAddress([]resolver.Address{{Addr: "127.0.0.2:8080"}})
+	cc.NewServiceConfig("{}")
+	assert.NotNil(t, cc.ParseServiceConfig("{}"))
+
+	st, ok := cc.LastState()
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.2:8080", st.Addresses[0].Addr)
+	assert.Equal(t, 2, len(cc.States()))
+
+	cc.ReportError(errors.New("lookup error"))
+	assert.Equal(t, []error{errors.New("lookup error")}, cc.Errors())
+}
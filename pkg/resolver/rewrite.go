@@ -0,0 +1,110 @@
+package resolver
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RewriteRule rewrites the names matching the pattern before the lookup,
+// the pattern must match the whole name and the replacement can refer to
+// its groups, e.g. {`([a-z-]+)`, "$1.prod.internal.example.com"}
+type RewriteRule struct {
+	Pattern     string
+	Replacement string
+}
+
+type rewriteRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// Rewriter holds the rewrite rules of one or more resolvers, so the
+// application can use short logical names everywhere, the rules can be
+// replaced at runtime and apply from the next refresh
+type Rewriter struct {
+	m     sync.RWMutex
+	rules []rewriteRule
+}
+
+// NewRewriter creates a rewriter with the given rules, an error is
+// returned if a pattern is invalid
+func NewRewriter(rules ...RewriteRule) (*Rewriter, error) {
+	w := &Rewriter{}
+	return w, w.Set(rules...)
+}
+
+// Set replaces the rules, they are kept if a pattern is invalid
+func (w *Rewriter) Set(rules ...RewriteRule) error {
+	parsed := make([]rewriteRule, 0, len(rules))
+	for _, rule := range rules {
+		re, err := regexp.Compile("^(?:" + rule.Pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid rewrite pattern %q: %v", rule.Pattern, err)
+		}
+		parsed = append(parsed, rewriteRule{re: re, replacement: rule.Replacement})
+	}
+
+	w.m.Lock()
+	w.rules = parsed
+	w.m.Unlock()
+	return nil
+}
+
+// Load replaces the rules with the ones of the file, a rule per line with
+// the pattern and the replacement separated by spaces, the empty lines
+// and the ones starting with # are ignored
+func (w *Rewriter) Load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rules := []RewriteRule{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid rewrite rule %q", line)
+		}
+		rules = append(rules, RewriteRule{Pattern: fields[0], Replacement: fields[1]})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return w.Set(rules...)
+}
+
+// Rewrite returns the name rewritten by the first matching rule, the name
+// itself if none matches
+func (w *Rewriter) Rewrite(name string) string {
+	w.m.RLock()
+	defer w.m.RUnlock()
+	for _, rule := range w.rules {
+		if rule.re.MatchString(name) {
+			return rule.re.ReplaceAllString(name, rule.replacement)
+		}
+	}
+
+	return name
+}
+
+// lookupName returns the name looked up for the target
+func (r *DomainResolver) lookupName() string {
+	if r.rewriter == nil {
+		return r.address
+	}
+
+	return r.rewriter.Rewrite(r.address)
+}
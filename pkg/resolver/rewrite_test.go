@@ -0,0 +1,99 @@
+package resolver
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestRewriter(t *testing.T) {
+	w, err := NewRewriter(RewriteRule{`service-[a-z]`, "$0.prod.internal.example.com"}, RewriteRule{`(.+)\.legacy`, "$1.example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, "service-a.prod.internal.example.com", w.Rewrite("service-a"))
+	assert.Equal(t, "billing.example.com", w.Rewrite("billing.legacy"))
+
+	// the patterns match the whole name
+	assert.Equal(t, "service-ab", w.Rewrite("service-ab"))
+
+	_, err = NewRewriter(RewriteRule{`(`, "x"})
+	assert.NotNil(t, err)
+
+	// invalid rules keep the current ones
+	assert.NotNil(t, w.Set(RewriteRule{`[`, "x"}))
+	assert.Equal(t, "billing.example.com", w.Rewrite("billing.legacy"))
+}
+
+func TestRewriterLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rewrite")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rules")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("# logical names\nservice-a   localhost\n\n"), 0644))
+	w, _ := NewRewriter()
+	assert.Nil(t, w.Load(path))
+	assert.Equal(t, "localhost", w.Rewrite("service-a"))
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("service-a\n"), 0644))
+	assert.NotNil(t, w.Load(path))
+	assert.NotNil(t, w.Load(filepath.Join(dir, "missing")))
+}
+
+func TestWithRewriter(t *testing.T) {
+	w, _ := NewRewriter(RewriteRule{"service-a", "localhost"})
+	r := NewResolver("service-a", "8080", false, &refreshRate, nil, WithRewriter(w))
+	assert.Equal(t, []string{"localhost"}, r.queryNames())
+	assert.NotEmpty(t, r.resolve(context.Background()))
+
+	// the rules apply from the next refresh
+	assert.Nil(t, w.Set(RewriteRule{"service-a", "service-b"}))
+	assert.Equal(t, []string{"service-b"}, r.queryNames())
+	assert.Equal(t, "service-a", r.Status().Address)
+}
+
+func TestRewriterHostnameFallback(t *testing.T) {
+	w, _ := NewRewriter(RewriteRule{"service-a", "service-b"})
+	cc := resolvertest.NewClientConn()
+	rb := NewDomainResolverBuilder("test-schema", "service-a", "8080", false, nil, WithRewriter(w), WithHostnameFallback(),
+		WithHosts(map[string][]string{"service-b": nil}, true))
+	_, err := rb.Build(resolver.Target{Scheme: "test-schema", Endpoint: "service-a:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+
+	st, _ := cc.LastState()
+	assert.Equal(t, "service-b:8080", st.Addresses[len(st.Addresses)-1].Addr)
+}
+
+func TestRewriterAuthoritative(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	// only the zone of the rewritten name has name servers
+	zoneServers.Lock()
+	zoneServers.zones["rewritten.test"] = nsZone{servers: []string{lis.Addr().String()}, expires: time.Now().Add(time.Minute)}
+	zoneServers.Unlock()
+
+	w, _ := NewRewriter(RewriteRule{"service-a", "api.rewritten.test"})
+	r := NewResolver("service-a", "8080", false, &refreshRate, nil, WithRewriter(w), WithAuthoritative(), WithForceTCP())
+	zoneServers.Lock()
+	zoneServers.zones["api.rewritten.test"] = nsZone{expires: time.Now().Add(time.Minute)}
+	zoneServers.Unlock()
+
+	conn, err := r.netResolver.Dial(context.Background(), "udp", "127.0.0.53:53")
+	assert.Nil(t, err)
+	assert.Equal(t, lis.Addr().String(), conn.RemoteAddr().String())
+	conn.Close()
+
+	conn, err = r.resolverFor(withRefreshConfig(context.Background(), refreshConfig{bypassCache: true})).Dial(context.Background(), "udp", "127.0.0.53:53")
+	assert.Nil(t, err)
+	assert.Equal(t, lis.Addr().String(), conn.RemoteAddr().String())
+	conn.Close()
+}
@@ -0,0 +1,73 @@
+package resolver
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the version of the JSON representation of the
+// snapshots and events, it's increased on incompatible changes only,
+// new optional fields can be added without changing it, the JSON
+// schemas of every version are in the schema directory
+const SchemaVersion = "v1"
+
+// snapshotJSON is the JSON representation of a Snapshot,
+// the gRPC state is not included
+type snapshotJSON struct {
+	Schema    string   `json:"schema"`
+	Target    string   `json:"target"`
+	Version   uint64   `json:"version"`
+	Addresses []string `json:"addresses"`
+}
+
+// MarshalSnapshot returns the JSON representation of the snapshot
+func MarshalSnapshot(s Snapshot) ([]byte, error) {
+	addrs := s.Addresses
+	if addrs == nil {
+		addrs = []string{}
+	}
+
+	return json.Marshal(snapshotJSON{Schema: SchemaVersion, Target: s.Target, Version: s.Version, Addresses: addrs})
+}
+
+// UnmarshalSnapshot parses the JSON representation of a snapshot,
+// an error is returned if the schema version is not supported
+func UnmarshalSnapshot(data []byte) (Snapshot, error) {
+	sj := snapshotJSON{}
+	if err := json.Unmarshal(data, &sj); err != nil {
+		return Snapshot{}, err
+	}
+
+	if err := checkSchema(sj.Schema); err != nil {
+		return Snapshot{}, err
+	}
+
+	return Snapshot{Target: sj.Target, Version: sj.Version, Addresses: sj.Addresses}, nil
+}
+
+// MarshalEvent returns the JSON representation of the event
+func MarshalEvent(e Event) ([]byte, error) {
+	e.Schema = SchemaVersion
+	return json.Marshal(e)
+}
+
+// UnmarshalEvent parses the JSON representation of an event,
+// an error is returned if the schema version is not supported
+func UnmarshalEvent(data []byte) (Event, error) {
+	e := Event{}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Event{}, err
+	}
+
+	return e, checkSchema(e.Schema)
+}
+
+// checkSchema returns an error if the schema version is not supported,
+// no version is accepted for the output of the older releases
+func checkSchema(schema string) error {
+	if schema != "" && schema != SchemaVersion {
+		return fmt.Errorf("unsupported schema version %q, expected %s", schema, SchemaVersion)
+	}
+
+	return nil
+}
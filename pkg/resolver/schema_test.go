@@ -0,0 +1,56 @@
+package resolver
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalSnapshot(t *testing.T) {
+	data, err := MarshalSnapshot(Snapshot{Target: "localhost:8080", Version: 3, Addresses: []string{"127.0.0.1:8080"}})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"schema":"v1","target":"localhost:8080","version":3,"addresses":["127.0.0.1:8080"]}`, string(data))
+
+	s, err := UnmarshalSnapshot(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost:8080", s.Target)
+	assert.Equal(t, uint64(3), s.Version)
+	assert.Equal(t, []string{"127.0.0.1:8080"}, s.Addresses)
+
+	data, err = MarshalSnapshot(Snapshot{Target: "localhost:8080"})
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"addresses":[]`)
+}
+
+func TestUnmarshalSnapshotSchema(t *testing.T) {
+	s, err := UnmarshalSnapshot([]byte(`{"target":"localhost:8080","version":1,"addresses":["127.0.0.1:8080"]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"127.0.0.1:8080"}, s.Addresses)
+
+	_, err = UnmarshalSnapshot([]byte(`{"schema":"v2","target":"localhost:8080"}`))
+	assert.NotNil(t, err)
+
+	_, err = UnmarshalSnapshot([]byte(`not json`))
+	assert.NotNil(t, err)
+}
+
+func TestMarshalEvent(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	data, err := MarshalEvent(Event{Type: EventChange, Time: now, Version: 2, Addresses: []string{"127.0.0.1:8080"}})
+	assert.Nil(t, err)
+
+	m := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(data, &m))
+	assert.Equal(t, "v1", m["schema"])
+
+	e, err := UnmarshalEvent(data)
+	assert.Nil(t, err)
+	assert.Equal(t, EventChange, e.Type)
+	assert.Equal(t, uint64(2), e.Version)
+	assert.True(t, now.Equal(e.Time))
+
+	_, err = UnmarshalEvent([]byte(`{"schema":"v0","type":"change"}`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,120 @@
+package resolver
+
+import (
+	"time"
+
+	"google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/resolver"
+)
+
+// HealthState is the result of the health checks of an address
+type HealthState int
+
+// health states
+const (
+	HealthUnknown HealthState = iota
+	HealthServing
+	HealthNotServing
+)
+
+// HealthSource returns the latest health check result of an address,
+// e.g. taken from the gRPC health service or a service registry
+type HealthSource func(addr string) HealthState
+
+// Signals are the inputs of the score of an address
+type Signals struct {
+	InDNS    bool          // the address was returned by the last resolution, false if bootstrapped or static
+	Health   HealthState   // latest health check result, HealthUnknown without a health source
+	Failures int           // consecutive connection failures, see ReportConnectResult
+	Age      time.Duration // time since the address was first seen
+}
+
+// Scorer returns the score of an address between 0 and 1 from its
+// signals, the higher the score the more traffic it should receive
+type Scorer func(addr string, s Signals) float64
+
+// scoreWarmup is the age after which an address is not penalized by
+// DefaultScorer anymore
+const scoreWarmup = 30 * time.Second
+
+// DefaultScorer halves the score of the addresses not returned by DNS,
+// divides it by the consecutive connection failures plus one, takes a
+// tenth of it for failing health checks and ramps up the new addresses
+// from half the score during their first 30 seconds
+func DefaultScorer(addr string, s Signals) float64 {
+	score := 1.0
+	if !s.InDNS {
+		score /= 2
+	}
+
+	score /= float64(s.Failures + 1)
+	if s.Health == HealthNotServing {
+		score /= 10
+	}
+
+	if s.Age < scoreWarmup {
+		score *= 0.5 + 0.5*float64(s.Age)/float64(scoreWarmup)
+	}
+
+	return score
+}
+
+type scoreKey struct{}
+
+// ScoreFrom returns the score attached to the address, false if the
+// scoring is not enabled
+func ScoreFrom(addr resolver.Address) (float64, bool) {
+	score, ok := addr.Attributes.Value(scoreKey{}).(float64)
+	return score, ok
+}
+
+// applyScores attaches the score of every address and sets its weight
+// for the weighted balancers, between 1 and 100 times the load weight
+// if any, the published score is kept while it gives the same weight so
+// a drifting score (e.g. by the age) doesn't recreate the SubConn, it's
+// expected to be called with the lock held
+func (r *DomainResolver) applyScores(addrs []resolver.Address, now time.Time) []resolver.Address {
+	if r.scorer == nil {
+		return addrs
+	}
+
+	scored := make([]resolver.Address, len(addrs))
+	for i, a := range addrs {
+		s := Signals{Failures: r.failures[a.Addr]}
+		if p, ok := r.provenance[a.Addr]; ok {
+			s.InDNS = p.Source != SourceBootstrap && p.Source != SourceStatic
+			s.Age = now.Sub(p.FirstSeen)
+		}
+		if r.healthSource != nil {
+			s.Health = r.healthSource(a.Addr)
+		}
+
+		score := r.scorer(a.Addr, s)
+		switch {
+		case score < 0:
+			score = 0
+		case score > 1:
+			score = 1
+		}
+
+		if prev, ok := ScoreFrom(r.lastAddresses[a.Addr]); ok && scoreWeight(prev) == scoreWeight(score) {
+			score = prev
+		}
+
+		weight := scoreWeight(score)
+		if info := weightedroundrobin.GetAddrInfo(a); info.Weight > 0 {
+			weight *= info.Weight
+		}
+
+		a = weightedroundrobin.SetAddrInfo(a, weightedroundrobin.AddrInfo{Weight: weight})
+		a.Attributes = a.Attributes.WithValues(scoreKey{}, score)
+		scored[i] = a
+	}
+
+	return scored
+}
+
+// scoreWeight returns the weight of the score, between 1 and 100
+func scoreWeight(score float64) uint32 {
+	return uint32(1 + score*99)
+}
@@ -0,0 +1,95 @@
+package resolver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer/weightedroundrobin"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestDefaultScorer(t *testing.T) {
+	assert.Equal(t, 1.0, DefaultScorer("10.0.0.1:80", Signals{InDNS: true, Age: time.Minute}))
+	assert.Equal(t, 0.5, DefaultScorer("10.0.0.1:80", Signals{InDNS: false, Age: time.Minute}))
+	assert.Equal(t, 0.25, DefaultScorer("10.0.0.1:80", Signals{InDNS: true, Failures: 3, Age: time.Minute}))
+	assert.InDelta(t, 0.1, DefaultScorer("10.0.0.1:80", Signals{InDNS: true, Health: HealthNotServing, Age: time.Minute}), 1e-9)
+	assert.Equal(t, 1.0, DefaultScorer("10.0.0.1:80", Signals{InDNS: true, Health: HealthServing, Age: time.Minute}))
+
+	// new addresses ramp up
+	assert.Equal(t, 0.5, DefaultScorer("10.0.0.1:80", Signals{InDNS: true}))
+	assert.Equal(t, 0.75, DefaultScorer("10.0.0.1:80", Signals{InDNS: true, Age: scoreWarmup / 2}))
+}
+
+func TestApplyScores(t *testing.T) {
+	health := func(addr string) HealthState {
+		if addr == "10.0.0.2:80" {
+			return HealthNotServing
+		}
+		return HealthServing
+	}
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithScoring(nil, health))
+	now := time.Now()
+	r.recordProvenance(map[string]string{"10.0.0.1:80": SourceA, "10.0.0.2:80": SourceA, "10.0.0.3:80": SourceBootstrap}, now.Add(-time.Minute))
+
+	addrs := []resolver.Address{{Addr: "10.0.0.1:80"}, {Addr: "10.0.0.2:80"}, {Addr: "10.0.0.3:80"}}
+	addrs[0] = weightedroundrobin.SetAddrInfo(addrs[0], weightedroundrobin.AddrInfo{Weight: 2})
+	scored := r.applyScores(addrs, now)
+
+	score, ok := ScoreFrom(scored[0])
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, score)
+	assert.Equal(t, uint32(200), weightedroundrobin.GetAddrInfo(scored[0]).Weight)
+
+	score, _ = ScoreFrom(scored[1])
+	assert.InDelta(t, 0.1, score, 1e-9)
+	assert.Equal(t, uint32(10), weightedroundrobin.GetAddrInfo(scored[1]).Weight)
+
+	score, _ = ScoreFrom(scored[2])
+	assert.Equal(t, 0.5, score)
+
+	// the input is not modified
+	_, ok = ScoreFrom(addrs[1])
+	assert.False(t, ok)
+
+	// scores are clamped
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithScoring(func(string, Signals) float64 { return 7 }, nil))
+	score, _ = ScoreFrom(r.applyScores(addrs, now)[1])
+	assert.Equal(t, 1.0, score)
+
+	// disabled
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, addrs, r.applyScores(addrs, now))
+}
+
+func TestScoringFeedback(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "127.0.0.1", "8080", false, &refreshRate, WithScoring(nil, nil), WithConnectFeedback(5, time.Minute))
+	r.publish(resolver.State{Addresses: []resolver.Address{{Addr: "10.0.0.1:80"}}})
+	st, _ := cc.LastState()
+	before, ok := ScoreFrom(st.Addresses[0])
+	assert.True(t, ok)
+
+	// a failure lowers the score without waiting for a refresh
+	r.ReportConnectResult("10.0.0.1:80", errors.New("connection refused"))
+	st, _ = cc.LastState()
+	after, _ := ScoreFrom(st.Addresses[0])
+	assert.Equal(t, 2, len(cc.States()))
+	assert.True(t, after < before)
+}
+
+func TestScoresKeepSubConns(t *testing.T) {
+	// the score drifts a little on every call but the weight stays the same
+	calls := 0
+	drifting := func(string, Signals) float64 {
+		calls++
+		return 0.5 + float64(calls)*1e-6
+	}
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "churn.test", "8080", false, nil, WithHosts(map[string][]string{"churn.test": nil}, true), WithScoring(drifting, nil))
+	defer r.Close()
+
+	assertSubConnsKept(t, r, cc, []string{"10.0.0.1"}, []string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"})
+}
@@ -0,0 +1,178 @@
+package resolver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"google.golang.org/grpc/resolver"
+)
+
+// Divergence describes a difference between the primary
+// and the shadow address lists
+type Divergence struct {
+	OnlyPrimary []string // addresses only returned by the primary resolver
+	OnlyShadow  []string // addresses only returned by the shadow resolver
+	Time        time.Time
+}
+
+// ShadowStats holds the comparison counters of a ShadowCompare
+type ShadowStats struct {
+	Comparisons int
+	Divergences int
+	Last        *Divergence // last divergence found, nil if there was none
+}
+
+// ShadowCompare runs a shadow resolver next to the primary one for the
+// same target, the state is only published by the primary (the shadow
+// runs in dry run mode) and both address lists are compared every
+// interval, reporting the divergences to the onDivergence callback and as
+// warning events of the primary resolver, useful for safe migrations
+// between discovery sources
+type ShadowCompare struct {
+	m            sync.Mutex
+	primary      *DomainResolver
+	shadow       *DomainResolver
+	interval     time.Duration
+	onDivergence func(Divergence)
+	stats        ShadowStats
+	warned       string // last divergence emitted as a warning, avoids repeating it every interval
+	isDone       chan bool
+	closeOnce    sync.Once // makes Close safe to be called more than once
+}
+
+// NewShadowCompare creates a new ShadowCompare, the shadow resolver is
+// switched to dry run mode, onDivergence can be nil
+func NewShadowCompare(primary, shadow *DomainResolver, interval time.Duration, onDivergence func(Divergence)) *ShadowCompare {
+	shadow.dryRun = true
+	return &ShadowCompare{
+		primary:      primary,
+		shadow:       shadow,
+		interval:     interval,
+		onDivergence: onDivergence,
+		isDone:       make(chan bool),
+	}
+}
+
+// StartResolver starts both resolvers and the comparison loop
+func (s *ShadowCompare) StartResolver() {
+	s.primary.StartResolver()
+	s.shadow.StartResolver()
+	s.Compare()
+
+	if s.interval > 0 {
+		go s.watch()
+	}
+}
+
+// Compare compares the current address lists of both resolvers,
+// returns the divergence and true if they differ
+func (s *ShadowCompare) Compare() (Divergence, bool) {
+	onlyPrimary, onlyShadow := list.DiffListStr(s.shadow.Status().Addresses, s.primary.Status().Addresses)
+	d := Divergence{OnlyPrimary: onlyPrimary, OnlyShadow: onlyShadow, Time: time.Now()}
+	diverged := len(onlyPrimary) > 0 || len(onlyShadow) > 0
+
+	msg := ""
+	if diverged {
+		msg = fmt.Sprintf("shadow divergence, only primary %v, only shadow %v", onlyPrimary, onlyShadow)
+	}
+
+	s.m.Lock()
+	s.stats.Comparisons++
+	if diverged {
+		s.stats.Divergences++
+		s.stats.Last = &d
+	}
+	repeated := msg == s.warned
+	s.warned = msg
+	s.m.Unlock()
+
+	if diverged && !repeated {
+		s.primary.emit(Event{Type: EventWarning, Time: d.Time, Error: msg})
+	}
+
+	if diverged && s.onDivergence != nil {
+		s.onDivergence(d)
+	}
+
+	return d, diverged
+}
+
+// Stats returns the comparison counters
+func (s *ShadowCompare) Stats() ShadowStats {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.stats
+}
+
+// ResolveNow forwards the call to both resolvers
+func (s *ShadowCompare) ResolveNow(o resolver.ResolveNowOptions) {
+	s.primary.ResolveNow(o)
+	s.shadow.ResolveNow(o)
+}
+
+// Close stops the comparison loop and both resolvers
+func (s *ShadowCompare) Close() {
+	s.closeOnce.Do(func() { close(s.isDone) })
+	s.primary.Close()
+	s.shadow.Close()
+}
+
+func (s *ShadowCompare) watch() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.isDone:
+			return
+		case <-ticker.C:
+			s.Compare()
+		}
+	}
+}
+
+// ShadowCompareBuilder implements the resolver.Builder interface
+// building a ShadowCompare from two DomainResolverBuilder
+type ShadowCompareBuilder struct {
+	primary      *DomainResolverBuilder
+	shadow       *DomainResolverBuilder
+	interval     time.Duration
+	onDivergence func(Divergence)
+}
+
+// NewShadowCompareBuilder creates a new instance for the ShadowCompareBuilder,
+// the scheme is taken from the primary builder
+func NewShadowCompareBuilder(primary, shadow *DomainResolverBuilder, interval time.Duration, onDivergence func(Divergence)) *ShadowCompareBuilder {
+	return &ShadowCompareBuilder{primary, shadow, interval, onDivergence}
+}
+
+// Build ...
+func (b *ShadowCompareBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	sb := b.shadow
+	shadow := NewDomainResolverBuilder(sb.scheme, sb.address, sb.port, sb.needWatcher, sb.refreshRate, append(append([]Option{}, sb.opts...), WithDryRun())...)
+	sr, err := shadow.Build(target, cc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := b.primary.Build(target, cc, opts)
+	if err != nil {
+		sr.Close()
+		return nil, err
+	}
+
+	s := NewShadowCompare(pr.(*DomainResolver), sr.(*DomainResolver), b.interval, b.onDivergence)
+	s.Compare()
+	if s.interval > 0 {
+		go s.watch()
+	}
+
+	return s, nil
+}
+
+// Scheme ...
+func (b *ShadowCompareBuilder) Scheme() string {
+	return b.primary.Scheme()
+}
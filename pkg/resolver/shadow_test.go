@@ -0,0 +1,85 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestShadowCompare(t *testing.T) {
+	divergences := []Divergence{}
+	primary := NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	shadow := NewResolver("127.0.0.2", "8080", false, &refreshRate, nil)
+	s := NewShadowCompare(primary, shadow, 0, func(d Divergence) { divergences = append(divergences, d) })
+	assert.True(t, shadow.dryRun)
+	events := primary.WatchEvents()
+	assert.Equal(t, EventSnapshot, (<-events).Type)
+
+	s.StartResolver()
+	s.ResolveNow(resolver.ResolveNowOptions{})
+	assert.Equal(t, 1, len(divergences))
+	assert.Equal(t, []string{"127.0.0.1:8080"}, divergences[0].OnlyPrimary)
+	assert.Equal(t, []string{"127.0.0.2:8080"}, divergences[0].OnlyShadow)
+
+	warning := <-events
+	assert.Equal(t, EventWarning, warning.Type)
+	assert.Equal(t, "shadow divergence, only primary [127.0.0.1:8080], only shadow [127.0.0.2:8080]", warning.Error)
+
+	// the same divergence is only emitted once
+	s.Compare()
+	assert.Equal(t, 2, len(divergences))
+	assert.Equal(t, 0, len(events))
+
+	shadow.Addresses = []string{"127.0.0.1:8080"}
+	_, diverged := s.Compare()
+	assert.False(t, diverged)
+
+	stats := s.Stats()
+	assert.Equal(t, 3, stats.Comparisons)
+	assert.Equal(t, 2, stats.Divergences)
+	assert.NotNil(t, stats.Last)
+	s.Close()
+}
+
+func TestShadowCompareCloseTwice(t *testing.T) {
+	primary := NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	shadow := NewResolver("127.0.0.2", "8080", false, &refreshRate, nil)
+	s := NewShadowCompare(primary, shadow, time.Millisecond, nil)
+	s.StartResolver()
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		s.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second Close blocked")
+	}
+}
+
+func TestShadowCompareBuilder(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	primary := NewDomainResolverBuilder("shadow-schema", "127.0.0.1", "8080", false, nil)
+	shadow := NewDomainResolverBuilder("shadow-schema", "localhost", "8080", false, nil)
+	b := NewShadowCompareBuilder(primary, shadow, time.Millisecond, nil)
+	assert.Equal(t, "shadow-schema", b.Scheme())
+
+	rr, err := b.Build(resolver.Target{Scheme: "shadow-schema", Endpoint: "127.0.0.1:8080"}, cc, resolver.BuildOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(shadow.opts))
+
+	// only the primary publishes the state
+	assert.Equal(t, 1, len(cc.States()))
+	assert.Equal(t, "127.0.0.1:8080", cc.States()[0].Addresses[0].Addr)
+
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, rr.(*ShadowCompare).Stats().Comparisons > 1)
+	rr.Close()
+}
@@ -0,0 +1,61 @@
+package resolver
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// ShutdownReport summarizes the shutdown of a resolver
+type ShutdownReport struct {
+	WatcherStopped bool   // the watcher terminated, true if there was none
+	Version        uint64 // address list version at the shutdown
+	DroppedEvents  int    // webhook events never sent
+}
+
+// Shutdown closes the resolver and waits for the watcher and the webhook
+// worker to terminate, no events are delivered once Close returns, the
+// context error is returned with a partial report if it's done first
+func (r *DomainResolver) Shutdown(ctx context.Context) (ShutdownReport, error) {
+	r.Close()
+
+	report := ShutdownReport{}
+	var err error
+	if r.webhook != nil {
+		report.DroppedEvents, err = r.webhook.wait(ctx)
+	}
+
+	report.WatcherStopped = waitWatcher(ctx, r)
+	if !report.WatcherStopped {
+		err = ctx.Err()
+	}
+
+	r.m.Lock()
+	report.Version = r.version
+	r.m.Unlock()
+
+	return report, err
+}
+
+// waitWatcher waits for the watcher of the resolver to unregister
+func waitWatcher(ctx context.Context, r *DomainResolver) bool {
+	for {
+		registry.Lock()
+		_, running := registry.watchers[r]
+		registry.Unlock()
+		if !running {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// isShutdown returns true once Close was called
+func (r *DomainResolver) isShutdown() bool {
+	return atomic.LoadInt32(&r.shutdown) == 1
+}
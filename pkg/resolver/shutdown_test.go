@@ -0,0 +1,147 @@
+package resolver
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestShutdown(t *testing.T) {
+	rate := time.Duration(1)
+	r := NewResolver("localhost", "8080", true, &rate, nil)
+	r.StartResolver()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	report, err := r.Shutdown(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, ShutdownReport{WatcherStopped: true}, report)
+
+	// idempotent
+	report, err = r.Shutdown(ctx)
+	assert.Nil(t, err)
+	assert.True(t, report.WatcherStopped)
+}
+
+func TestShutdownDroppedEvents(t *testing.T) {
+	block := make(chan struct{})
+	received := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received <- struct{}{}
+		<-block
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithWebhook(srv.URL, 0))
+	r.StartResolver()
+	for i := 0; i < 3; i++ {
+		r.m.Lock()
+		r.Addresses = []string{"127.0.0.2:8080"}
+		r.m.Unlock()
+		r.getState(context.Background())
+	}
+
+	// the worker is stuck sending the first event, the POST is aborted
+	<-received
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	report, err := r.Shutdown(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), report.Version)
+	assert.Equal(t, 2, report.DroppedEvents)
+	assert.True(t, report.WatcherStopped)
+}
+
+func TestCloseAbortsWebhook(t *testing.T) {
+	aborted := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ioutil.ReadAll(req.Body) // the closed connection is noticed once the body is read
+		<-req.Context().Done()
+		close(aborted)
+	}))
+	defer srv.Close()
+
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithWebhook(srv.URL, 3))
+	r.StartResolver()
+	r.m.Lock()
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.m.Unlock()
+	r.getState(context.Background())
+
+	// the worker returned once Close does, no retry is sent afterwards
+	time.Sleep(50 * time.Millisecond)
+	r.Close()
+	select {
+	case <-r.webhook.exited:
+	default:
+		t.Fatal("the webhook worker is still running after Close")
+	}
+	<-aborted
+}
+
+func TestNoDeliveryAfterClose(t *testing.T) {
+	listener := make(chan bool, 1)
+	cc := resolvertest.NewClientConn()
+	r := NewResolver("localhost", "8080", false, &refreshRate, listener)
+	r.cc = cc
+	r.updateState = true
+	r.StartResolver()
+	assert.Equal(t, 1, len(cc.States()))
+	r.Close()
+
+	r.m.Lock()
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.m.Unlock()
+	r.Refresh()
+	assert.Equal(t, 1, len(cc.States()))
+	assert.Equal(t, 0, len(listener))
+
+	r.publish(resolver.State{})
+	assert.Equal(t, 1, len(cc.States()))
+}
+
+func TestCloseWithUnreadListener(t *testing.T) {
+	listener := make(chan bool)
+	r := NewResolver("localhost", "8080", false, &refreshRate, listener)
+	refreshed := make(chan struct{})
+	go func() {
+		r.Refresh() // blocked on the listener nobody reads
+		close(refreshed)
+	}()
+
+	for len(r.Snapshot().Addresses) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		r.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close blocked by the unread listener")
+	}
+	<-refreshed
+	assert.Equal(t, 0, len(listener))
+	_, err := r.Shutdown(context.Background())
+	assert.Nil(t, err)
+}
+
+func TestWebhookWait(t *testing.T) {
+	w := newWebhook("http://127.0.0.1:0", 0)
+	w.close()
+	dropped, err := w.wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, dropped)
+}
@@ -0,0 +1,38 @@
+package resolver
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// RefreshOnSignal refreshes every resolver with a running watcher when
+// the process receives one of the signals, SIGHUP if none is given, so
+// the operators can kick the discovery after a known DNS change, the
+// returned function stops listening for the signals
+func RefreshOnSignal(sig ...os.Signal) (stop func()) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sig...)
+	go func() {
+		for {
+			select {
+			case s := <-ch:
+				log.Println("[grpc-resolver]: received ", s, ", refreshing ", ActiveWatchers(), " resolvers")
+				RefreshAll()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
@@ -0,0 +1,25 @@
+package resolver
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshOnSignal(t *testing.T) {
+	rate := time.Duration(3600)
+	r := NewResolver("localhost", "8080", true, &rate, nil)
+	r.StartResolver()
+	defer r.Close()
+	first := r.Status().LastRefresh
+
+	stop := RefreshOnSignal()
+	defer stop()
+	p, err := os.FindProcess(os.Getpid())
+	assert.Nil(t, err)
+	assert.Nil(t, p.Signal(syscall.SIGHUP))
+	assert.Eventually(t, func() bool { return r.Status().LastRefresh.After(first) }, time.Second, 5*time.Millisecond)
+}
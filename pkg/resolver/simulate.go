@@ -0,0 +1,117 @@
+package resolver
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// SimulatedAnswer is a scripted DNS answer, the IPs returned for the
+// target at the given time, no IPs is a failed lookup
+type SimulatedAnswer struct {
+	Time time.Time
+	IPs  []string
+}
+
+// SimulatedPublication is a state the resolver would have sent to gRPC
+type SimulatedPublication struct {
+	Time      time.Time
+	Version   uint64 // address list version at the time
+	Addresses []string
+}
+
+// simulation holds the scripted answer and the clock of a simulated
+// resolver, only used by the goroutine running Simulate
+type simulation struct {
+	now time.Time
+	ips []string
+}
+
+// Simulate replays the answers in order through the refresh pipeline of
+// a resolver created with the given options, comparator, address TTL,
+// quarantine, scoring, caps and dedupe included, and returns what would
+// have been published and when, useful to tune the policies offline
+// against the DNS logs of production. The time of the answers is the
+// clock of the policies, the lookups never reach DNS nor the hosts
+// overrides and the resolver isn't started so no watcher runs. The event
+// log, the metrics and the publishers of the options are fed as usual, so
+// they must be in memory or offline ones, they are how the simulated
+// events and snapshots are observed, while the webhook, warm-up,
+// leadership check and group are dropped, those would reach the backends
+// or hold the publications back
+func Simulate(address, port string, answers []SimulatedAnswer, opts ...Option) []SimulatedPublication {
+	cc := &simConn{}
+	r := NewGRPCResolver(cc, address, port, false, nil, opts...)
+	defer r.Close()
+
+	r.webhook, r.warmUp, r.leaderCheck = nil, nil, nil
+	if r.group != nil {
+		r.group.remove(r)
+		r.group = nil
+	}
+
+	r.simulation = &simulation{}
+	cc.r = r
+	for _, a := range answers {
+		r.simulation.now, r.simulation.ips = a.Time, a.IPs
+
+		st, apply := r.getState(r.ctx)
+		if apply {
+			r.publish(st)
+		}
+	}
+
+	return cc.published
+}
+
+// now returns the simulated time while simulating, the current time otherwise
+func (r *DomainResolver) now() time.Time {
+	if r.simulation != nil {
+		return r.simulation.now
+	}
+
+	return time.Now()
+}
+
+// records returns the scripted IPs in the format of the lookups
+func (s *simulation) records() []string {
+	ips := []net.IP{}
+	for _, a := range s.ips {
+		if ip := net.ParseIP(a); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+
+	return pushRecords(ips)
+}
+
+// simConn records the states sent by the simulated resolver
+type simConn struct {
+	m         sync.Mutex
+	r         *DomainResolver
+	published []SimulatedPublication
+}
+
+func (c *simConn) UpdateState(s resolver.State) {
+	c.r.m.Lock()
+	p := SimulatedPublication{Time: c.r.now(), Version: c.r.version, Addresses: list.FromAddrToString(s.Addresses)}
+	c.r.m.Unlock()
+
+	c.m.Lock()
+	c.published = append(c.published, p)
+	c.m.Unlock()
+}
+
+func (c *simConn) ReportError(err error) {}
+
+func (c *simConn) NewAddress(addresses []resolver.Address) {}
+
+func (c *simConn) NewServiceConfig(serviceConfig string) {}
+
+func (c *simConn) ParseServiceConfig(serviceConfigJSON string) *serviceconfig.ParseResult {
+	return &serviceconfig.ParseResult{}
+}
@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	answers := []SimulatedAnswer{
+		{Time: start, IPs: []string{"10.0.0.1", "10.0.0.2"}},
+		{Time: start.Add(10 * time.Second), IPs: []string{"10.0.0.2", "10.0.0.1"}},
+		{Time: start.Add(20 * time.Second), IPs: []string{"10.0.0.1"}},
+		{Time: start.Add(30 * time.Second)}, // lookup failure, within the TTL
+		{Time: start.Add(2 * time.Minute)},  // the addresses expired
+	}
+
+	published := Simulate("my-service", "8080", answers, WithAddressTTL(time.Minute))
+	assert.Equal(t, []SimulatedPublication{
+		{Time: start, Version: 1, Addresses: []string{"10.0.0.1:8080", "10.0.0.2:8080"}},
+		{Time: start.Add(20 * time.Second), Version: 2, Addresses: []string{"10.0.0.1:8080"}},
+		{Time: start.Add(2 * time.Minute), Version: 3, Addresses: []string{}},
+	}, published)
+}
+
+func TestSimulateIPv6(t *testing.T) {
+	published := Simulate("my-service", "8080", []SimulatedAnswer{{Time: time.Now(), IPs: []string{"::1", "invalid"}}})
+	assert.Equal(t, 1, len(published))
+	assert.Equal(t, []string{"[::1]:8080"}, published[0].Addresses)
+}
+
+func TestSimulateSideEffects(t *testing.T) {
+	hits := int32(0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer srv.Close()
+
+	published, warmed, checked := 0, 0, 0
+	log := &bytes.Buffer{}
+	g := NewGroup()
+	answers := []SimulatedAnswer{{Time: time.Now(), IPs: []string{"10.0.0.1"}}, {Time: time.Now(), IPs: []string{"10.0.0.2"}}}
+	out := Simulate("my-service", "8080", answers,
+		WithWebhook(srv.URL, 0),
+		WithEventLog(log),
+		WithPublishers(PublisherFunc(func(Snapshot) error { published++; return nil })),
+		WithWarmUp(func(context.Context, string) { warmed++ }, 1),
+		WithLeaderGate(func() bool { checked++; return false }, time.Hour),
+		WithGroup(g))
+
+	// the leader check and the group would have held everything back, the
+	// publishers and the event log observe the simulation
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, 2, published)
+	assert.Contains(t, log.String(), `"type":"change"`)
+	assert.Equal(t, 0, warmed)
+	assert.Equal(t, 0, checked)
+	assert.Equal(t, 0, len(g.members))
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+}
@@ -0,0 +1,51 @@
+package resolver
+
+import (
+	"log"
+	"time"
+)
+
+// stallFactor is the number of refresh intervals between two ticks
+// above which the watcher is considered stalled
+const stallFactor = 2
+
+// catchUpRate is the rate the wall clock is checked at when the catch
+// up refresh is enabled
+var catchUpRate = time.Second
+
+// checkSkew compares the wall clock time of the tick against the
+// previous one, reporting a stall when ticks were missed (process
+// paused, CPU starvation, long GC...), only called from the watcher
+func (r *DomainResolver) checkSkew(now time.Time) (stalled bool) {
+	// the monotonic reading is stripped since it doesn't advance on sleep in every platform
+	now = now.Round(0)
+	last := r.lastTick
+	r.lastTick = now
+	if last.IsZero() {
+		return false
+	}
+
+	if gap := now.Sub(last); gap > stallFactor*r.interval {
+		r.reportStall(gap)
+		return true
+	}
+
+	return false
+}
+
+// reportStall logs and emits the stall of the watcher
+func (r *DomainResolver) reportStall(gap time.Duration) {
+	log.Println("[grpc-resolver]: watcher of ", r.address, " stalled for ", gap, " with a refresh rate of ", r.interval, r.labelsString())
+	r.emit(Event{Type: EventStall, Time: time.Now(), DurationMs: float64(gap) / float64(time.Millisecond)})
+}
+
+// catchUp refreshes right away if the wall clock moved more than a
+// refresh interval since the last check, e.g. after a laptop sleep or
+// a container freeze, instead of waiting for the next tick
+func (r *DomainResolver) catchUp(now, lastCheck time.Time) {
+	if gap := now.Round(0).Sub(lastCheck); gap > r.interval {
+		r.reportStall(gap)
+		r.lastTick = now.Round(0)
+		r.refreshTick()
+	}
+}
@@ -0,0 +1,55 @@
+package resolver
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSkew(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithEventLog(&buf))
+	r.interval = time.Second
+
+	now := time.Now()
+	assert.False(t, r.checkSkew(now))
+	assert.False(t, r.checkSkew(now.Add(time.Second)))
+	assert.True(t, r.checkSkew(now.Add(5*time.Second)))
+
+	e := Event{}
+	assert.Nil(t, json.NewDecoder(&buf).Decode(&e))
+	assert.Equal(t, EventStall, e.Type)
+	assert.Equal(t, float64(4000), e.DurationMs)
+}
+
+func TestCatchUp(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithEventLog(&buf), WithCatchUpRefresh())
+	assert.True(t, r.catchUpRefresh)
+	r.interval = time.Minute
+
+	now := time.Now()
+	r.catchUp(now, now.Add(-time.Second))
+	assert.Equal(t, 0, buf.Len())
+	assert.Empty(t, r.Addresses)
+
+	r.catchUp(now, now.Add(-time.Hour))
+	assert.Contains(t, buf.String(), EventStall)
+	assert.NotEmpty(t, r.Addresses)
+	assert.Equal(t, now.Round(0), r.lastTick)
+}
+
+func TestWatcherCatchUp(t *testing.T) {
+	catchUpRate = 10 * time.Millisecond
+	defer func() { catchUpRate = time.Second }()
+
+	rate := time.Duration(60)
+	r := NewResolver("localhost", "8080", true, &rate, nil, WithCatchUpRefresh())
+	r.StartResolver()
+	time.Sleep(50 * time.Millisecond)
+	r.Close()
+	assert.True(t, waitUnregistered(r))
+}
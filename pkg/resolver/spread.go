@@ -0,0 +1,42 @@
+package resolver
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// phaseOffset returns the delay of the first tick of the watcher within
+// the refresh interval, derived from the target so the resolvers sharing
+// an interval are spread evenly across the window and the same target
+// always gets the same phase
+func (r *DomainResolver) phaseOffset() time.Duration {
+	if r.interval <= 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(r.address + ":" + r.port))
+	return time.Duration(h.Sum64() % uint64(r.interval))
+}
+
+// waitPhase delays the ticker of the watcher by its phase offset, false
+// is returned if the resolver was closed meanwhile
+func (r *DomainResolver) waitPhase() bool {
+	timer := time.NewTimer(r.phaseOffset())
+	defer timer.Stop()
+
+	select {
+	case <-r.isDone:
+		return false
+	case <-r.ctx.Done():
+		return false
+	case <-timer.C:
+	}
+
+	r.ticker.Reset(r.interval)
+	select {
+	case <-r.ticker.C:
+	default:
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package resolver
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhaseOffset(t *testing.T) {
+	interval := time.Second
+	buckets := make([]int, 10)
+	for i := 0; i < 1000; i++ {
+		r := NewResolver(fmt.Sprintf("svc-%d.ns", i), "443", true, &refreshRate, nil, WithRefreshInterval(interval), WithSpreadRefresh())
+		offset := r.phaseOffset()
+		assert.True(t, offset >= 0 && offset < interval)
+		assert.Equal(t, offset, r.phaseOffset())
+		buckets[offset*10/interval]++
+	}
+
+	// every tenth of the window gets roughly a tenth of the targets
+	for _, n := range buckets {
+		assert.InDelta(t, 100, n, 50)
+	}
+
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	assert.Equal(t, time.Duration(0), r.phaseOffset())
+}
+
+func TestSpreadRefreshClose(t *testing.T) {
+	r := NewResolver("localhost", "8080", true, &refreshRate, nil, WithSpreadRefresh())
+	assert.True(t, r.phaseOffset() > 0)
+	r.StartResolver()
+	r.Close()
+	assert.True(t, waitUnregistered(r))
+}
+
+func TestSpreadRefresh(t *testing.T) {
+	r := NewResolver("localhost", "8080", true, &refreshRate, nil, WithRefreshInterval(50*time.Millisecond), WithSpreadRefresh())
+	r.StartResolver()
+	defer r.Close()
+	first := r.Status().LastRefresh
+
+	assert.Eventually(t, func() bool { return r.Status().LastRefresh.After(first) }, time.Second, 5*time.Millisecond)
+}
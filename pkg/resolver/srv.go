@@ -0,0 +1,128 @@
+package resolver
+
+import (
+	"context"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TierHealth returns false if the addresses of an SRV priority tier
+// should not be used, e.g. because the health checks are failing
+type TierHealth func(addrs []string) bool
+
+// resolveSRV looks up the SRV records of the domain and returns the
+// host:port list of the lowest priority tier, following RFC 2782 the
+// next tier is only used when the previous ones have no addresses or
+// are marked unhealthy by the tier health callback
+func (r *DomainResolver) resolveSRV(ctx context.Context) []string {
+	_, records, err := r.resolverFor(ctx).LookupSRV(ctx, r.srvService, r.srvProto, r.lookupName())
+	if err != nil {
+		log.Println("[grpc-resolver]: error looking up for srv records ", err, describeAnswers(ctx))
+		return []string{}
+	}
+
+	return r.pickTier(r.srvTiers(ctx, records))
+}
+
+// srvTiers resolves the targets of the SRV records returning
+// their host:port lists grouped by priority in ascending order
+func (r *DomainResolver) srvTiers(ctx context.Context, records []*net.SRV) [][]string {
+	byPriority := map[uint16][]string{}
+	priorities := []int{}
+	for _, rec := range records {
+		if _, ok := byPriority[rec.Priority]; !ok {
+			priorities = append(priorities, int(rec.Priority))
+			byPriority[rec.Priority] = []string{}
+		}
+
+		port := strconv.Itoa(int(rec.Port))
+		for _, ip := range r.lookUp(ctx, strings.TrimSuffix(rec.Target, ".")) {
+			byPriority[rec.Priority] = append(byPriority[rec.Priority], net.JoinHostPort(ip, port))
+		}
+	}
+
+	sort.Ints(priorities)
+	tiers := make([][]string, 0, len(priorities))
+	for _, p := range priorities {
+		tiers = append(tiers, byPriority[uint16(p)])
+	}
+
+	return tiers
+}
+
+// pickTier returns the first tier with addresses considered healthy,
+// if every tier is unhealthy the first one with addresses is kept
+func (r *DomainResolver) pickTier(tiers [][]string) []string {
+	fallback := []string{}
+	for _, addrs := range tiers {
+		if len(addrs) == 0 {
+			continue
+		}
+
+		if r.tierHealth == nil || r.tierHealth(addrs) {
+			return addrs
+		}
+
+		if len(fallback) == 0 {
+			fallback = addrs
+		}
+	}
+
+	return fallback
+}
+
+// hostPorts returns the host:port list of the domain
+// either from the SRV records or the IP lookup, or the imported snapshot
+func (r *DomainResolver) hostPorts(ctx context.Context) []string {
+	if imported := r.importedAddresses(); imported != nil {
+		return imported
+	}
+
+	if r.srv {
+		return r.resolveSRV(ctx)
+	}
+
+	if r.endpointsDir != "" {
+		return r.readEndpoints()
+	}
+
+	ips := []string{}
+	for _, name := range r.queryNames() {
+		if ips = r.lookUp(ctx, name); len(ips) > 0 {
+			break
+		}
+	}
+
+	return r.joinPorts(ips)
+}
+
+// joinPorts returns every IP joined with each port of the resolver,
+// the ones set by WithPorts if any
+func (r *DomainResolver) joinPorts(ips []string) []string {
+	ports := []string{r.port}
+	if len(r.ports) > 0 {
+		ports = r.ports
+	}
+
+	hostports := make([]string, 0, len(ips)*len(ports))
+	for _, ip := range ips {
+		for _, port := range ports {
+			hostports = append(hostports, net.JoinHostPort(ip, port))
+		}
+	}
+
+	return hostports
+}
+
+// splitHost returns the host of a host:port address
+func splitHost(addr string) string {
+	host, _, err := SplitTarget(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSRVTiers(t *testing.T) {
+	hosts := map[string][]string{"a.local": {"10.0.0.1"}, "b.local": {"10.0.0.2"}, "c.local": {"10.0.0.3"}}
+	r := NewResolver("_grpc._tcp.my-service", "", false, &refreshRate, nil, WithHosts(hosts, true), WithSRV("", "", nil))
+	tiers := r.srvTiers(context.Background(), []*net.SRV{
+		{Target: "c.local.", Port: 9000, Priority: 20},
+		{Target: "a.local.", Port: 8080, Priority: 10},
+		{Target: "b.local.", Port: 8081, Priority: 10},
+		{Target: "unknown.local.", Port: 8080, Priority: 30},
+	})
+
+	assert.Equal(t, [][]string{{"10.0.0.1:8080", "10.0.0.2:8081"}, {"10.0.0.3:9000"}, {}}, tiers)
+	assert.Equal(t, []string{"10.0.0.1:8080", "10.0.0.2:8081"}, r.pickTier(tiers))
+}
+
+func TestPickTierFailover(t *testing.T) {
+	unhealthy := map[string]bool{"10.0.0.1:8080": true}
+	health := func(addrs []string) bool { return !unhealthy[addrs[0]] }
+	r := NewResolver("my-service", "", false, &refreshRate, nil, WithSRV("grpc", "tcp", health))
+	assert.Equal(t, "grpc", r.srvService)
+	assert.Equal(t, "tcp", r.srvProto)
+
+	tiers := [][]string{{}, {"10.0.0.1:8080"}, {"10.0.0.2:8080"}}
+	assert.Equal(t, []string{"10.0.0.2:8080"}, r.pickTier(tiers))
+
+	// every tier is unhealthy
+	unhealthy["10.0.0.2:8080"] = true
+	assert.Equal(t, []string{"10.0.0.1:8080"}, r.pickTier(tiers))
+	assert.Equal(t, []string{}, r.pickTier([][]string{}))
+}
+
+func TestResolveSRV(t *testing.T) {
+	r := NewResolver("no-domain1234.com", "", false, &refreshRate, nil, WithSRV("grpc", "tcp", nil))
+	assert.Equal(t, 0, len(r.resolve(context.Background())))
+	assert.Equal(t, "10.0.0.1", splitHost("10.0.0.1:80"))
+	assert.Equal(t, "::1", splitHost("[::1]:80"))
+	assert.Equal(t, "my-host", splitHost("my-host"))
+}
+
+func TestHostPortsMultiplePorts(t *testing.T) {
+	r := NewResolver("localhost", "443", false, &refreshRate, nil, WithHosts(map[string][]string{"localhost": {"10.0.0.1", "10.0.0.2"}}, true), WithPorts("443", "8443"))
+	assert.Equal(t, []string{"10.0.0.1:443", "10.0.0.1:8443", "10.0.0.2:443", "10.0.0.2:8443"}, r.hostPorts(context.Background()))
+
+	r = NewResolver("localhost", "443", false, &refreshRate, nil, WithHosts(map[string][]string{"localhost": {"10.0.0.1"}}, true))
+	assert.Equal(t, []string{"10.0.0.1:443"}, r.hostPorts(context.Background()))
+}
+
+func TestIPTargetPorts(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "10.0.0.1", "443", false, nil, WithPorts("443", "8443"))
+	r.StartResolver()
+	st, _ := cc.LastState()
+	assert.Equal(t, []string{"10.0.0.1:443", "10.0.0.1:8443"}, list.FromAddrToString(st.Addresses))
+	assert.Equal(t, []string{"10.0.0.1:443", "10.0.0.1:8443"}, r.Status().Addresses)
+
+	// the family ports apply to the IP targets too
+	cc = resolvertest.NewClientConn()
+	r = NewGRPCResolver(cc, "::1", "443", false, nil, WithFamilyPorts("8443", "9443"))
+	r.StartResolver()
+	st, _ = cc.LastState()
+	assert.Equal(t, []string{"[::1]:9443"}, list.FromAddrToString(st.Addresses))
+	assert.Equal(t, []string{"[::1]:9443"}, r.Status().Addresses)
+}
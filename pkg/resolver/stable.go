@@ -0,0 +1,49 @@
+package resolver
+
+import (
+	"google.golang.org/grpc/resolver"
+)
+
+// stableOrder reorders the addresses keeping the relative order of the
+// ones already present in the previous list and appending the new ones
+// at the end, so pick_first and long lived streams stay on their backend
+func stableOrder(prev []string, addrs []resolver.Address) []resolver.Address {
+	byAddr := make(map[string]resolver.Address, len(addrs))
+	for _, a := range addrs {
+		byAddr[a.Addr] = a
+	}
+
+	ordered := make([]resolver.Address, 0, len(addrs))
+	kept := make(map[string]bool, len(prev))
+	for _, p := range prev {
+		if a, ok := byAddr[p]; ok && !kept[p] {
+			ordered = append(ordered, a)
+			kept[p] = true
+		}
+	}
+
+	for _, a := range addrs {
+		if !kept[a.Addr] {
+			ordered = append(ordered, a)
+			kept[a.Addr] = true
+		}
+	}
+
+	return ordered
+}
+
+// applyStableOrder reorders the addresses against the last published
+// order when enabled, it's expected to be called with the lock held
+func (r *DomainResolver) applyStableOrder(addrs []resolver.Address) []resolver.Address {
+	if !r.stableOrder {
+		return addrs
+	}
+
+	addrs = stableOrder(r.published, addrs)
+	r.published = make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		r.published = append(r.published, a.Addr)
+	}
+
+	return addrs
+}
@@ -0,0 +1,32 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestStableOrder(t *testing.T) {
+	addrs := []resolver.Address{{Addr: "10.0.0.4:80"}, {Addr: "10.0.0.1:80"}, {Addr: "10.0.0.3:80"}}
+	ordered := stableOrder([]string{"10.0.0.3:80", "10.0.0.2:80", "10.0.0.1:80"}, addrs)
+	assert.Equal(t, []string{"10.0.0.3:80", "10.0.0.1:80", "10.0.0.4:80"}, list.FromAddrToString(ordered))
+
+	ordered = stableOrder(nil, addrs)
+	assert.Equal(t, list.FromAddrToString(addrs), list.FromAddrToString(ordered))
+}
+
+func TestApplyStableOrder(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	addrs := []resolver.Address{{Addr: "10.0.0.2:80"}, {Addr: "10.0.0.1:80"}}
+	assert.Equal(t, addrs, r.applyStableOrder(addrs))
+	assert.Nil(t, r.published)
+
+	r = NewResolver("localhost", "8080", false, &refreshRate, nil, WithStableOrder())
+	r.applyStableOrder(addrs)
+	assert.Equal(t, []string{"10.0.0.2:80", "10.0.0.1:80"}, r.published)
+
+	ordered := r.applyStableOrder([]resolver.Address{{Addr: "10.0.0.3:80"}, {Addr: "10.0.0.1:80"}, {Addr: "10.0.0.2:80"}})
+	assert.Equal(t, []string{"10.0.0.2:80", "10.0.0.1:80", "10.0.0.3:80"}, list.FromAddrToString(ordered))
+}
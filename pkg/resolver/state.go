@@ -0,0 +1,71 @@
+package resolver
+
+import (
+	"reflect"
+
+	"github.com/cperez08/dm-resolver/pkg/list"
+	"google.golang.org/grpc/resolver"
+)
+
+// isPublished returns true if the state is identical to the last one
+// sent to the ClientConn, addresses order, attributes and service config
+// included, otherwise it's recorded as the last state
+func (r *DomainResolver) isPublished(st resolver.State) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.lastState != nil && equalState(*r.lastState, st) {
+		return true
+	}
+
+	r.lastState = &st
+	return false
+}
+
+// equalState returns true if both states are identical
+func equalState(a, b resolver.State) bool {
+	return list.EqualAddresses(a.Addresses, b.Addresses) &&
+		list.EqualAttributes(a.Attributes, b.Attributes) &&
+		reflect.DeepEqual(a.ServiceConfig, b.ServiceConfig)
+}
+
+// reuseAddresses replaces the addresses equal in value to a published one
+// by the published one, the attributes are rebuilt on every refresh and
+// the gRPC balancers key the SubConns by the whole Address, attributes
+// pointer included, so an unchanged address would be redialed otherwise
+func (r *DomainResolver) reuseAddresses(addrs []resolver.Address) []resolver.Address {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	reused := make([]resolver.Address, len(addrs))
+	published := make(map[string]resolver.Address, len(addrs))
+	for i, a := range addrs {
+		if prev, ok := r.lastAddresses[a.Addr]; ok && list.EqualAddress(prev, a) {
+			a = prev
+		}
+		reused[i] = a
+		published[a.Addr] = a
+	}
+
+	r.lastAddresses = published
+	return reused
+}
+
+// setResolved records the prepared addresses of the current Addresses,
+// expected to be called with the lock held
+func (r *DomainResolver) setResolved(addrs []resolver.Address) {
+	r.resolvedAddrs = make(map[string]resolver.Address, len(addrs))
+	for _, a := range addrs {
+		r.resolvedAddrs[a.Addr] = a
+	}
+}
+
+// resolvedAddress returns the prepared address of an entry of Addresses,
+// expected to be called with the lock held
+func (r *DomainResolver) resolvedAddress(addr string) resolver.Address {
+	if a, ok := r.resolvedAddrs[addr]; ok {
+		return a
+	}
+
+	return resolver.Address{Addr: addr}
+}
@@ -0,0 +1,111 @@
+package resolver
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/roundrobin"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestIsPublished(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	st := resolver.State{Addresses: []resolver.Address{{Addr: "10.0.0.1:80"}, {Addr: "10.0.0.2:80"}}}
+	assert.False(t, r.isPublished(st))
+	assert.True(t, r.isPublished(resolver.State{Addresses: []resolver.Address{{Addr: "10.0.0.1:80"}, {Addr: "10.0.0.2:80"}}}))
+
+	// order matters to the balancer
+	assert.False(t, r.isPublished(resolver.State{Addresses: []resolver.Address{{Addr: "10.0.0.2:80"}, {Addr: "10.0.0.1:80"}}}))
+
+	withAttrs := resolver.State{Addresses: []resolver.Address{{Addr: "10.0.0.2:80", Attributes: attributes.New(hostnamesKey{}, []string{"a"})}, {Addr: "10.0.0.1:80"}}}
+	assert.False(t, r.isPublished(withAttrs))
+	assert.True(t, r.isPublished(withAttrs))
+}
+
+// subConnRecorder is a balancer.ClientConn counting the SubConns created
+// and removed by the balancer it's given to
+type subConnRecorder struct {
+	created, removed int
+}
+
+func (c *subConnRecorder) NewSubConn([]resolver.Address, balancer.NewSubConnOptions) (balancer.SubConn, error) {
+	c.created++
+	return &fakeSubConn{}, nil
+}
+
+func (c *subConnRecorder) RemoveSubConn(balancer.SubConn)        { c.removed++ }
+func (c *subConnRecorder) UpdateState(balancer.State)            {}
+func (c *subConnRecorder) ResolveNow(resolver.ResolveNowOptions) {}
+func (c *subConnRecorder) Target() string                        { return "" }
+
+type fakeSubConn struct{}
+
+func (*fakeSubConn) UpdateAddresses([]resolver.Address) {}
+func (*fakeSubConn) Connect()                           {}
+
+// assertSubConnsKept feeds the states published after each refresh of the
+// resolver to a round_robin balancer, the hosts of the target are set
+// before each refresh, and asserts only the added addresses are dialed
+func assertSubConnsKept(t *testing.T, r *DomainResolver, cc *resolvertest.ClientConn, refreshes ...[]string) {
+	recorder := &subConnRecorder{}
+	b := balancer.Get(roundrobin.Name).Build(recorder, balancer.BuildOptions{})
+	defer b.Close()
+
+	dialed := 0
+	for i, ips := range refreshes {
+		r.m.Lock()
+		r.hosts[r.address] = ips
+		r.m.Unlock()
+		if i == 0 {
+			r.StartResolver()
+		} else {
+			r.Refresh()
+		}
+
+		st, ok := cc.LastState()
+		assert.True(t, ok)
+		assert.Nil(t, b.UpdateClientConnState(balancer.ClientConnState{ResolverState: st}))
+		dialed = len(ips)
+	}
+
+	assert.Equal(t, dialed, recorder.created)
+	assert.Equal(t, 0, recorder.removed)
+}
+
+func TestReuseAddresses(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "churn.test", "8080", false, nil, WithHosts(map[string][]string{"churn.test": nil}, true),
+		WithLoadReporter(func(string) uint32 { return 3 }))
+	defer r.Close()
+
+	// the unchanged address keeps its SubConn when one is added
+	assertSubConnsKept(t, r, cc, []string{"10.0.0.1"}, []string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"})
+
+	// a changed attribute is published as a new address
+	a := resolver.Address{Addr: "10.0.0.1:8080", Attributes: attributes.New(hostnamesKey{}, []string{"a"})}
+	b := resolver.Address{Addr: "10.0.0.1:8080", Attributes: attributes.New(hostnamesKey{}, []string{"a"})}
+	c := resolver.Address{Addr: "10.0.0.1:8080", Attributes: attributes.New(hostnamesKey{}, []string{"c"})}
+	assert.True(t, r.reuseAddresses([]resolver.Address{a})[0].Attributes == a.Attributes)
+	assert.True(t, r.reuseAddresses([]resolver.Address{b})[0].Attributes == a.Attributes)
+	assert.True(t, r.reuseAddresses([]resolver.Address{c})[0].Attributes == c.Attributes)
+}
+
+func TestChangeEventHostnames(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithReverseLookup(), WithEventLog(buf))
+	r.m.Lock()
+	r.hostnames = map[string][]string{"10.0.0.1:8080": {"a.example.com."}}
+	r.Addresses = []string{"10.0.0.1:8080"}
+	change := r.recordChange([]string{"10.0.0.1:8080"}, nil)
+	r.m.Unlock()
+	r.announceChange(change)
+
+	e := Event{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &e))
+	assert.Equal(t, map[string][]string{"10.0.0.1:8080": {"a.example.com."}}, e.Hostnames)
+}
@@ -0,0 +1,135 @@
+package resolver
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Status is a point in time view of the resolver
+type Status struct {
+	Address   string   // domain or IP being resolved
+	Addresses []string // current address list in the format host:port
+	Churn     Churn    // address churn counters
+	Invalid   int      // number of invalid addresses dropped before the publication
+	Version   uint64   // address list version, increased on every change
+
+	CNAMEChain []string // names from the domain to its canonical name, only WithCNAMETracking
+
+	LastRefresh time.Time             // last resolution returning records, zero if none yet
+	Provenance  map[string]Provenance // source and first/last seen times of the current addresses
+
+	ClientSubnet *ClientSubnet // EDNS client subnet and scope of the last answer, nil if not set
+	DNSAnswers   []DNSAnswer   // metadata of the answers of the last resolution, see DNSAnswer
+
+	Labels map[string]string // user defined labels, see WithLabels
+}
+
+// Churn holds the address churn counters of the resolver, a high churn
+// usually indicates a misconfigured DNS TTL or an unstable backend
+type Churn struct {
+	Changes     int // total number of address list changes
+	Added       int // total number of addresses added
+	Removed     int // total number of addresses removed
+	LastHour    int // number of address list changes in the last hour
+	LastChanged time.Time
+}
+
+// Status returns the current status of the resolver
+func (r *DomainResolver) Status() Status {
+	r.access()
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.pruneChanges(r.now())
+	churn := r.churn
+	churn.LastHour = len(r.changes)
+
+	return Status{
+		Address:   r.address,
+		Addresses: append([]string{}, r.Addresses...),
+		Churn:     churn,
+		Invalid:   r.invalid,
+		Version:   r.version,
+
+		CNAMEChain: append([]string(nil), r.cnameChain...),
+
+		LastRefresh: r.lastRefresh,
+		Provenance:  r.currentProvenance(),
+
+		ClientSubnet: r.clientSubnetStatus(),
+		DNSAnswers:   append([]DNSAnswer{}, r.dnsAnswers...),
+
+		Labels: r.copyLabels(),
+	}
+}
+
+// recordChange updates the churn counters after the Addresses were
+// updated and returns the change event, it's expected to be called with
+// the lock held and the event to be given to announceChange once the lock
+// is released, the event consumers are user code
+func (r *DomainResolver) recordChange(added, removed []string) Event {
+	now := r.now()
+	r.churn.Changes++
+	r.churn.Added += len(added)
+	r.churn.Removed += len(removed)
+	r.churn.LastChanged = now
+	r.changes = append(r.changes, now)
+	r.pruneChanges(now)
+
+	r.version++
+	e := Event{Type: EventChange, Time: now, Version: r.version, Addresses: append([]string{}, r.Addresses...), Added: added, Removed: removed}
+	if r.trackCNAME {
+		e.CNAMEChain = append([]string(nil), r.cnameChain...)
+	}
+	if r.reverseLookup {
+		e.Hostnames = make(map[string][]string, len(r.hostnames))
+		for addr, names := range r.hostnames {
+			e.Hostnames[addr] = names
+		}
+	}
+
+	return e
+}
+
+// announceChange starts the warm up of the added addresses and emits the
+// change event returned by recordChange, followed by a warning if the
+// churn threshold is exceeded, it's expected to be called without the
+// lock held
+func (r *DomainResolver) announceChange(e Event) {
+	if r.warmUp != nil {
+		r.warmUp.update(e.Added, e.Removed)
+	}
+
+	r.emit(e)
+	r.checkChurn(e.Time)
+}
+
+// checkChurn logs and emits a warning if the address list changes of the
+// last hour exceed the churn threshold
+func (r *DomainResolver) checkChurn(now time.Time) {
+	if r.churnThreshold <= 0 {
+		return
+	}
+
+	r.m.Lock()
+	changes := len(r.changes)
+	r.m.Unlock()
+	if changes <= r.churnThreshold {
+		return
+	}
+
+	msg := fmt.Sprintf("%d changes in the last hour exceed the churn threshold of %d", changes, r.churnThreshold)
+	log.Printf("[grpc-resolver]: high churn for %s, %s%s", r.address, msg, r.labelsString())
+	r.emit(Event{Type: EventWarning, Time: now, Error: msg})
+}
+
+// pruneChanges drops the change times older than an hour
+func (r *DomainResolver) pruneChanges(now time.Time) {
+	i := 0
+	for i < len(r.changes) && now.Sub(r.changes[i]) > time.Hour {
+		i++
+	}
+
+	r.changes = r.changes[i:]
+}
@@ -0,0 +1,86 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	r := NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	st := r.Status()
+	assert.Equal(t, "127.0.0.1", st.Address)
+	assert.Equal(t, []string{"127.0.0.1:8080"}, st.Addresses)
+	assert.Equal(t, 0, st.Churn.Changes)
+}
+
+func TestRecordChange(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithChurnThreshold(1))
+	events := r.WatchEvents()
+	r.changes = append(r.changes, time.Now().Add(-2*time.Hour))
+	r.announceChange(r.recordChange([]string{"1", "2"}, nil))
+	assert.Equal(t, EventChange, (<-events).Type)
+	assert.Equal(t, 0, len(events))
+
+	// over the threshold, a warning follows the change
+	r.announceChange(r.recordChange([]string{"3"}, []string{"1"}))
+	assert.Equal(t, EventChange, (<-events).Type)
+	warning := <-events
+	assert.Equal(t, EventWarning, warning.Type)
+	assert.Equal(t, "2 changes in the last hour exceed the churn threshold of 1", warning.Error)
+
+	st := r.Status()
+	assert.Equal(t, 2, st.Churn.Changes)
+	assert.Equal(t, 3, st.Churn.Added)
+	assert.Equal(t, 1, st.Churn.Removed)
+	assert.Equal(t, 2, st.Churn.LastHour)
+	assert.False(t, st.Churn.LastChanged.IsZero())
+}
+
+func TestStatusVersion(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	r.StartResolver()
+	assert.Equal(t, uint64(0), r.Status().Version)
+
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.getState(context.Background())
+	assert.Equal(t, uint64(1), r.Status().Version)
+}
+
+// statusWriter is an event log reading the status of the resolver on
+// every event, it deadlocks if the events are emitted with the lock held
+type statusWriter struct {
+	r        *DomainResolver
+	versions []uint64
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.versions = append(w.versions, w.r.Status().Version)
+	return len(p), nil
+}
+
+func TestChangeEmittedUnlocked(t *testing.T) {
+	w := &statusWriter{}
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithEventLog(w), WithHosts(map[string][]string{"localhost": {"10.0.0.1"}}, true))
+	w.r = r
+	r.StartResolver()
+	r.m.Lock()
+	r.hosts["localhost"] = []string{"10.0.0.2"}
+	r.m.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Refresh()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the change event was emitted with the lock held")
+	}
+	r.Close()
+	assert.Contains(t, w.versions, uint64(1))
+}
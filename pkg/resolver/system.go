@@ -0,0 +1,248 @@
+package resolver
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"net"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SystemServers returns the DNS servers configured in the host as
+// host:port, read from resolv.conf on unix, from the resolvers of scutil
+// --dns on macOS, the split DNS ones included, and from the NameServer
+// and DhcpNameServer registry values of the network interfaces on Windows
+func SystemServers() ([]string, error) {
+	resolvers, err := systemResolvers()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := []string{}
+	for _, sr := range resolvers {
+		for _, s := range sr.servers {
+			if !contains(servers, s) {
+				servers = append(servers, s)
+			}
+		}
+	}
+
+	return servers, nil
+}
+
+// systemResolver is a resolver of the host, the domain is only set for
+// the split DNS ones of macOS, only asked for the names of the domain
+type systemResolver struct {
+	domain  string
+	servers []string // host:port
+}
+
+// systemResolvers returns the resolvers configured in the host, see SystemServers
+func systemResolvers() ([]systemResolver, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("scutil", "--dns").Output()
+		if err != nil {
+			return nil, err
+		}
+		return parseScutil(out), nil
+	case "windows":
+		out, err := exec.Command("reg", "query", `HKLM\SYSTEM\CurrentControlSet\Services\Tcpip\Parameters\Interfaces`, "/s").Output()
+		if err != nil {
+			return nil, err
+		}
+		return []systemResolver{{servers: withDNSPort(parseRegQuery(out))}}, nil
+	default:
+		conf, err := readResolvConf(DefaultResolvConf)
+		if err != nil {
+			return nil, err
+		}
+		return []systemResolver{{servers: withDNSPort(conf.nameservers)}}, nil
+	}
+}
+
+// parseScutil returns the resolvers listed by scutil --dns with name
+// servers in order, with the domain of the split DNS ones, the section of
+// the scoped queries is skipped, those are bound to an interface
+func parseScutil(out []byte) []systemResolver {
+	resolvers := []systemResolver{}
+	var current *systemResolver
+	flush := func() {
+		if current != nil && len(current.servers) > 0 {
+			current.servers = withDNSPort(current.servers)
+			resolvers = append(resolvers, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "DNS configuration (for scoped queries)"):
+			flush()
+			return resolvers
+		case strings.HasPrefix(line, "resolver #"):
+			flush()
+			current = &systemResolver{}
+		case current == nil:
+		case strings.HasPrefix(line, "nameserver["):
+			if i := strings.Index(line, ":"); i > 0 {
+				current.servers = appendUnique(current.servers, strings.TrimSpace(line[i+1:]))
+			}
+		case strings.HasPrefix(line, "domain ") || strings.HasPrefix(line, "domain:"):
+			if i := strings.Index(line, ":"); i > 0 {
+				current.domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(line[i+1:]), "."))
+			}
+		}
+	}
+	flush()
+
+	return resolvers
+}
+
+// parseRegQuery returns the name servers of the interfaces listed by
+// reg query, the static NameServer values take precedence over the DHCP
+// ones, the values are separated by commas or spaces
+func parseRegQuery(out []byte) []string {
+	static, dhcp := []string{}, []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "REG_SZ" {
+			continue
+		}
+
+		for _, s := range strings.FieldsFunc(strings.Join(fields[2:], " "), func(c rune) bool { return c == ',' || c == ' ' }) {
+			switch fields[0] {
+			case "NameServer":
+				static = appendUnique(static, s)
+			case "DhcpNameServer":
+				dhcp = appendUnique(dhcp, s)
+			}
+		}
+	}
+
+	for _, s := range dhcp {
+		static = appendUnique(static, s)
+	}
+
+	return static
+}
+
+func appendUnique(l []string, s string) []string {
+	if net.ParseIP(s) == nil || contains(l, s) {
+		return l
+	}
+
+	return append(l, s)
+}
+
+// withDNSPort adds the DNS port to the servers
+func withDNSPort(servers []string) []string {
+	rs := make([]string, 0, len(servers))
+	for _, s := range servers {
+		rs = append(rs, net.JoinHostPort(s, "53"))
+	}
+
+	return rs
+}
+
+// systemServersTTL is how long the system servers are cached
+const systemServersTTL = 5 * time.Second
+
+var systemCache struct {
+	sync.Mutex
+	resolvers  []systemResolver
+	loaded     bool // read at least once
+	refreshing bool
+	expires    time.Time
+}
+
+// usesSystemServers returns true on the platforms where the Go resolver
+// dialer has to pick the system servers itself, on macOS the Go resolver
+// only sees resolv.conf. On Windows the Go resolver ignores the Dial of
+// net.Resolver before Go 1.19, so the system servers of Windows are only
+// used through SystemServers, e.g. WithDNSServer with one of them
+func usesSystemServers() bool {
+	return runtime.GOOS == "darwin"
+}
+
+// prefetchSystemServers loads the system servers the first time and
+// refreshes them in the background once expired, so the scutil command
+// never runs in the dial of the Go resolver
+func prefetchSystemServers() {
+	if !usesSystemServers() {
+		return
+	}
+
+	systemCache.Lock()
+	defer systemCache.Unlock()
+	switch {
+	case !systemCache.loaded:
+		loadSystemServers()
+	case !systemCache.refreshing && time.Now().After(systemCache.expires):
+		systemCache.refreshing = true
+		go func() {
+			systemCache.Lock()
+			defer systemCache.Unlock()
+			loadSystemServers()
+			systemCache.refreshing = false
+		}()
+	}
+}
+
+// loadSystemServers reads the system resolvers, it's expected to be
+// called with the cache lock held
+func loadSystemServers() {
+	resolvers, err := systemResolvers()
+	if err != nil {
+		log.Println("[grpc-resolver]: error reading the system DNS servers ", err)
+	}
+	systemCache.resolvers = resolvers
+	systemCache.loaded = true
+	systemCache.expires = time.Now().Add(systemServersTTL)
+}
+
+// systemServer returns the server the Go resolver dialer should use for
+// the query of the host to the address chosen by the Go resolver, see
+// usesSystemServers, the servers are the ones read by prefetchSystemServers
+func systemServer(address, host string) string {
+	if !usesSystemServers() {
+		return address
+	}
+
+	systemCache.Lock()
+	defer systemCache.Unlock()
+	return pickServer(address, host, systemCache.resolvers)
+}
+
+// pickServer returns the address if it's one of the servers of the
+// resolver of the host, the first of them otherwise, the address if there
+// are none. The resolver of the host is the split DNS one of its longest
+// domain, the first one without a domain if none matches
+func pickServer(address, host string, resolvers []systemResolver) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	var servers []string
+	matched := -1
+	for _, sr := range resolvers {
+		switch {
+		case sr.domain == "":
+			if servers == nil && matched < 0 {
+				servers = sr.servers
+			}
+		case (host == sr.domain || strings.HasSuffix(host, "."+sr.domain)) && len(sr.domain) > matched:
+			servers, matched = sr.servers, len(sr.domain)
+		}
+	}
+
+	if len(servers) == 0 || contains(servers, address) {
+		return address
+	}
+
+	return servers[0]
+}
@@ -0,0 +1,106 @@
+package resolver
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const scutilOutput = `DNS configuration
+
+resolver #1
+  search domain[0] : corp.example.com
+  nameserver[0] : 192.168.1.1
+  if_index : 6 (en0)
+
+resolver #2
+  domain   : corp.example.com.
+  nameserver[0] : 10.8.0.1
+  nameserver[1] : 10.8.0.2
+  if_index : 19 (utun3)
+  flags    : Supplemental, Request A records
+  reach    : 0x00000003 (Reachable,Transient Connection)
+
+resolver #3
+  domain   : local
+  options  : mdns
+  timeout  : 5
+
+resolver #4
+  domain   : eu.corp.example.com
+  nameserver[0] : 10.9.0.1
+  nameserver[1] : 10.8.0.1
+  nameserver[0] : fe80::1%en0
+
+DNS configuration (for scoped queries)
+
+resolver #1
+  nameserver[0] : 192.168.1.2
+  if_index : 6 (en0)
+`
+
+const regQueryOutput = `
+HKEY_LOCAL_MACHINE\SYSTEM\CurrentControlSet\Services\Tcpip\Parameters\Interfaces\{0c7b2a8e-6f7a-4d3e-9c6b-1f0e2d3c4b5a}
+    EnableDHCP    REG_DWORD    0x1
+    NameServer    REG_SZ
+    DhcpNameServer    REG_SZ    192.168.1.1 192.168.1.2
+
+HKEY_LOCAL_MACHINE\SYSTEM\CurrentControlSet\Services\Tcpip\Parameters\Interfaces\{7d1e3f4a-2b5c-4e6d-8a9b-0c1d2e3f4a5b}
+    NameServer    REG_SZ    10.0.0.53,10.0.0.54
+    DhcpNameServer    REG_SZ    not-an-ip
+`
+
+func TestParseScutil(t *testing.T) {
+	assert.Equal(t, []systemResolver{
+		{servers: []string{"192.168.1.1:53"}},
+		{domain: "corp.example.com", servers: []string{"10.8.0.1:53", "10.8.0.2:53"}},
+		{domain: "eu.corp.example.com", servers: []string{"10.9.0.1:53", "10.8.0.1:53"}},
+	}, parseScutil([]byte(scutilOutput)))
+	assert.Empty(t, parseScutil([]byte("No DNS configuration available\n")))
+}
+
+func TestParseRegQuery(t *testing.T) {
+	assert.Equal(t, []string{"10.0.0.53", "10.0.0.54", "192.168.1.1", "192.168.1.2"}, parseRegQuery([]byte(regQueryOutput)))
+	assert.Empty(t, parseRegQuery([]byte("")))
+}
+
+func TestPickServer(t *testing.T) {
+	resolvers := []systemResolver{{servers: []string{"10.0.0.53:53", "10.0.0.54:53"}}}
+	assert.Equal(t, "10.0.0.54:53", pickServer("10.0.0.54:53", "example.com", resolvers))
+	assert.Equal(t, "10.0.0.53:53", pickServer("127.0.0.53:53", "example.com", resolvers))
+	assert.Equal(t, "127.0.0.53:53", pickServer("127.0.0.53:53", "example.com", nil))
+
+	// the split DNS resolver of the longest domain of the host
+	resolvers = parseScutil([]byte(scutilOutput))
+	assert.Equal(t, "192.168.1.1:53", pickServer("127.0.0.53:53", "example.com.", resolvers))
+	assert.Equal(t, "10.8.0.1:53", pickServer("127.0.0.53:53", "svc.corp.example.com.", resolvers))
+	assert.Equal(t, "10.8.0.2:53", pickServer("10.8.0.2:53", "svc.corp.example.com.", resolvers))
+	assert.Equal(t, "10.9.0.1:53", pickServer("127.0.0.53:53", "svc.EU.corp.example.com", resolvers))
+	assert.Equal(t, "192.168.1.1:53", pickServer("127.0.0.53:53", "notcorp.example.com", resolvers))
+	assert.Equal(t, []string{"10.0.0.53:53", "[::1]:53"}, withDNSPort([]string{"10.0.0.53", "::1"}))
+}
+
+func TestSystemServers(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the system servers of the test host are only known on linux")
+	}
+
+	conf, err := readResolvConf(DefaultResolvConf)
+	servers, serr := SystemServers()
+	assert.Equal(t, err == nil, serr == nil)
+	assert.Equal(t, withDNSPort(conf.nameservers), servers)
+	assert.Equal(t, "10.0.0.1:53", systemServer("10.0.0.1:53", "example.com"))
+}
+
+func TestPrefetchSystemServers(t *testing.T) {
+	if usesSystemServers() {
+		t.Skip("the system servers are only left alone off macOS")
+	}
+
+	// nothing is read where the dialer doesn't pick the system servers
+	prefetchSystemServers()
+	systemCache.Lock()
+	defer systemCache.Unlock()
+	assert.False(t, systemCache.loaded)
+}
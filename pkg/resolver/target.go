@@ -0,0 +1,160 @@
+package resolver
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// QuerySRV is the query type resolving the target through its SRV records
+const QuerySRV = "srv"
+
+// Scheme names suggested for the builders, any other name works the
+// same, they just keep the target strings consistent across the code
+const (
+	// SchemeDNS resolves the A/AAAA records of the target
+	SchemeDNS = "dm-dns"
+	// SchemeSRV resolves the SRV records of the target without ?type=srv
+	SchemeSRV = "dm-srv"
+	// SchemeAgent resolves the target through the resolver agent, see the agent package
+	SchemeAgent = "dm-agent"
+)
+
+// FormatTarget returns the target string given to grpc.Dial in the
+// scheme://authority/endpoint format, the authority is usually empty
+// or the DNS server to query, e.g. FormatTarget(SchemeDNS, "", "my-service:8080")
+func FormatTarget(scheme, authority, endpoint string) string {
+	return scheme + "://" + authority + "/" + endpoint
+}
+
+// Target identifies a host (domain or IP) and the port the resolved
+// addresses are published with, the rest of the fields are optional and
+// follow the format scheme://authority/host:port?type=srv&key=value
+// where the authority is the DNS server to query
+type Target struct {
+	Scheme    string
+	Authority string
+	Host      string
+	Port      string
+	QueryType string // empty for A/AAAA records or QuerySRV
+	Params    map[string]string
+}
+
+// ParseTarget parses a target in the scheme://authority/host:port?params
+// format, everything but the host is optional, e.g. localhost:8080 or
+// dns://10.0.0.53/my-service?type=srv&service=grpc&proto=tcp
+func ParseTarget(s string) (Target, error) {
+	t := Target{}
+	if i := strings.Index(s, "?"); i >= 0 {
+		values, err := url.ParseQuery(s[i+1:])
+		if err != nil {
+			return t, fmt.Errorf("invalid target params %s: %v", s, err)
+		}
+		s = s[:i]
+
+		t.Params = map[string]string{}
+		for k := range values {
+			t.Params[k] = values.Get(k)
+		}
+		t.QueryType = strings.ToLower(t.Params["type"])
+		delete(t.Params, "type")
+	}
+
+	if i := strings.Index(s, "://"); i >= 0 {
+		t.Scheme, s = s[:i], s[i+3:]
+		j := strings.Index(s, "/")
+		if j < 0 {
+			return t, fmt.Errorf("invalid target %s, missing endpoint", s)
+		}
+		t.Authority, s = s[:j], s[j+1:]
+	}
+
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		// no port, e.g. SRV targets
+		host = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	}
+	t.Host, t.Port = host, port
+
+	if t.Host == "" {
+		return t, fmt.Errorf("invalid target %s, missing host", s)
+	}
+
+	return t, nil
+}
+
+// SplitTarget returns the host and the port of a target or an address,
+// the scheme and authority prefix and the params are dropped, the IPv6
+// brackets removed and the port is empty if missing, an error is
+// returned if there is no host
+func SplitTarget(s string) (host, port string, err error) {
+	t, err := ParseTarget(s)
+	return t.Host, t.Port, err
+}
+
+// JoinAddr returns the IP and the port in the host:port format, the
+// IPv6 addresses in brackets
+func JoinAddr(ip net.IP, port string) string {
+	return net.JoinHostPort(ip.String(), port)
+}
+
+// FromResolverTarget converts the target given by gRPC to the Build function
+func FromResolverTarget(rt resolver.Target) (Target, error) {
+	t, err := ParseTarget(rt.Endpoint)
+	t.Scheme, t.Authority = rt.Scheme, rt.Authority
+	return t, err
+}
+
+// String returns the target in the host:port format
+func (t Target) String() string {
+	if t.Port == "" {
+		return t.Host
+	}
+
+	return net.JoinHostPort(t.Host, t.Port)
+}
+
+// URI returns the target in the scheme://authority/host:port?params format,
+// omitting the empty parts
+func (t Target) URI() string {
+	s := t.String()
+	if t.Scheme != "" {
+		s = FormatTarget(t.Scheme, t.Authority, s)
+	}
+
+	values := url.Values{}
+	for k, v := range t.Params {
+		values.Set(k, v)
+	}
+	if t.QueryType != "" {
+		values.Set("type", t.QueryType)
+	}
+	if len(values) > 0 {
+		s += "?" + values.Encode()
+	}
+
+	return s
+}
+
+// Options returns the resolver options described by the target,
+// the authority as DNS server, the SRV query type or scheme and the
+// ipv4_port and ipv6_port params as family ports
+func (t Target) Options() []Option {
+	opts := []Option{}
+	if t.Authority != "" {
+		opts = append(opts, WithDNSServer(t.Authority))
+	}
+
+	if t.QueryType == QuerySRV || t.Scheme == SchemeSRV {
+		opts = append(opts, WithSRV(t.Params["service"], t.Params["proto"], nil))
+	}
+
+	if t.Params["ipv4_port"] != "" || t.Params["ipv6_port"] != "" {
+		opts = append(opts, WithFamilyPorts(t.Params["ipv4_port"], t.Params["ipv6_port"]))
+	}
+
+	return opts
+}
@@ -0,0 +1,107 @@
+package resolver
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestTargetString(t *testing.T) {
+	assert.Equal(t, "localhost:8080", Target{Host: "localhost", Port: "8080"}.String())
+	assert.Equal(t, "[::1]:8080", Target{Host: "::1", Port: "8080"}.String())
+	assert.Equal(t, "my-service", Target{Host: "my-service"}.String())
+}
+
+func TestParseTarget(t *testing.T) {
+	tg, err := ParseTarget("localhost:8080")
+	assert.Nil(t, err)
+	assert.Equal(t, Target{Host: "localhost", Port: "8080"}, tg)
+
+	tg, err = ParseTarget("[::1]:8080")
+	assert.Nil(t, err)
+	assert.Equal(t, Target{Host: "::1", Port: "8080"}, tg)
+
+	tg, err = ParseTarget("dns://10.0.0.53/my-service?type=SRV&service=grpc&proto=tcp")
+	assert.Nil(t, err)
+	assert.Equal(t, Target{Scheme: "dns", Authority: "10.0.0.53", Host: "my-service", QueryType: QuerySRV,
+		Params: map[string]string{"service": "grpc", "proto": "tcp"}}, tg)
+	assert.Equal(t, "dns://10.0.0.53/my-service?proto=tcp&service=grpc&type=srv", tg.URI())
+
+	tg, err = ParseTarget("my-schema:///localhost:8080")
+	assert.Nil(t, err)
+	assert.Equal(t, Target{Scheme: "my-schema", Host: "localhost", Port: "8080"}, tg)
+	assert.Equal(t, "my-schema:///localhost:8080", tg.URI())
+
+	_, err = ParseTarget("dns://10.0.0.53")
+	assert.NotNil(t, err)
+	_, err = ParseTarget("localhost:8080?%zz")
+	assert.NotNil(t, err)
+	_, err = ParseTarget(":8080")
+	assert.NotNil(t, err)
+}
+
+func TestFromResolverTarget(t *testing.T) {
+	tg, err := FromResolverTarget(resolver.Target{Scheme: "my-schema", Authority: "10.0.0.53:53", Endpoint: "localhost:8080?type=srv"})
+	assert.Nil(t, err)
+	assert.Equal(t, "my-schema", tg.Scheme)
+	assert.Equal(t, "10.0.0.53:53", tg.Authority)
+	assert.Equal(t, QuerySRV, tg.QueryType)
+}
+
+func TestTargetOptions(t *testing.T) {
+	assert.Equal(t, 0, len(Target{Host: "localhost", Port: "8080"}.Options()))
+
+	tg := Target{Authority: "10.0.0.53", Host: "localhost", QueryType: QuerySRV, Params: map[string]string{"service": "grpc", "proto": "tcp"}}
+	r := NewResolver(tg.Host, tg.Port, false, &refreshRate, nil, tg.Options()...)
+	assert.Equal(t, "10.0.0.53:53", r.dnsServer)
+	assert.True(t, r.srv)
+	assert.Equal(t, "grpc", r.srvService)
+	assert.Equal(t, "tcp", r.srvProto)
+
+	tg, err := ParseTarget("localhost:443?ipv6_port=9443")
+	assert.Nil(t, err)
+	r = NewResolver(tg.Host, tg.Port, false, &refreshRate, nil, tg.Options()...)
+	assert.Equal(t, "", r.ipv4Port)
+	assert.Equal(t, "9443", r.ipv6Port)
+}
+
+func TestFormatTarget(t *testing.T) {
+	assert.Equal(t, "dm-dns:///my-service:8080", FormatTarget(SchemeDNS, "", "my-service:8080"))
+	assert.Equal(t, "dm-srv://10.0.0.53/my-service", FormatTarget(SchemeSRV, "10.0.0.53", "my-service"))
+
+	tg, err := ParseTarget(FormatTarget(SchemeSRV, "", "my-service"))
+	assert.Nil(t, err)
+	assert.Equal(t, SchemeSRV, tg.Scheme)
+	r := NewResolver(tg.Host, tg.Port, false, &refreshRate, nil, tg.Options()...)
+	assert.True(t, r.srv)
+}
+
+func TestSplitTarget(t *testing.T) {
+	cases := []struct{ in, host, port string }{
+		{"localhost:8080", "localhost", "8080"},
+		{"[::1]:8080", "::1", "8080"},
+		{"[::1]", "::1", ""},
+		{"::1", "::1", ""},
+		{"my-service", "my-service", ""},
+		{"dm-dns://10.0.0.53/my-service:8080?type=srv", "my-service", "8080"},
+	}
+	for _, c := range cases {
+		host, port, err := SplitTarget(c.in)
+		assert.Nil(t, err, c.in)
+		assert.Equal(t, c.host, host, c.in)
+		assert.Equal(t, c.port, port, c.in)
+	}
+
+	_, _, err := SplitTarget(":8080")
+	assert.NotNil(t, err)
+	_, _, err = SplitTarget("dm-dns://missing-endpoint")
+	assert.NotNil(t, err)
+}
+
+func TestJoinAddr(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:8080", JoinAddr(net.ParseIP("10.0.0.1"), "8080"))
+	assert.Equal(t, "[::1]:8080", JoinAddr(net.ParseIP("::1"), "8080"))
+	assert.Equal(t, "10.0.0.1:8080", JoinAddr(net.ParseIP("::ffff:10.0.0.1"), "8080"))
+}
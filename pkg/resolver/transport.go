@@ -0,0 +1,99 @@
+package resolver
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/resolver"
+)
+
+// Transport tells how an address expects to be connected to
+type Transport int
+
+const (
+	// TransportDefault uses the credentials of the ClientConn
+	TransportDefault Transport = iota
+	// TransportTLS requires TLS
+	TransportTLS
+	// TransportPlaintext requires no transport security
+	TransportPlaintext
+)
+
+// TransportHint returns the transport of an address, e.g. taken from
+// Consul metadata or a port convention, useful in heterogeneous fleets
+// mid-way through a TLS migration
+type TransportHint func(addr string) Transport
+
+// PlaintextPorts returns a hint marking the addresses on the given ports
+// as plaintext and the rest as TLS, an address missing from the list is
+// never sent in the clear
+func PlaintextPorts(ports ...string) TransportHint {
+	return func(addr string) Transport {
+		_, port, err := net.SplitHostPort(addr)
+		if err == nil && contains(ports, port) {
+			return TransportPlaintext
+		}
+
+		return TransportTLS
+	}
+}
+
+type transportKey struct{}
+
+// TransportFrom returns the transport attached to the address,
+// TransportDefault if none
+func TransportFrom(addr resolver.Address) Transport {
+	t, _ := addr.Attributes.Value(transportKey{}).(Transport)
+	return t
+}
+
+// attachTransport attaches the transport given by the hint to the addresses
+func (r *DomainResolver) attachTransport(addrs []resolver.Address) []resolver.Address {
+	if r.transportHint == nil {
+		return addrs
+	}
+
+	for i, a := range addrs {
+		if t := r.transportHint(a.Addr); t != TransportDefault {
+			addrs[i].Attributes = a.Attributes.WithValues(transportKey{}, t)
+		}
+	}
+
+	return addrs
+}
+
+// HintedCredentials returns transport credentials doing the handshake of
+// the given TLS credentials for every address but the ones marked as
+// plaintext by WithTransportHint, those are used without transport
+// security. The transport is read from the attributes of the dialed
+// address, an address without it gets TLS
+func HintedCredentials(tls credentials.TransportCredentials) credentials.TransportCredentials {
+	return &hintedCredentials{TransportCredentials: tls}
+}
+
+type hintedCredentials struct {
+	credentials.TransportCredentials
+}
+
+// plaintextInfo is the auth info of the plaintext connections
+type plaintextInfo struct {
+	credentials.CommonAuthInfo
+}
+
+func (plaintextInfo) AuthType() string {
+	return "insecure"
+}
+
+func (c *hintedCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	attrs := credentials.ClientHandshakeInfoFromContext(ctx).Attributes
+	if t, _ := attrs.Value(transportKey{}).(Transport); t == TransportPlaintext {
+		return rawConn, plaintextInfo{credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity}}, nil
+	}
+
+	return c.TransportCredentials.ClientHandshake(ctx, authority, rawConn)
+}
+
+func (c *hintedCredentials) Clone() credentials.TransportCredentials {
+	return &hintedCredentials{TransportCredentials: c.TransportCredentials.Clone()}
+}
@@ -0,0 +1,111 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cperez08/dm-resolver/pkg/resolver/resolvertest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/resolver"
+)
+
+// fakeTLS records the handshakes instead of doing them
+type fakeTLS struct {
+	credentials.TransportCredentials
+	handshakes int
+}
+
+func (f *fakeTLS) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	f.handshakes++
+	return rawConn, nil, nil
+}
+
+func (f *fakeTLS) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls"}
+}
+
+func (f *fakeTLS) Clone() credentials.TransportCredentials {
+	return f
+}
+
+func TestPlaintextPorts(t *testing.T) {
+	hint := PlaintextPorts("80", "8080")
+	assert.Equal(t, TransportPlaintext, hint("10.0.0.1:80"))
+	assert.Equal(t, TransportPlaintext, hint("[::1]:8080"))
+	assert.Equal(t, TransportTLS, hint("10.0.0.1:443"))
+	assert.Equal(t, TransportTLS, hint("no-port"))
+}
+
+func TestAttachTransport(t *testing.T) {
+	hint := func(addr string) Transport {
+		if addr == "10.0.0.2:80" {
+			return TransportDefault
+		}
+		return PlaintextPorts("80")(addr)
+	}
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithTransportHint(hint))
+	addrs := r.attachTransport([]resolver.Address{{Addr: "10.0.0.1:443"}, {Addr: "10.0.0.1:80"}, {Addr: "10.0.0.2:80"}})
+	assert.Equal(t, TransportTLS, TransportFrom(addrs[0]))
+	assert.Equal(t, TransportPlaintext, TransportFrom(addrs[1]))
+	assert.Equal(t, TransportDefault, TransportFrom(addrs[2]))
+	assert.Nil(t, addrs[2].Attributes)
+
+	r = NewResolver("127.0.0.1", "443", false, &refreshRate, nil, WithTransportHint(PlaintextPorts("80")), WithPublishers(PublisherFunc(func(s Snapshot) error {
+		assert.Equal(t, TransportTLS, TransportFrom(s.State.Addresses[0]))
+		return nil
+	})))
+	r.StartResolver()
+}
+
+func TestAttachTransportKeepsSubConns(t *testing.T) {
+	cc := resolvertest.NewClientConn()
+	r := NewGRPCResolver(cc, "churn.test", "8080", false, nil, WithHosts(map[string][]string{"churn.test": nil}, true),
+		WithTransportHint(PlaintextPorts("8080")))
+	defer r.Close()
+
+	assertSubConnsKept(t, r, cc, []string{"10.0.0.1"}, []string{"10.0.0.1", "10.0.0.2"})
+}
+
+func TestHintedCredentials(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	gs := grpc.NewServer()
+	go gs.Serve(lis)
+	defer gs.Stop()
+	_, port, _ := net.SplitHostPort(lis.Addr().String())
+
+	dial := func(hint TransportHint) *fakeTLS {
+		tls := &fakeTLS{}
+		rate := time.Duration(3600)
+		builder := NewDomainResolverBuilder("hinted", "127.0.0.1", port, false, &rate, WithTransportHint(hint))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// the fake TLS handshake leaves the connection as is, so both work
+		conn, err := grpc.DialContext(ctx, "hinted:///127.0.0.1:"+port, grpc.WithResolvers(builder), grpc.WithBlock(),
+			grpc.WithTransportCredentials(HintedCredentials(tls)))
+		assert.Nil(t, err)
+		conn.Close()
+		return tls
+	}
+
+	// the hint is read from the dialed address, not from the connection
+	assert.Equal(t, 0, dial(PlaintextPorts(port)).handshakes)
+	assert.Equal(t, 1, dial(PlaintextPorts("1")).handshakes)
+
+	// no transport attached, TLS
+	tls := &fakeTLS{}
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+	_, _, err = HintedCredentials(tls).ClientHandshake(context.Background(), "svc", conn)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, tls.handshakes)
+
+	clone := HintedCredentials(&fakeTLS{}).Clone().(*hintedCredentials)
+	assert.NotNil(t, clone.TransportCredentials)
+}
@@ -0,0 +1,57 @@
+package resolver
+
+import (
+	"errors"
+	"log"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// validate drops the addresses that gRPC would fail to dial,
+// the number of dropped addresses is kept for the Status
+func (r *DomainResolver) validate(addrs []resolver.Address) []resolver.Address {
+	valid := make([]resolver.Address, 0, len(addrs))
+	invalid := 0
+	for _, a := range addrs {
+		if err := validAddress(a.Addr); err != nil {
+			log.Printf("[grpc-resolver]: dropping invalid address %s: %v", a.Addr, err)
+			invalid++
+			continue
+		}
+		valid = append(valid, a)
+	}
+
+	if invalid > 0 {
+		r.m.Lock()
+		r.invalid += invalid
+		r.m.Unlock()
+	}
+
+	return valid
+}
+
+// validAddress checks the address is a valid host:port join with
+// a port in the range 1-65535 and, in case the host is an IP,
+// that it is neither unspecified nor multicast
+func validAddress(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	if host == "" {
+		return errors.New("empty host")
+	}
+
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return errors.New("port out of range")
+	}
+
+	if ip := net.ParseIP(host); ip != nil && (ip.IsUnspecified() || ip.IsMulticast()) {
+		return errors.New("unspecified or multicast ip")
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestValidAddress(t *testing.T) {
+	assert.Nil(t, validAddress("127.0.0.1:8080"))
+	assert.Nil(t, validAddress("[::1]:8080"))
+	assert.Nil(t, validAddress("my-service:443"))
+	assert.NotNil(t, validAddress("127.0.0.1"))
+	assert.NotNil(t, validAddress(":8080"))
+	assert.NotNil(t, validAddress("127.0.0.1:0"))
+	assert.NotNil(t, validAddress("127.0.0.1:65536"))
+	assert.NotNil(t, validAddress("127.0.0.1:http"))
+	assert.NotNil(t, validAddress("0.0.0.0:8080"))
+	assert.NotNil(t, validAddress("[::]:8080"))
+	assert.NotNil(t, validAddress("224.0.0.1:8080"))
+}
+
+func TestValidate(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	addrs := r.validate([]resolver.Address{{Addr: "127.0.0.1:8080"}, {Addr: "0.0.0.0:8080"}, {Addr: "127.0.0.1:0"}})
+	assert.Equal(t, []resolver.Address{{Addr: "127.0.0.1:8080"}}, addrs)
+	assert.Equal(t, 2, r.Status().Invalid)
+}
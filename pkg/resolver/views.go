@@ -0,0 +1,87 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// ViewMode defines how the results of the views are combined
+type ViewMode int
+
+const (
+	// ViewUnion publishes the addresses found in any of the views
+	ViewUnion ViewMode = iota
+	// ViewIntersection publishes only the addresses found in all the views
+	ViewIntersection
+	// ViewPriority publishes the result of the first view returning
+	// addresses, e.g. the internal view with the external one as fallback
+	ViewPriority
+)
+
+// lookUpViews looks up the name against all the views concurrently and
+// combines the results according to the view mode
+func (r *DomainResolver) lookUpViews(ctx context.Context, name string) []string {
+	results := make([][]string, len(r.views))
+	var wg sync.WaitGroup
+	for i, v := range r.views {
+		wg.Add(1)
+		go func(i int, v *net.Resolver) {
+			defer wg.Done()
+			results[i] = lookUpByIP(ctx, v, name)
+		}(i, v)
+	}
+	wg.Wait()
+
+	return combineViews(r.viewMode, results)
+}
+
+// combineViews merges the results of the views keeping the order
+// in which the addresses were first seen
+func combineViews(mode ViewMode, results [][]string) []string {
+	merged := []string{}
+	switch mode {
+	case ViewPriority:
+		for _, res := range results {
+			if len(res) > 0 {
+				return res
+			}
+		}
+	case ViewIntersection:
+		if len(results) == 0 {
+			return merged
+		}
+		for _, ip := range results[0] {
+			inAll := true
+			for _, res := range results[1:] {
+				if !contains(res, ip) {
+					inAll = false
+					break
+				}
+			}
+			if inAll && !contains(merged, ip) {
+				merged = append(merged, ip)
+			}
+		}
+	default:
+		for _, res := range results {
+			for _, ip := range res {
+				if !contains(merged, ip) {
+					merged = append(merged, ip)
+				}
+			}
+		}
+	}
+
+	return merged
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
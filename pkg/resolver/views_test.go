@@ -0,0 +1,25 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombineViews(t *testing.T) {
+	internal := []string{"10.0.0.1", "10.0.0.2"}
+	external := []string{"10.0.0.2", "1.2.3.4"}
+
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2", "1.2.3.4"}, combineViews(ViewUnion, [][]string{internal, external}))
+	assert.Equal(t, []string{"10.0.0.2"}, combineViews(ViewIntersection, [][]string{internal, external}))
+	assert.Equal(t, internal, combineViews(ViewPriority, [][]string{internal, external}))
+	assert.Equal(t, external, combineViews(ViewPriority, [][]string{{}, external}))
+	assert.Equal(t, []string{}, combineViews(ViewIntersection, nil))
+}
+
+func TestWithViews(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithViews(ViewPriority, "10.0.0.53", "8.8.8.8:5353"))
+	assert.Equal(t, []string{"10.0.0.53:53", "8.8.8.8:5353"}, r.viewServers)
+	assert.Equal(t, 2, len(r.views))
+	assert.Equal(t, ViewPriority, r.viewMode)
+}
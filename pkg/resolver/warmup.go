@@ -0,0 +1,92 @@
+package resolver
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultWarmUpConcurrency is the number of concurrent warm-ups if none is set
+const DefaultWarmUpConcurrency = 4
+
+// WarmUpHook is invoked when an address first appears, e.g. to open a
+// connection, do the TLS handshake or prime a cache, the context is
+// cancelled if the address is removed or the resolver closed meanwhile,
+// see InfoFromContext
+type WarmUpHook func(ctx context.Context, addr string)
+
+// warmUp runs the hook of the added addresses with bounded concurrency
+type warmUp struct {
+	ctx     context.Context // parent of the warm-up contexts, the one of the resolver
+	hook    WarmUpHook
+	sem     chan struct{}
+	m       sync.Mutex
+	cancels map[string]context.CancelFunc // warm-ups running or waiting, by address
+	closed  bool
+}
+
+func newWarmUp(hook WarmUpHook, concurrency int) *warmUp {
+	if concurrency <= 0 {
+		concurrency = DefaultWarmUpConcurrency
+	}
+
+	return &warmUp{ctx: context.Background(), hook: hook, sem: make(chan struct{}, concurrency), cancels: map[string]context.CancelFunc{}}
+}
+
+// update starts the warm-up of the added addresses and cancels the
+// ones of the removed addresses
+func (w *warmUp) update(added, removed []string) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	for _, a := range removed {
+		if cancel, ok := w.cancels[a]; ok {
+			cancel()
+			delete(w.cancels, a)
+		}
+	}
+
+	if w.closed {
+		return
+	}
+
+	for _, a := range added {
+		if _, ok := w.cancels[a]; ok {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(w.ctx)
+		w.cancels[a] = cancel
+		go w.run(ctx, a)
+	}
+}
+
+// run waits for a slot and invokes the hook unless cancelled meanwhile
+func (w *warmUp) run(ctx context.Context, addr string) {
+	select {
+	case <-ctx.Done():
+		return
+	case w.sem <- struct{}{}:
+	}
+
+	defer func() { <-w.sem }()
+	if ctx.Err() == nil {
+		w.hook(ctx, addr)
+	}
+
+	w.m.Lock()
+	if w.cancels[addr] != nil && ctx.Err() == nil {
+		w.cancels[addr]()
+		delete(w.cancels, addr)
+	}
+	w.m.Unlock()
+}
+
+// close cancels every warm-up
+func (w *warmUp) close() {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.closed = true
+	for a, cancel := range w.cancels {
+		cancel()
+		delete(w.cancels, a)
+	}
+}
@@ -0,0 +1,72 @@
+package resolver
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmUp(t *testing.T) {
+	var running, max int32
+	m := sync.Mutex{}
+	warmed := []string{}
+	release := make(chan struct{})
+	w := newWarmUp(func(ctx context.Context, addr string) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		if n > atomic.LoadInt32(&max) {
+			atomic.StoreInt32(&max, n)
+		}
+
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return
+		}
+
+		m.Lock()
+		warmed = append(warmed, addr)
+		m.Unlock()
+	}, 2)
+
+	w.update([]string{"1", "2", "3"}, nil)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&running) == 2 }, time.Second, time.Millisecond)
+
+	// the removed addresses are cancelled, running or waiting
+	w.update(nil, []string{"1", "2", "3"})
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&running) == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&max))
+
+	w.update([]string{"4"}, nil)
+	release <- struct{}{}
+	assert.Eventually(t, func() bool {
+		m.Lock()
+		defer m.Unlock()
+		return len(warmed) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"4"}, warmed)
+
+	w.close()
+	w.update([]string{"5"}, nil)
+	assert.Equal(t, 0, len(w.cancels))
+}
+
+func TestWithWarmUp(t *testing.T) {
+	warmed := make(chan string, 10)
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithWarmUp(func(ctx context.Context, addr string) { warmed <- addr }, 0))
+	assert.Equal(t, DefaultWarmUpConcurrency, cap(r.warmUp.sem))
+	r.StartResolver()
+	defer r.Close()
+
+	assert.Equal(t, r.Addresses[0], <-warmed)
+	r.m.Lock()
+	r.Addresses = append(r.Addresses, "127.0.0.9:8080")
+	e := r.recordChange([]string{"127.0.0.9:8080"}, nil)
+	r.m.Unlock()
+	r.announceChange(e)
+	assert.Contains(t, r.Addresses, <-warmed)
+}
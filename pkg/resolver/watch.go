@@ -0,0 +1,78 @@
+package resolver
+
+// Watch returns a channel that always holds the latest address list, if
+// the consumer didn't read the previous list it is replaced by the newest
+// one, so the watcher is never blocked by slow consumers, the current
+// list is sent right away and the channel is closed on Close
+func (r *DomainResolver) Watch() <-chan []string {
+	r.access()
+	ch := make(chan []string, 1)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.closed {
+		close(ch)
+		return ch
+	}
+
+	if len(r.Addresses) > 0 {
+		ch <- append([]string{}, r.Addresses...)
+	}
+
+	r.subscribers = append(r.subscribers, ch)
+	r.storeSubscribers()
+	return ch
+}
+
+// Unwatch stops sending lists to a channel returned by Watch and closes it
+func (r *DomainResolver) Unwatch(ch <-chan []string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i, sub := range r.subscribers {
+		if sub == ch {
+			r.subscribers = append(r.subscribers[:i], r.subscribers[i+1:]...)
+			r.storeSubscribers()
+			close(sub)
+			return
+		}
+	}
+}
+
+// storeSubscribers copies the subscribers for isIdle, it's expected to
+// be called with the lock held
+func (r *DomainResolver) storeSubscribers() {
+	r.watching.Store(append([]chan []string{}, r.subscribers...))
+}
+
+// sendLatest replaces the unread list of every subscriber with the
+// current one, it's expected to be called with the lock held
+func (r *DomainResolver) sendLatest() {
+	if r.closed {
+		return
+	}
+
+	for _, ch := range r.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- append([]string{}, r.Addresses...)
+	}
+}
+
+// closeSubscribers closes the channels returned by Watch
+func (r *DomainResolver) closeSubscribers() {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.closed {
+		return
+	}
+
+	r.closed = true
+	for _, ch := range r.subscribers {
+		close(ch)
+	}
+	r.subscribers = nil
+	r.storeSubscribers()
+	r.closeEventSubscribers()
+}
@@ -0,0 +1,46 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil)
+	ch := r.Watch()
+	assert.Equal(t, 0, len(ch))
+
+	r.StartResolver()
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.getState(context.Background())
+	r.Addresses = []string{"127.0.0.3:8080"}
+	r.getState(context.Background())
+
+	// only the latest list is kept
+	assert.Equal(t, 1, len(ch))
+	assert.Equal(t, r.Addresses, <-ch)
+
+	// the current list is sent right away to new subscribers
+	ch2 := r.Watch()
+	assert.Equal(t, r.Addresses, <-ch2)
+
+	r.Close()
+	_, ok := <-ch
+	assert.False(t, ok)
+	_, ok = <-r.Watch()
+	assert.False(t, ok)
+}
+
+func TestUnwatch(t *testing.T) {
+	r := NewResolver("127.0.0.1", "8080", false, &refreshRate, nil)
+	ch := r.Watch()
+	<-ch
+
+	r.Unwatch(ch)
+	r.Unwatch(ch)
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Equal(t, 0, len(r.subscribers))
+}
@@ -0,0 +1,195 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	webhookQueueSize   = 100
+	webhookBaseBackoff = 100 * time.Millisecond
+)
+
+// webhook POSTs the change, health and warning events to an HTTP
+// endpoint, the events are
+// queued and sent in order by a single worker so a slow endpoint never
+// blocks the watcher, events are dropped when the queue is full
+type webhook struct {
+	url     string
+	retries int
+	client  *http.Client
+	queue   chan Event
+	ctx     context.Context // cancelled by close, aborts the POST in flight
+	cancel  context.CancelFunc
+	done    chan struct{}
+	exited  chan struct{} // closed when the worker returns
+	dropped int64         // events dropped because the queue was full
+	start   sync.Once
+	stop    sync.Once
+
+	// delta mode, only used by the worker
+	fullEvery int    // deltas sent between full events, 0 disables the deltas
+	last      uint64 // version of the last event delivered, 0 if it failed
+	sinceFull int
+}
+
+func newWebhook(url string, retries int) *webhook {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhook{
+		url:     url,
+		retries: retries,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan Event, webhookQueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+}
+
+// enqueue queues the event starting the worker the first time
+func (w *webhook) enqueue(e Event) {
+	w.start.Do(func() { go w.run() })
+
+	select {
+	case <-w.done:
+	case w.queue <- e:
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+		log.Println("[grpc-resolver]: webhook queue full, dropping event version ", e.Version)
+	}
+}
+
+// close stops the worker aborting the POST in flight and waits for it
+// to return, the queued events are discarded
+func (w *webhook) close() {
+	w.stop.Do(func() {
+		close(w.done)
+		w.cancel()
+	})
+	w.start.Do(func() { close(w.exited) }) // the worker never started
+	<-w.exited
+}
+
+// wait waits for the worker to return after close, the number of
+// events dropped, the discarded ones included, is returned
+func (w *webhook) wait(ctx context.Context) (int, error) {
+	select {
+	case <-w.exited:
+	case <-ctx.Done():
+		return int(atomic.LoadInt64(&w.dropped)), ctx.Err()
+	}
+
+	discarded := 0
+	for {
+		select {
+		case <-w.queue:
+			discarded++
+		default:
+			return int(atomic.LoadInt64(&w.dropped)) + discarded, nil
+		}
+	}
+}
+
+func (w *webhook) run() {
+	defer close(w.exited)
+	for {
+		select {
+		case <-w.done:
+			return
+		case e := <-w.queue:
+			// select picks at random when both are ready
+			select {
+			case <-w.done:
+				atomic.AddInt64(&w.dropped, 1)
+				return
+			default:
+			}
+
+			if w.fullEvery > 0 && e.Type == EventChange {
+				e = w.delta(e)
+			}
+
+			err := w.send(e)
+			if err != nil {
+				log.Println("[grpc-resolver]: error sending webhook ", err)
+			}
+			if e.Type == EventChange {
+				w.delivered(e, err)
+			}
+		}
+	}
+}
+
+// delta turns the event into a delta against the last version delivered,
+// a full event is sent every fullEvery deltas and whenever the previous
+// version was dropped or not delivered, so the receiver never misses a change
+func (w *webhook) delta(e Event) Event {
+	if w.last == 0 || e.Version != w.last+1 || w.sinceFull >= w.fullEvery {
+		w.sinceFull = 0
+		return e
+	}
+
+	w.sinceFull++
+	e.Base = w.last
+	e.Addresses = nil
+	return e
+}
+
+// delivered records the version of the event for the next delta
+func (w *webhook) delivered(e Event, err error) {
+	if err != nil {
+		w.last = 0
+		return
+	}
+
+	w.last = e.Version
+}
+
+// send POSTs the event retrying with exponential backoff
+func (w *webhook) send(e Event) error {
+	body, err := MarshalEvent(e)
+	if err != nil {
+		return err
+	}
+
+	backoff := webhookBaseBackoff
+	for attempt := 0; ; attempt++ {
+		if err = w.post(body); err == nil || attempt >= w.retries {
+			return err
+		}
+
+		select {
+		case <-w.done:
+			return err
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
+
+func (w *webhook) post(body []byte) error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, w.url)
+	}
+
+	return nil
+}
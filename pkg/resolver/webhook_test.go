@@ -0,0 +1,79 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook(t *testing.T) {
+	var calls int32
+	events := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		e := Event{}
+		json.NewDecoder(req.Body).Decode(&e)
+		events <- e
+	}))
+	defer srv.Close()
+
+	r := NewResolver("localhost", "8080", false, &refreshRate, nil, WithWebhook(srv.URL, 1))
+	r.StartResolver()
+	r.Addresses = []string{"127.0.0.2:8080"}
+	r.getState(context.Background())
+
+	// the first attempt fails and the event is retried
+	e := <-events
+	assert.Equal(t, EventChange, e.Type)
+	assert.Equal(t, "localhost", e.Target)
+	assert.Equal(t, uint64(1), e.Version)
+	assert.Equal(t, []string{"127.0.0.2:8080"}, e.Removed)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	r.Close()
+}
+
+func TestWebhookSendErrors(t *testing.T) {
+	w := newWebhook("http://127.0.0.1:0", 0)
+	assert.NotNil(t, w.send(Event{}))
+
+	w.close()
+	w.close()
+	w.enqueue(Event{})
+}
+
+func TestWebhookDelta(t *testing.T) {
+	w := newWebhook("", 0)
+	w.fullEvery = 2
+
+	// the first event is full, the next two are deltas
+	e := w.delta(Event{Version: 1, Addresses: []string{"a"}})
+	assert.Equal(t, []string{"a"}, e.Addresses)
+	w.delivered(e, nil)
+	e = w.delta(Event{Version: 2, Addresses: []string{"a", "b"}, Added: []string{"b"}})
+	assert.Nil(t, e.Addresses)
+	assert.Equal(t, uint64(1), e.Base)
+	w.delivered(e, nil)
+	e = w.delta(Event{Version: 3, Addresses: []string{"b"}, Removed: []string{"a"}})
+	assert.Equal(t, uint64(2), e.Base)
+	w.delivered(e, nil)
+	e = w.delta(Event{Version: 4, Addresses: []string{"c"}})
+	assert.Equal(t, []string{"c"}, e.Addresses)
+	assert.Equal(t, uint64(0), e.Base)
+
+	// a failed event or a gap in the versions sends the full list
+	w.delivered(e, assert.AnError)
+	e = w.delta(Event{Version: 5, Addresses: []string{"d"}})
+	assert.Equal(t, []string{"d"}, e.Addresses)
+	w.delivered(e, nil)
+	e = w.delta(Event{Version: 7, Addresses: []string{"e"}})
+	assert.Equal(t, []string{"e"}, e.Addresses)
+}